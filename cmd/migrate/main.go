@@ -1,25 +1,48 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/nrzaman/baos-birthday-bot/internal/database"
 	"github.com/nrzaman/baos-birthday-bot/util"
 )
 
 func main() {
+	// Schema subcommands; without one, birthdays are imported from JSON
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "status":
+			runStatus(os.Args[2:])
+			return
+		case "up":
+			runUp(os.Args[2:])
+			return
+		}
+	}
+
+	importJSON()
+}
+
+// importJSON imports birthdays from a JSON file into the database
+func importJSON() {
 	jsonPath := flag.String("json", "./config/birthdays.json", "Path to birthdays.json file")
 	dbPath := flag.String("db", "./birthdays.db", "Path to SQLite database file")
+	guildID := flag.String("guild", "", "Discord server ID to import into (empty: assigned when the bot starts with DISCORD_BIRTHDAY_CHANNEL_ID)")
+	channelID := flag.String("channel", "", "Announcement channel ID to configure for -guild")
+	timezone := flag.String("timezone", "UTC", "Timezone to configure for -guild, e.g. America/New_York")
 	flag.Parse()
 
 	fmt.Println("Birthday Bot - JSON to Database Migration Tool")
 	fmt.Println("===============================================")
 	fmt.Printf("JSON file: %s\n", *jsonPath)
-	fmt.Printf("Database:  %s\n\n", *dbPath)
+	fmt.Printf("Database:  %s\n", *dbPath)
+	fmt.Printf("Server:    %s\n\n", *guildID)
 
 	// Read JSON file
 	fmt.Println("Reading JSON file...")
@@ -36,6 +59,18 @@ func main() {
 
 	fmt.Printf("Found %d birthdays in JSON file\n\n", len(people.People))
 
+	// Report every bad entry up front; they are skipped below
+	invalid := validatePeople(people.People, time.Now())
+	skipIndex := make(map[int]bool, len(invalid))
+	if len(invalid) > 0 {
+		fmt.Printf("Found %d invalid entries, which will not be imported:\n", len(invalid))
+		for _, entry := range invalid {
+			fmt.Printf("  ✗ Entry %d (%q): %v\n", entry.Index, entry.Name, entry.Err)
+			skipIndex[entry.Index] = true
+		}
+		fmt.Println()
+	}
+
 	// Open/create database
 	fmt.Println("Opening database...")
 	db, err := database.New(*dbPath)
@@ -48,14 +83,37 @@ func main() {
 		}
 	}()
 
+	ctx := context.Background()
+
+	// Configure the server's announcement settings
+	if *channelID != "" {
+		if *guildID == "" {
+			log.Fatal("-channel requires -guild")
+		}
+		if _, err := time.LoadLocation(*timezone); err != nil {
+			log.Fatalf("Invalid timezone %q: %v", *timezone, err)
+		}
+		if err := db.SetGuildChannel(ctx, *guildID, *channelID); err != nil {
+			log.Fatalf("Failed to configure server: %v", err)
+		}
+		if err := db.SetGuildTimezone(ctx, *guildID, *timezone); err != nil {
+			log.Fatalf("Failed to configure server: %v", err)
+		}
+		fmt.Printf("Configured server %s to announce in channel %s (%s)\n\n", *guildID, *channelID, *timezone)
+	}
+
 	// Migrate data
 	fmt.Println("Migrating birthdays...")
 	successCount := 0
 	skipCount := 0
 
-	for _, person := range people.People {
+	for i, person := range people.People {
+		if skipIndex[i] {
+			continue
+		}
+
 		// Check if already exists
-		existing, err := db.GetBirthday(person.Name)
+		existing, err := db.GetBirthday(ctx, *guildID, person.Name)
 		if err != nil {
 			log.Printf("Warning: Error checking for existing birthday %s: %v", person.Name, err)
 			continue
@@ -67,8 +125,9 @@ func main() {
 			continue
 		}
 
-		// Add birthday (with gender if present in JSON)
-		if err := db.AddBirthday(person.Name, person.Birthday.Month, person.Birthday.Day, person.Gender, nil); err != nil {
+		// Add birthday (with pronouns if present in JSON; validated above)
+		pronouns, _ := personPronouns(person)
+		if err := db.AddBirthday(ctx, *guildID, person.Name, person.Birthday.Month, person.Birthday.Day, person.Birthday.Year, person.HideAge, pronouns, nil); err != nil {
 			log.Printf("Warning: Failed to add birthday for %s: %v", person.Name, err)
 			continue
 		}
@@ -80,21 +139,25 @@ func main() {
 	fmt.Printf("\nMigration complete!\n")
 	fmt.Printf("  Successfully migrated: %d\n", successCount)
 	fmt.Printf("  Skipped (already exist): %d\n", skipCount)
+	fmt.Printf("  Invalid (not imported): %d\n", len(invalid))
 	fmt.Printf("  Total in JSON: %d\n\n", len(people.People))
 
 	// Verify
 	fmt.Println("Verifying database...")
-	all, err := db.GetAllBirthdays()
+	all, err := db.GetAllBirthdays(ctx, *guildID)
 	if err != nil {
 		log.Fatalf("Failed to verify: %v", err)
 	}
 
-	fmt.Printf("Database now contains %d birthdays\n", len(all))
+	fmt.Printf("Database now contains %d birthdays for this server\n", len(all))
+	if len(invalid) > 0 {
+		log.Fatalf("%d entries were not imported; fix them in %s and run the import again", len(invalid), *jsonPath)
+	}
 	fmt.Println("\nMigration successful! ✓")
 	fmt.Println("\nNext steps:")
 	fmt.Println("  1. Review the migrated data")
-	fmt.Println("  2. Optionally add gender information using SQL:")
-	fmt.Println("     UPDATE birthdays SET gender = 'male' WHERE name = 'Name';")
+	fmt.Println("  2. Optionally add pronouns with /birthday edit, e.g.:")
+	fmt.Println("     /birthday edit name:Name month:1 day:1 pronouns:he/him")
 	fmt.Println("  3. Update main.go to use the database")
 	fmt.Println("  4. Keep the JSON file as backup")
 }
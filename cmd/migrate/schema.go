@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+)
+
+// runStatus prints every schema migration and whether it has been applied
+func runStatus(args []string) {
+	db := openWithoutMigrating("status", args)
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Error closing database: %v", err)
+		}
+	}()
+
+	status, err := db.MigrationStatus()
+	if err != nil {
+		log.Fatalf("Failed to get migration status: %v", err)
+	}
+
+	pending := 0
+	for _, s := range status {
+		if s.AppliedAt != nil {
+			fmt.Printf("  ✓ %04d %-30s applied %s\n", s.Version, s.Name, s.AppliedAt.Format("2006-01-02 15:04:05"))
+		} else {
+			fmt.Printf("  - %04d %-30s pending\n", s.Version, s.Name)
+			pending++
+		}
+	}
+	fmt.Printf("\n%d of %d migrations applied, %d pending\n", len(status)-pending, len(status), pending)
+}
+
+// runUp applies every pending schema migration
+func runUp(args []string) {
+	db := openWithoutMigrating("up", args)
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Error closing database: %v", err)
+		}
+	}()
+
+	ran, err := db.Migrate()
+	for _, m := range ran {
+		fmt.Printf("  ✓ Applied %04d %s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+
+	if len(ran) == 0 {
+		fmt.Println("Database is already up to date")
+	} else {
+		fmt.Printf("\nApplied %d migrations\n", len(ran))
+	}
+}
+
+// openWithoutMigrating parses the -db flag for a subcommand and opens the database
+// without applying migrations, so pending ones can be reported
+func openWithoutMigrating(name string, args []string) *database.DB {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	dbPath := flags.String("db", "./birthdays.db", "Path to SQLite database file")
+	_ = flags.Parse(args) // ExitOnError exits instead of returning an error
+
+	if _, err := os.Stat(*dbPath); err != nil {
+		log.Fatalf("Database %s not found: %v", *dbPath, err)
+	}
+
+	fmt.Printf("Database: %s\n\n", *dbPath)
+	db, err := database.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+	"github.com/nrzaman/baos-birthday-bot/util"
+)
+
+// invalidEntry is a JSON entry that can't be imported
+type invalidEntry struct {
+	Index int // Position in the Birthdays array, from 0
+	Name  string
+	Err   error
+}
+
+// validatePeople checks every entry before anything is imported, so that all
+// the problems in a file are reported at once. now is the current time, which
+// years of birth may not be after.
+func validatePeople(people []util.Person, now time.Time) []invalidEntry {
+	var invalid []invalidEntry
+	firstIndex := make(map[string]int)
+
+	for i, person := range people {
+		err := validatePerson(person, now)
+		if err == nil {
+			if first, ok := firstIndex[person.Name]; ok {
+				err = fmt.Errorf("%w: same name as entry %d", database.ErrDuplicateName, first)
+			} else {
+				firstIndex[person.Name] = i
+			}
+		}
+		if err != nil {
+			invalid = append(invalid, invalidEntry{Index: i, Name: person.Name, Err: err})
+		}
+	}
+
+	return invalid
+}
+
+// validatePerson checks a single entry's name, date, year and pronouns
+func validatePerson(person util.Person, now time.Time) error {
+	if err := database.ValidateName(person.Name); err != nil {
+		return err
+	}
+	if err := database.ValidateDate(person.Birthday.Month, person.Birthday.Day); err != nil {
+		return err
+	}
+	if err := database.ValidateYear(person.Birthday.Year, now); err != nil {
+		return err
+	}
+	_, err := personPronouns(person)
+	return err
+}
+
+// personPronouns returns an entry's pronouns, falling back to the ones for its
+// gender, or nil if it has neither
+func personPronouns(person util.Person) (*database.Pronouns, error) {
+	if person.Pronouns != nil {
+		pronouns, err := database.ParsePronouns(*person.Pronouns)
+		if err != nil {
+			return nil, err
+		}
+		return &pronouns, nil
+	}
+	if person.Gender != nil {
+		pronouns := database.GenderPronouns(*person.Gender)
+		return &pronouns, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+	"github.com/nrzaman/baos-birthday-bot/util"
+)
+
+func testPerson(name string, month, day int) util.Person {
+	return util.Person{Name: name, Birthday: util.Birthday{Month: month, Day: day}}
+}
+
+func TestValidatePeople(t *testing.T) {
+	// Arrange
+	badPronouns, goodPronouns := "xe/xem", "xe/xem/xyr/xemself"
+	zeroYear, futureYear, goodYear := 0, 2031, 1990
+	people := []util.Person{
+		testPerson("Alice", 1, 25),
+		testPerson("Bob", 4, 31),
+		testPerson("Leah", 2, 29),
+		testPerson("Alice", 6, 10),
+		testPerson("", 3, 1),
+		testPerson("Dana", 2, 30),
+		{Name: "Eli", Birthday: util.Birthday{Month: 5, Day: 5}, Pronouns: &badPronouns},
+		{Name: "Fran", Birthday: util.Birthday{Month: 5, Day: 6}, Pronouns: &goodPronouns},
+		{Name: "Gus", Birthday: util.Birthday{Month: 5, Day: 7, Year: &zeroYear}},
+		{Name: "Hal", Birthday: util.Birthday{Month: 5, Day: 8, Year: &futureYear}},
+		{Name: "Ida", Birthday: util.Birthday{Month: 5, Day: 9, Year: &goodYear}},
+	}
+
+	// Act
+	invalid := validatePeople(people, time.Date(2030, time.June, 1, 0, 0, 0, 0, time.UTC))
+
+	// Assert
+	want := []struct {
+		index int
+		err   error
+	}{
+		{1, database.ErrInvalidDate},
+		{3, database.ErrDuplicateName},
+		{4, nil},
+		{5, database.ErrInvalidDate},
+		{6, database.ErrInvalidValue},
+		{8, database.ErrInvalidValue},
+		{9, database.ErrInvalidValue},
+	}
+	if len(invalid) != len(want) {
+		t.Fatalf("Expected %d invalid entries, got %d: %+v", len(want), len(invalid), invalid)
+	}
+	for i, w := range want {
+		if invalid[i].Index != w.index {
+			t.Errorf("Invalid entry %d has index %d; want %d", i, invalid[i].Index, w.index)
+		}
+		if w.err != nil && !errors.Is(invalid[i].Err, w.err) {
+			t.Errorf("Entry %d error = %v; want %v", w.index, invalid[i].Err, w.err)
+		}
+	}
+}
@@ -1,14 +1,13 @@
 package birthday
 
 import (
-	"bytes"
+	"context"
 	"fmt"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/nrzaman/baos-birthday-bot/internal/database"
 	"github.com/nrzaman/baos-birthday-bot/internal/interfaces"
-	"github.com/nrzaman/baos-birthday-bot/util"
 )
 
 // ServiceDB handles birthday-related operations using a database
@@ -30,112 +29,392 @@ func (s *ServiceDB) IsBirthdayToday(month int, day int) bool {
 	return month == int(s.timeProvider.Month()) && day == s.timeProvider.Day()
 }
 
-// GetBirthdayMessage generates a birthday message for anyone with a birthday today
-func (s *ServiceDB) GetBirthdayMessage() string {
-	now := s.timeProvider.Now()
-	birthdays, err := s.db.GetBirthdaysByDate(int(now.Month()), now.Day())
+// Now returns the current time in the guild's timezone
+func (s *ServiceDB) Now(ctx context.Context, guildID string) (time.Time, error) {
+	now, _, err := s.today(ctx, guildID)
+	return now, err
+}
+
+// today returns the current time in the guild's timezone along with the guild's settings
+func (s *ServiceDB) today(ctx context.Context, guildID string) (time.Time, database.Guild, error) {
+	guild, err := s.GetGuildSettings(ctx, guildID)
+	if err != nil {
+		return time.Time{}, database.Guild{}, err
+	}
+	return s.timeProvider.Now().In(guild.Location()), guild, nil
+}
+
+// GetMonthBirthdays returns the birthdays celebrated in the guild in the current
+// month, in date order. In non-leap years, February 29 birthdays are included in
+// the month the guild celebrates them in.
+func (s *ServiceDB) GetMonthBirthdays(ctx context.Context, guildID string) (MonthGroup, error) {
+	now, guild, err := s.today(ctx, guildID)
 	if err != nil {
-		fmt.Printf("Error getting birthdays: %v\n", err)
-		return ""
+		return MonthGroup{}, err
 	}
 
-	var buffer bytes.Buffer
-	caseyHandled := false
-	for _, birthday := range birthdays {
-		if birthday.Name == "Casey" && s.IsBirthdayToday(1, 6) && !caseyHandled {
-			// Special handling for Casey on January 6th only
-			buffer.WriteString("Today is the anniversary of the **Capitol Riots**. Nothing else special happened today.\n")
-			caseyHandled = true
-		} else {
-			// Normal birthday message for everyone else (including Casey on non-1/6 days)
-			pronoun := birthday.GetPronoun(false) // possessive form
-			buffer.WriteString(fmt.Sprintf("Today is **%s's birthday**! 🎉 Please wish %s a happy birthday! 🎂\n",
-				birthday.Name, pronoun))
+	birthdays, err := s.db.GetBirthdaysByMonth(ctx, guildID, int(now.Month()))
+	if err != nil {
+		return MonthGroup{}, err
+	}
+	if now.Month() == time.March && !IsLeapYear(now.Year()) {
+		// February 29 birthdays may be celebrated on March 1
+		leapDay, err := s.db.GetBirthdaysByDate(ctx, guildID, int(time.February), 29)
+		if err != nil {
+			return MonthGroup{}, err
 		}
+		birthdays = append(birthdays, leapDay...)
 	}
 
-	return buffer.String()
+	for _, group := range GroupByMonth(birthdays, now, guild.LeapDayPolicy) {
+		if group.Month == now.Month() {
+			return group, nil
+		}
+	}
+	return MonthGroup{Month: now.Month()}, nil
 }
 
-// ListCurrentMonthBirthdays returns a string listing all birthdays in the current month
-func (s *ServiceDB) ListCurrentMonthBirthdays() string {
-	currentMonth := int(s.timeProvider.Month())
+// GetBirthdaysByMonth returns every birthday in the guild grouped by the month
+// it is celebrated in this year. Private years of birth are left out.
+func (s *ServiceDB) GetBirthdaysByMonth(ctx context.Context, guildID string) ([]MonthGroup, error) {
+	now, guild, err := s.today(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	birthdays, err := s.GetAllBirthdays(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	return GroupByMonth(birthdays, now, guild.LeapDayPolicy), nil
+}
 
-	birthdays, err := s.db.GetBirthdaysByMonth(currentMonth)
+// GetNextBirthdays returns the next count birthdays celebrated in the guild,
+// soonest first, along with anyone else celebrating on the same day as the last
+// of them. Private years of birth are left out.
+func (s *ServiceDB) GetNextBirthdays(ctx context.Context, guildID string, count int) ([]Upcoming, error) {
+	upcoming, err := s.upcoming(ctx, guildID)
 	if err != nil {
-		fmt.Printf("Error getting birthdays: %v\n", err)
-		return ""
+		return nil, err
 	}
+	if count < 1 || count >= len(upcoming) {
+		return upcoming, nil
+	}
+
+	// Nobody is left out of a day that is listed
+	end := count
+	for end < len(upcoming) && upcoming[end].DaysUntil == upcoming[count-1].DaysUntil {
+		end++
+	}
+	return upcoming[:end], nil
+}
 
-	var buffer bytes.Buffer
-	for _, birthday := range birthdays {
-		month := time.Month(birthday.Month)
-		buffer.WriteString(fmt.Sprintf("**%s Birthdays:**\n\n• %s, %s %s\n",
-			month.String(),
-			birthday.Name,
-			month.String(),
-			strconv.Itoa(birthday.Day)))
+// GetUpcomingBirthdays returns the birthdays celebrated in the guild from today
+// until days days from now, soonest first. Private years of birth are left out.
+func (s *ServiceDB) GetUpcomingBirthdays(ctx context.Context, guildID string, days int) ([]Upcoming, error) {
+	upcoming, err := s.upcoming(ctx, guildID)
+	if err != nil {
+		return nil, err
 	}
 
-	return buffer.String()
+	end := 0
+	for end < len(upcoming) && upcoming[end].DaysUntil <= days {
+		end++
+	}
+	return upcoming[:end], nil
 }
 
-// ListAllBirthdays returns a string listing all birthdays
-func (s *ServiceDB) ListAllBirthdays() string {
-	birthdays, err := s.db.GetAllBirthdays()
+// upcoming returns the next celebration of every birthday in the guild, soonest first
+func (s *ServiceDB) upcoming(ctx context.Context, guildID string) ([]Upcoming, error) {
+	now, guild, err := s.today(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	birthdays, err := s.GetAllBirthdays(ctx, guildID)
 	if err != nil {
-		fmt.Printf("Error getting birthdays: %v\n", err)
-		return ""
+		return nil, err
 	}
+	return UpcomingBirthdays(birthdays, now, guild.LeapDayPolicy), nil
+}
 
-	var buffer bytes.Buffer
-	buffer.WriteString("**All Birthdays:**\n\n")
-	for _, birthday := range birthdays {
-		month := time.Month(birthday.Month)
-		buffer.WriteString(fmt.Sprintf("• %s, %s %s\n",
-			birthday.Name,
-			month.String(),
-			strconv.Itoa(birthday.Day)))
+// GetAllBirthdays returns every birthday in the guild in date order. Private
+// years of birth are left out.
+func (s *ServiceDB) GetAllBirthdays(ctx context.Context, guildID string) ([]database.Birthday, error) {
+	birthdays, err := s.db.GetAllBirthdays(ctx, guildID)
+	if err != nil {
+		return nil, err
 	}
 
-	return buffer.String()
+	for i := range birthdays {
+		// Private years stay in the database
+		if birthdays[i].YearPrivate {
+			birthdays[i].Year = nil
+		}
+	}
+	return birthdays, nil
 }
 
-// AddBirthday adds a new birthday
-func (s *ServiceDB) AddBirthday(name string, month, day int, gender *string) error {
-	return s.db.AddBirthday(name, month, day, gender, nil)
+// AddBirthday adds a new birthday, rejecting invalid dates and duplicate names
+func (s *ServiceDB) AddBirthday(ctx context.Context, guildID, name string, month, day int, year *int, yearPrivate bool, pronouns *database.Pronouns, discordID *string) error {
+	if err := validateBirthday(name, month, day); err != nil {
+		return err
+	}
+	if err := s.validateYear(year); err != nil {
+		return err
+	}
+
+	existing, err := s.db.GetBirthday(ctx, guildID, name)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("%w: %s", database.ErrDuplicateName, name)
+	}
+
+	return s.db.AddBirthday(ctx, guildID, name, month, day, year, yearPrivate, pronouns, discordID)
+}
+
+// UpdateBirthday changes the date of an existing birthday. A nil year,
+// yearPrivate, pronouns or discordID leaves the stored value unchanged.
+func (s *ServiceDB) UpdateBirthday(ctx context.Context, guildID, name string, month, day int, year *int, yearPrivate *bool, pronouns *database.Pronouns, discordID *string) error {
+	if err := validateBirthday(name, month, day); err != nil {
+		return err
+	}
+	if err := s.validateYear(year); err != nil {
+		return err
+	}
+
+	existing, err := s.db.GetBirthday(ctx, guildID, name)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("%w for %s", database.ErrNotFound, name)
+	}
+
+	if year == nil {
+		year = existing.Year
+	}
+	private := existing.YearPrivate
+	if yearPrivate != nil {
+		private = *yearPrivate
+	}
+	if pronouns == nil {
+		pronouns = existing.Pronouns
+	}
+	if discordID == nil {
+		discordID = existing.DiscordID
+	}
+
+	return s.db.UpdateBirthday(ctx, guildID, name, month, day, year, private, pronouns, discordID)
 }
 
 // RemoveBirthday removes a birthday
-func (s *ServiceDB) RemoveBirthday(name string) error {
-	return s.db.DeleteBirthday(name)
+func (s *ServiceDB) RemoveBirthday(ctx context.Context, guildID, name string) error {
+	return s.db.DeleteBirthday(ctx, guildID, name)
 }
 
-// GetBirthdaysToday returns all birthdays happening today
-func (s *ServiceDB) GetBirthdaysToday() ([]database.Birthday, error) {
-	now := s.timeProvider.Now()
-	return s.db.GetBirthdaysByDate(int(now.Month()), now.Day())
+// GetBirthdayByDiscordID returns the birthday linked to a Discord user, or nil if there is none
+func (s *ServiceDB) GetBirthdayByDiscordID(ctx context.Context, guildID, discordID string) (*database.Birthday, error) {
+	return s.db.GetBirthdayByDiscordID(ctx, guildID, discordID)
 }
 
-// GetBirthdays returns all birthdays in util.People format for compatibility
-func (s *ServiceDB) GetBirthdays() util.People {
-	birthdays, err := s.db.GetAllBirthdays()
+// UpsertByDiscordID registers or changes the birthday linked to a Discord user.
+// A nil year, yearPrivate or pronouns leaves the stored value unchanged.
+func (s *ServiceDB) UpsertByDiscordID(ctx context.Context, guildID, discordID, name string, month, day int, year *int, yearPrivate *bool, pronouns *database.Pronouns) error {
+	if err := validateBirthday(name, month, day); err != nil {
+		return err
+	}
+	if err := s.validateYear(year); err != nil {
+		return err
+	}
+
+	// The name is still unique, so make sure it isn't taken by someone else
+	existing, err := s.db.GetBirthday(ctx, guildID, name)
 	if err != nil {
-		fmt.Printf("Error getting birthdays: %v\n", err)
-		return util.People{People: []util.Person{}}
+		return err
+	}
+	if existing != nil && (existing.DiscordID == nil || *existing.DiscordID != discordID) {
+		return fmt.Errorf("%w: %s", database.ErrDuplicateName, name)
 	}
 
-	people := make([]util.Person, 0, len(birthdays))
-	for _, birthday := range birthdays {
-		people = append(people, util.Person{
-			Name: birthday.Name,
-			Birthday: util.Birthday{
-				Month: birthday.Month,
-				Day:   birthday.Day,
-			},
-			Gender: birthday.Gender,
-		})
+	return s.db.UpsertByDiscordID(ctx, guildID, discordID, name, month, day, year, yearPrivate, pronouns)
+}
+
+// RemoveByDiscordID removes the birthday linked to a Discord user
+func (s *ServiceDB) RemoveByDiscordID(ctx context.Context, guildID, discordID string) error {
+	return s.db.DeleteBirthdayByDiscordID(ctx, guildID, discordID)
+}
+
+// validateYear checks that an optional year of birth is not in the future
+func (s *ServiceDB) validateYear(year *int) error {
+	return database.ValidateYear(year, s.timeProvider.Now())
+}
+
+// validateBirthday checks a name and date before they reach the database so
+// that callers get a readable error instead of a constraint violation. Invalid
+// dates, such as April 31, wrap database.ErrInvalidDate and an empty or overlong
+// name wraps database.ErrInvalidValue.
+func validateBirthday(name string, month, day int) error {
+	if err := database.ValidateName(name); err != nil {
+		return err
 	}
+	return database.ValidateDate(month, day)
+}
 
-	return util.People{People: people}
+// GetBirthdaysToday returns everyone in the guild celebrating today, following
+// the guild's leap day policy in non-leap years
+func (s *ServiceDB) GetBirthdaysToday(ctx context.Context, guildID string) ([]database.Birthday, error) {
+	now, guild, err := s.today(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	birthdays, err := s.db.GetBirthdaysByDate(ctx, guildID, int(now.Month()), now.Day())
+	if err != nil {
+		return nil, err
+	}
+	if IsLeapYear(now.Year()) {
+		return birthdays, nil
+	}
+
+	// February 29 birthdays are celebrated today if the guild's policy moves them here
+	month, day := CelebrationDate(int(time.February), 29, now.Year(), guild.LeapDayPolicy)
+	if month != now.Month() || day != now.Day() {
+		return birthdays, nil
+	}
+	leapDay, err := s.db.GetBirthdaysByDate(ctx, guildID, int(time.February), 29)
+	if err != nil {
+		return nil, err
+	}
+	return append(birthdays, leapDay...), nil
+}
+
+// GetGuilds returns the settings for every configured guild
+func (s *ServiceDB) GetGuilds(ctx context.Context) ([]database.Guild, error) {
+	return s.db.GetGuilds(ctx)
+}
+
+// GetGuildSettings returns a guild's settings, or the defaults if it has not been configured
+func (s *ServiceDB) GetGuildSettings(ctx context.Context, guildID string) (database.Guild, error) {
+	guild, err := s.db.GetGuild(ctx, guildID)
+	if err != nil {
+		return database.Guild{}, err
+	}
+	if guild == nil {
+		return database.DefaultGuild(guildID), nil
+	}
+	return *guild, nil
+}
+
+// SetAnnouncementChannel sets the channel a guild's announcements are sent to
+func (s *ServiceDB) SetAnnouncementChannel(ctx context.Context, guildID, channelID string) error {
+	return s.db.SetGuildChannel(ctx, guildID, channelID)
+}
+
+// SetAnnouncementTime sets the time of day a guild's announcements are sent
+func (s *ServiceDB) SetAnnouncementTime(ctx context.Context, guildID string, hour, minute int) error {
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return fmt.Errorf("%w: time %02d:%02d does not exist", database.ErrInvalidValue, hour, minute)
+	}
+	return s.db.SetGuildAnnouncementTime(ctx, guildID, hour, minute)
+}
+
+// SetTimezone sets a guild's timezone, rejecting unknown IANA names
+func (s *ServiceDB) SetTimezone(ctx context.Context, guildID, timezone string) error {
+	if timezone == "" || timezone == "Local" {
+		return fmt.Errorf("%w: unknown timezone %q", database.ErrInvalidValue, timezone)
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q: use a name like America/New_York or Europe/London", database.ErrInvalidValue, timezone)
+	}
+	return s.db.SetGuildTimezone(ctx, guildID, timezone)
+}
+
+// SetLeapDayPolicy sets when a guild celebrates February 29 birthdays in non-leap years
+func (s *ServiceDB) SetLeapDayPolicy(ctx context.Context, guildID, policy string) error {
+	if policy != database.LeapDayFeb28 && policy != database.LeapDayMar1 {
+		return fmt.Errorf("%w: unknown leap day policy %q: use %q or %q", database.ErrInvalidValue, policy, database.LeapDayFeb28, database.LeapDayMar1)
+	}
+	return s.db.SetGuildLeapDayPolicy(ctx, guildID, policy)
+}
+
+// MarkAnnounced records that the guild's announcements for the given local date were sent
+func (s *ServiceDB) MarkAnnounced(ctx context.Context, guildID string, date time.Time) error {
+	return s.db.SetGuildLastAnnounced(ctx, guildID, date.Format(database.DateLayout))
+}
+
+// ClaimAnnouncement records an announcement for the guild's local date in the
+// ledger, returning false if it was already made
+func (s *ServiceDB) ClaimAnnouncement(ctx context.Context, guildID string, date time.Time, kind string) (bool, error) {
+	return s.db.ClaimAnnouncement(ctx, guildID, date.Format(database.DateLayout), kind)
+}
+
+// ReleaseAnnouncement removes a claimed announcement so it can be retried
+func (s *ServiceDB) ReleaseAnnouncement(ctx context.Context, guildID string, date time.Time, kind string) error {
+	return s.db.ReleaseAnnouncement(ctx, guildID, date.Format(database.DateLayout), kind)
+}
+
+// GetAnnouncementProgress returns how many of the messages of an announcement
+// for the guild's local date were delivered
+func (s *ServiceDB) GetAnnouncementProgress(ctx context.Context, guildID string, date time.Time, kind string) (int, error) {
+	return s.db.GetAnnouncementProgress(ctx, guildID, date.Format(database.DateLayout), kind)
+}
+
+// SetAnnouncementProgress records how many of the messages of an announcement
+// for the guild's local date were delivered
+func (s *ServiceDB) SetAnnouncementProgress(ctx context.Context, guildID string, date time.Time, kind string, sent int) error {
+	return s.db.SetAnnouncementProgress(ctx, guildID, date.Format(database.DateLayout), kind, sent)
+}
+
+// GetAnnouncementHistory returns the guild's most recent announcements, newest first
+func (s *ServiceDB) GetAnnouncementHistory(ctx context.Context, guildID string, limit int) ([]database.Announcement, error) {
+	if limit < 1 {
+		return nil, fmt.Errorf("%w: limit must be at least 1, got %d", database.ErrInvalidValue, limit)
+	}
+	return s.db.GetAnnouncements(ctx, guildID, limit)
+}
+
+// MaxTemplates is the most variants a guild may have for a kind of announcement
+const MaxTemplates = 5
+
+// GetTemplates returns the guild's templates for a kind of announcement, or nil
+// if it uses the default
+func (s *ServiceDB) GetTemplates(ctx context.Context, guildID, kind string) ([]string, error) {
+	if err := validateTemplateKind(kind); err != nil {
+		return nil, err
+	}
+	return s.db.GetTemplates(ctx, guildID, kind)
+}
+
+// SetTemplates replaces the guild's templates for a kind of announcement with
+// between one and MaxTemplates variants
+func (s *ServiceDB) SetTemplates(ctx context.Context, guildID, kind string, templates []string) error {
+	if err := validateTemplateKind(kind); err != nil {
+		return err
+	}
+	if len(templates) == 0 || len(templates) > MaxTemplates {
+		return fmt.Errorf("%w: give between 1 and %d templates, got %d", database.ErrInvalidValue, MaxTemplates, len(templates))
+	}
+	for _, template := range templates {
+		if strings.TrimSpace(template) == "" {
+			return fmt.Errorf("%w: templates cannot be empty", database.ErrInvalidValue)
+		}
+	}
+	return s.db.SetTemplates(ctx, guildID, kind, templates)
+}
+
+// ResetTemplates makes the guild use the default template for a kind of announcement
+func (s *ServiceDB) ResetTemplates(ctx context.Context, guildID, kind string) error {
+	if err := validateTemplateKind(kind); err != nil {
+		return err
+	}
+	return s.db.SetTemplates(ctx, guildID, kind, nil)
+}
+
+// validateTemplateKind rejects anything but the kinds of announcement that have templates
+func validateTemplateKind(kind string) error {
+	if kind != database.AnnouncementBirthday && kind != database.AnnouncementMonthly {
+		return fmt.Errorf("%w: unknown kind of announcement %q: use %q or %q", database.ErrInvalidValue, kind, database.AnnouncementBirthday, database.AnnouncementMonthly)
+	}
+	return nil
 }
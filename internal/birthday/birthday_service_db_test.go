@@ -1,14 +1,23 @@
 package birthday_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/nrzaman/baos-birthday-bot/internal/birthday"
 	"github.com/nrzaman/baos-birthday-bot/internal/database"
+	"github.com/nrzaman/baos-birthday-bot/internal/render"
 )
 
+// testGuildID is the Discord server used by tests that don't care about guilds
+const testGuildID = "guild-1"
+
+var ctx = context.Background()
+
 // MockTimeProvider for testing
 type MockTimeProvider struct {
 	CurrentTime time.Time
@@ -26,6 +35,10 @@ func (m *MockTimeProvider) Day() int {
 	return m.CurrentTime.Day()
 }
 
+func (m *MockTimeProvider) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
 // Helper function to create test database
 func setupTestDB(t *testing.T) *database.DB {
 	t.Helper()
@@ -40,13 +53,50 @@ func setupTestDB(t *testing.T) *database.DB {
 }
 
 // Helper function to add test birthdays
-func addTestBirthday(t *testing.T, db *database.DB, name string, month, day int, gender *string) {
-	err := db.AddBirthday(name, month, day, gender, nil)
+func addTestBirthday(t *testing.T, db *database.DB, name string, month, day int, pronouns *database.Pronouns) {
+	err := db.AddBirthday(ctx, testGuildID, name, month, day, nil, false, pronouns, nil)
 	if err != nil {
 		t.Fatalf("Failed to add test birthday: %v", err)
 	}
 }
 
+// birthdayMessage renders today's birthday announcement for the test guild
+func birthdayMessage(t *testing.T, service *birthday.ServiceDB) string {
+	t.Helper()
+	birthdays, err := service.GetBirthdaysToday(ctx, testGuildID)
+	if err != nil {
+		t.Fatalf("GetBirthdaysToday returned error: %v", err)
+	}
+	now, err := service.Now(ctx, testGuildID)
+	if err != nil {
+		t.Fatalf("Now returned error: %v", err)
+	}
+	templates, err := service.GetBirthdayTemplates(ctx, testGuildID, birthdays)
+	if err != nil {
+		t.Fatalf("GetBirthdayTemplates returned error: %v", err)
+	}
+	return render.BirthdayMessage(birthdays, now, templates)
+}
+
+// addCaseyOverride gives Casey the Capitol Riots message on January 6th
+func addCaseyOverride(t *testing.T, service *birthday.ServiceDB) {
+	t.Helper()
+	message := "Today is the anniversary of the **Capitol Riots**. Nothing else special happened today."
+	if err := service.SetOverride(ctx, testGuildID, 1, 6, "Casey", message); err != nil {
+		t.Fatalf("SetOverride returned error: %v", err)
+	}
+}
+
+// monthBirthdays renders the test guild's birthdays for the current month
+func monthBirthdays(t *testing.T, service *birthday.ServiceDB) string {
+	t.Helper()
+	celebrations, err := service.GetMonthBirthdays(ctx, testGuildID)
+	if err != nil {
+		t.Fatalf("GetMonthBirthdays returned error: %v", err)
+	}
+	return render.MonthBirthdays(celebrations)
+}
+
 func TestGetBirthdayMessage_NoBirthdays(t *testing.T) {
 	// Arrange
 	db := setupTestDB(t)
@@ -57,7 +107,7 @@ func TestGetBirthdayMessage_NoBirthdays(t *testing.T) {
 	service := birthday.NewServiceDB(timeProvider, db)
 
 	// Act
-	message := service.GetBirthdayMessage()
+	message := birthdayMessage(t, service)
 
 	// Assert
 	if message != "" {
@@ -69,7 +119,7 @@ func TestGetBirthdayMessage_SingleBirthdayMale(t *testing.T) {
 	// Arrange
 	db := setupTestDB(t)
 
-	male := "male"
+	male := database.HeHim
 	addTestBirthday(t, db, "John", 3, 15, &male)
 
 	timeProvider := &MockTimeProvider{
@@ -78,7 +128,7 @@ func TestGetBirthdayMessage_SingleBirthdayMale(t *testing.T) {
 	service := birthday.NewServiceDB(timeProvider, db)
 
 	// Act
-	message := service.GetBirthdayMessage()
+	message := birthdayMessage(t, service)
 
 	// Assert
 	if !strings.Contains(message, "John") {
@@ -96,7 +146,7 @@ func TestGetBirthdayMessage_SingleBirthdayFemale(t *testing.T) {
 	// Arrange
 	db := setupTestDB(t)
 
-	female := "female"
+	female := database.SheHer
 	addTestBirthday(t, db, "Alice", 3, 15, &female)
 
 	timeProvider := &MockTimeProvider{
@@ -105,7 +155,7 @@ func TestGetBirthdayMessage_SingleBirthdayFemale(t *testing.T) {
 	service := birthday.NewServiceDB(timeProvider, db)
 
 	// Act
-	message := service.GetBirthdayMessage()
+	message := birthdayMessage(t, service)
 
 	// Assert
 	if !strings.Contains(message, "Alice") {
@@ -120,7 +170,7 @@ func TestGetBirthdayMessage_SingleBirthdayNonbinary(t *testing.T) {
 	// Arrange
 	db := setupTestDB(t)
 
-	nonbinary := "nonbinary"
+	nonbinary := database.TheyThem
 	addTestBirthday(t, db, "Taylor", 3, 15, &nonbinary)
 
 	timeProvider := &MockTimeProvider{
@@ -129,7 +179,7 @@ func TestGetBirthdayMessage_SingleBirthdayNonbinary(t *testing.T) {
 	service := birthday.NewServiceDB(timeProvider, db)
 
 	// Act
-	message := service.GetBirthdayMessage()
+	message := birthdayMessage(t, service)
 
 	// Assert
 	if !strings.Contains(message, "Taylor") {
@@ -144,16 +194,17 @@ func TestGetBirthdayMessage_CaseyOnJanuary6th(t *testing.T) {
 	// Arrange
 	db := setupTestDB(t)
 
-	male := "male"
+	male := database.HeHim
 	addTestBirthday(t, db, "Casey", 1, 6, &male)
 
 	timeProvider := &MockTimeProvider{
 		CurrentTime: time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC),
 	}
 	service := birthday.NewServiceDB(timeProvider, db)
+	addCaseyOverride(t, service)
 
 	// Act
-	message := service.GetBirthdayMessage()
+	message := birthdayMessage(t, service)
 
 	// Assert
 	if !strings.Contains(message, "Capitol Riots") {
@@ -171,17 +222,21 @@ func TestGetBirthdayMessage_CaseyOnOtherDay(t *testing.T) {
 	// Arrange
 	db := setupTestDB(t)
 
-	male := "male"
-	// Casey's birthday is actually 1/6, but we're testing a different day
+	male := database.HeHim
+	// Casey's override is for 1/6, which isn't this Casey's birthday
 	addTestBirthday(t, db, "Casey", 3, 15, &male)
 
 	timeProvider := &MockTimeProvider{
 		CurrentTime: time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC),
 	}
 	service := birthday.NewServiceDB(timeProvider, db)
+	err := service.SetOverride(ctx, testGuildID, 1, 6, "Casey", "Capitol Riots")
+	if !errors.Is(err, database.ErrInvalidValue) {
+		t.Errorf("SetOverride() on another day than Casey's birthday error = %v; want ErrInvalidValue", err)
+	}
 
 	// Act
-	message := service.GetBirthdayMessage()
+	message := birthdayMessage(t, service)
 
 	// Assert
 	if strings.Contains(message, "Capitol Riots") {
@@ -196,8 +251,8 @@ func TestGetBirthdayMessage_January6thWithOtherPeople(t *testing.T) {
 	// Arrange
 	db := setupTestDB(t)
 
-	male := "male"
-	female := "female"
+	male := database.HeHim
+	female := database.SheHer
 	addTestBirthday(t, db, "Casey", 1, 6, &male)
 	addTestBirthday(t, db, "Alice", 1, 6, &female)
 
@@ -205,9 +260,10 @@ func TestGetBirthdayMessage_January6thWithOtherPeople(t *testing.T) {
 		CurrentTime: time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC),
 	}
 	service := birthday.NewServiceDB(timeProvider, db)
+	addCaseyOverride(t, service)
 
 	// Act
-	message := service.GetBirthdayMessage()
+	message := birthdayMessage(t, service)
 
 	// Assert
 	if !strings.Contains(message, "Capitol Riots") {
@@ -227,8 +283,8 @@ func TestGetBirthdayMessage_MultipleBirthdays(t *testing.T) {
 	// Arrange
 	db := setupTestDB(t)
 
-	male := "male"
-	female := "female"
+	male := database.HeHim
+	female := database.SheHer
 	addTestBirthday(t, db, "John", 3, 15, &male)
 	addTestBirthday(t, db, "Alice", 3, 15, &female)
 
@@ -238,7 +294,7 @@ func TestGetBirthdayMessage_MultipleBirthdays(t *testing.T) {
 	service := birthday.NewServiceDB(timeProvider, db)
 
 	// Act
-	message := service.GetBirthdayMessage()
+	message := birthdayMessage(t, service)
 
 	// Assert
 	if !strings.Contains(message, "John") {
@@ -295,8 +351,8 @@ func TestListCurrentMonthBirthdays_WithBirthdays(t *testing.T) {
 	// Arrange
 	db := setupTestDB(t)
 
-	male := "male"
-	female := "female"
+	male := database.HeHim
+	female := database.SheHer
 	addTestBirthday(t, db, "John", 3, 15, &male)
 	addTestBirthday(t, db, "Alice", 3, 20, &female)
 	addTestBirthday(t, db, "Bob", 4, 10, &male) // Different month
@@ -307,7 +363,7 @@ func TestListCurrentMonthBirthdays_WithBirthdays(t *testing.T) {
 	service := birthday.NewServiceDB(timeProvider, db)
 
 	// Act
-	message := service.ListCurrentMonthBirthdays()
+	message := monthBirthdays(t, service)
 
 	// Assert
 	if !strings.Contains(message, "John") {
@@ -334,7 +390,7 @@ func TestListCurrentMonthBirthdays_NoBirthdays(t *testing.T) {
 	service := birthday.NewServiceDB(timeProvider, db)
 
 	// Act
-	message := service.ListCurrentMonthBirthdays()
+	message := monthBirthdays(t, service)
 
 	// Assert
 	if message != "" {
@@ -342,95 +398,559 @@ func TestListCurrentMonthBirthdays_NoBirthdays(t *testing.T) {
 	}
 }
 
-func TestListAllBirthdays_MultipleBirthdays(t *testing.T) {
+func TestGetAllBirthdays_InDateOrder(t *testing.T) {
 	// Arrange
 	db := setupTestDB(t)
 
-	male := "male"
-	female := "female"
+	male := database.HeHim
+	female := database.SheHer
+	addTestBirthday(t, db, "Bob", 12, 25, &male)
 	addTestBirthday(t, db, "John", 3, 15, &male)
 	addTestBirthday(t, db, "Alice", 6, 20, &female)
-	addTestBirthday(t, db, "Bob", 12, 25, &male)
 
-	timeProvider := &MockTimeProvider{
-		CurrentTime: time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC),
+	service := birthday.NewServiceDB(&MockTimeProvider{}, db)
+
+	// Act
+	birthdays, err := service.GetAllBirthdays(ctx, testGuildID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetAllBirthdays returned error: %v", err)
 	}
-	service := birthday.NewServiceDB(timeProvider, db)
+	if len(birthdays) != 3 {
+		t.Fatalf("Expected 3 birthdays, got %d", len(birthdays))
+	}
+	for i, want := range []string{"John", "Alice", "Bob"} {
+		if birthdays[i].Name != want {
+			t.Errorf("Expected birthday %d to be %q, got %q", i, want, birthdays[i].Name)
+		}
+	}
+	if birthdays[0].Month != 3 || birthdays[0].Day != 15 {
+		t.Errorf("Expected John on 3/15, got %d/%d", birthdays[0].Month, birthdays[0].Day)
+	}
+	if birthdays[0].Pronouns == nil || *birthdays[0].Pronouns != database.HeHim {
+		t.Errorf("Expected pronouns he/him, got %v", birthdays[0].Pronouns)
+	}
+}
+
+func TestGetAllBirthdays_NoBirthdays(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+	service := birthday.NewServiceDB(&MockTimeProvider{}, db)
 
 	// Act
-	message := service.ListAllBirthdays()
+	birthdays, err := service.GetAllBirthdays(ctx, testGuildID)
 
 	// Assert
-	if !strings.Contains(message, "All Birthdays") {
-		t.Errorf("Expected header 'All Birthdays', got: %q", message)
+	if err != nil {
+		t.Fatalf("GetAllBirthdays returned error: %v", err)
 	}
-	if !strings.Contains(message, "John") {
-		t.Errorf("Expected message to contain 'John', got: %q", message)
+	if len(birthdays) != 0 {
+		t.Errorf("Expected no birthdays, got %d", len(birthdays))
 	}
-	if !strings.Contains(message, "Alice") {
-		t.Errorf("Expected message to contain 'Alice', got: %q", message)
+}
+
+func TestReads_ReportStorageFailures(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+	addTestBirthday(t, db, "John", 3, 15, nil)
+	service := birthday.NewServiceDB(&MockTimeProvider{CurrentTime: time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC)}, db)
+	_ = db.Close() // Every query now fails
+
+	// Act & Assert: a broken database is an error, not an empty list
+	if _, err := service.GetBirthdaysToday(ctx, testGuildID); err == nil {
+		t.Error("Expected GetBirthdaysToday to return an error")
 	}
-	if !strings.Contains(message, "Bob") {
-		t.Errorf("Expected message to contain 'Bob', got: %q", message)
+	if _, err := service.GetMonthBirthdays(ctx, testGuildID); err == nil {
+		t.Error("Expected GetMonthBirthdays to return an error")
 	}
-	if !strings.Contains(message, "March") {
-		t.Errorf("Expected message to contain 'March', got: %q", message)
+	if _, err := service.GetAllBirthdays(ctx, testGuildID); err == nil {
+		t.Error("Expected GetAllBirthdays to return an error")
 	}
-	if !strings.Contains(message, "June") {
-		t.Errorf("Expected message to contain 'June', got: %q", message)
+	if _, err := service.Now(ctx, testGuildID); err == nil {
+		t.Error("Expected Now to return an error")
 	}
-	if !strings.Contains(message, "December") {
-		t.Errorf("Expected message to contain 'December', got: %q", message)
+}
+
+func TestAddBirthday_RejectsDuplicateName(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+	addTestBirthday(t, db, "John", 3, 15, nil)
+
+	service := birthday.NewServiceDB(&MockTimeProvider{}, db)
+
+	// Act
+	err := service.AddBirthday(ctx, testGuildID, "John", 4, 1, nil, false, nil, nil)
+
+	// Assert
+	if !errors.Is(err, database.ErrDuplicateName) {
+		t.Errorf("Expected duplicate name error, got: %v", err)
+	}
+}
+
+func TestAddBirthday_RejectsInvalidDate(t *testing.T) {
+	tests := []struct {
+		name  string
+		month int
+		day   int
+	}{
+		{"Month too small", 0, 1},
+		{"Month too large", 13, 1},
+		{"Day too small", 1, 0},
+		{"Day too large", 1, 32},
+		{"April 31", 4, 31},
+		{"June 31", 6, 31},
+		{"February 30", 2, 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			db := setupTestDB(t)
+			service := birthday.NewServiceDB(&MockTimeProvider{}, db)
+
+			// Act
+			err := service.AddBirthday(ctx, testGuildID, "John", tt.month, tt.day, nil, false, nil, nil)
+
+			// Assert
+			if !errors.Is(err, database.ErrInvalidDate) {
+				t.Errorf("Expected invalid date error for %d/%d, got: %v", tt.month, tt.day, err)
+			}
+		})
+	}
+}
+
+func TestAddBirthday_RejectsInvalidName(t *testing.T) {
+	tests := []struct {
+		name       string
+		personName string
+		wantErr    bool
+	}{
+		{"Empty", " ", true},
+		{"Longest allowed", strings.Repeat("é", database.MaxNameLength), false},
+		{"Too long", strings.Repeat("a", database.MaxNameLength+1), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			db := setupTestDB(t)
+			service := birthday.NewServiceDB(&MockTimeProvider{}, db)
+
+			// Act
+			err := service.AddBirthday(ctx, testGuildID, tt.personName, 1, 1, nil, false, nil, nil)
+
+			// Assert
+			if tt.wantErr != errors.Is(err, database.ErrInvalidValue) || (!tt.wantErr && err != nil) {
+				t.Errorf("AddBirthday(%q) error = %v; want error %v", tt.personName, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdateBirthday_KeepsUnsetFields(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+	male := database.HeHim
+	discordID := "1234"
+	year := 1990
+	if err := db.AddBirthday(ctx, testGuildID, "John", 3, 15, &year, true, &male, &discordID); err != nil {
+		t.Fatalf("Failed to add test birthday: %v", err)
+	}
+
+	service := birthday.NewServiceDB(&MockTimeProvider{}, db)
+
+	// Act
+	err := service.UpdateBirthday(ctx, testGuildID, "John", 4, 1, nil, nil, nil, nil)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("UpdateBirthday returned error: %v", err)
+	}
+	updated, _ := db.GetBirthday(ctx, testGuildID, "John")
+	if updated.Month != 4 || updated.Day != 1 {
+		t.Errorf("Expected date 4/1, got %d/%d", updated.Month, updated.Day)
+	}
+	if updated.Pronouns == nil || *updated.Pronouns != database.HeHim {
+		t.Errorf("Expected pronouns to stay he/him, got %v", updated.Pronouns)
+	}
+	if updated.DiscordID == nil || *updated.DiscordID != "1234" {
+		t.Errorf("Expected discord ID to stay '1234', got %v", updated.DiscordID)
+	}
+	if updated.Year == nil || *updated.Year != 1990 || !updated.YearPrivate {
+		t.Errorf("Expected private year 1990 to be kept, got %v (private %v)", updated.Year, updated.YearPrivate)
+	}
+}
+
+func TestUpdateBirthday_MissingRecord(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+	service := birthday.NewServiceDB(&MockTimeProvider{}, db)
+
+	// Act
+	err := service.UpdateBirthday(ctx, testGuildID, "Nobody", 4, 1, nil, nil, nil, nil)
+
+	// Assert
+	if !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("Expected not found error, got: %v", err)
 	}
 }
 
-func TestListAllBirthdays_NoBirthdays(t *testing.T) {
+func TestRemoveBirthday_MissingRecord(t *testing.T) {
 	// Arrange
 	db := setupTestDB(t)
+	service := birthday.NewServiceDB(&MockTimeProvider{}, db)
+
+	// Act
+	err := service.RemoveBirthday(ctx, testGuildID, "Nobody")
+
+	// Assert
+	if !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("Expected not found error, got: %v", err)
+	}
+}
+
+func TestUpsertByDiscordID_RejectsNameOfAnotherPerson(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+	addTestBirthday(t, db, "John", 3, 15, nil)
+
+	service := birthday.NewServiceDB(&MockTimeProvider{}, db)
+
+	// Act
+	err := service.UpsertByDiscordID(ctx, testGuildID, "42", "John", 4, 1, nil, nil, nil)
+
+	// Assert
+	if !errors.Is(err, database.ErrDuplicateName) {
+		t.Errorf("Expected duplicate name error, got: %v", err)
+	}
+}
+
+func TestUpsertByDiscordID_RenamesOwnRecord(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+	service := birthday.NewServiceDB(&MockTimeProvider{}, db)
+	if err := service.UpsertByDiscordID(ctx, testGuildID, "42", "John", 3, 15, nil, nil, nil); err != nil {
+		t.Fatalf("Failed to register birthday: %v", err)
+	}
+
+	// Act
+	err := service.UpsertByDiscordID(ctx, testGuildID, "42", "John", 4, 1, nil, nil, nil)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected update of own record to succeed, got: %v", err)
+	}
+	b, _ := service.GetBirthdayByDiscordID(ctx, testGuildID, "42")
+	if b == nil || b.Month != 4 || b.Day != 1 {
+		t.Errorf("Expected birthday on 4/1, got %+v", b)
+	}
+}
+
+func TestGetBirthdayMessage_MentionsLinkedUsers(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+
+	discordID := "1234"
+	if err := db.AddBirthday(ctx, testGuildID, "John", 3, 15, nil, false, nil, &discordID); err != nil {
+		t.Fatalf("Failed to add test birthday: %v", err)
+	}
+	addTestBirthday(t, db, "Alice", 3, 15, nil)
 
 	timeProvider := &MockTimeProvider{
-		CurrentTime: time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC),
+		CurrentTime: time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC),
 	}
 	service := birthday.NewServiceDB(timeProvider, db)
 
 	// Act
-	message := service.ListAllBirthdays()
+	message := birthdayMessage(t, service)
 
 	// Assert
-	if !strings.Contains(message, "All Birthdays") {
-		t.Errorf("Expected header even with no birthdays, got: %q", message)
+	if !strings.Contains(message, "<@1234>") {
+		t.Errorf("Expected message to mention John, got: %q", message)
+	}
+	if !strings.Contains(message, "**Alice's birthday**") {
+		t.Errorf("Expected unlinked Alice to be named, got: %q", message)
 	}
 }
 
-func TestGetBirthdays_ReturnsCorrectFormat(t *testing.T) {
+func TestGetBirthdayMessage_UsesGuildTimezone(t *testing.T) {
 	// Arrange
 	db := setupTestDB(t)
+	addTestBirthday(t, db, "John", 3, 15, nil)
+	if err := db.UpsertGuild(ctx, database.Guild{GuildID: testGuildID, ChannelID: "100", Timezone: "America/New_York"}); err != nil {
+		t.Fatalf("Failed to configure guild: %v", err)
+	}
 
-	male := "male"
-	addTestBirthday(t, db, "John", 3, 15, &male)
+	// 02:00 UTC on March 16th is still March 15th in New York
+	timeProvider := &MockTimeProvider{
+		CurrentTime: time.Date(2025, 3, 16, 2, 0, 0, 0, time.UTC),
+	}
+	service := birthday.NewServiceDB(timeProvider, db)
+
+	// Act
+	message := birthdayMessage(t, service)
+
+	// Assert
+	if !strings.Contains(message, "John") {
+		t.Errorf("Expected John's birthday in the guild's timezone, got: %q", message)
+	}
+}
+
+func TestGetBirthdayMessage_OnlyIncludesGuild(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+	addTestBirthday(t, db, "John", 3, 15, nil)
+	if err := db.AddBirthday(ctx, "guild-2", "Alice", 3, 15, nil, false, nil, nil); err != nil {
+		t.Fatalf("Failed to add test birthday: %v", err)
+	}
 
 	timeProvider := &MockTimeProvider{
-		CurrentTime: time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC),
+		CurrentTime: time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC),
+	}
+	service := birthday.NewServiceDB(timeProvider, db)
+
+	// Act
+	message := birthdayMessage(t, service)
+
+	// Assert
+	if !strings.Contains(message, "John") {
+		t.Errorf("Expected message to contain 'John', got: %q", message)
+	}
+	if strings.Contains(message, "Alice") {
+		t.Errorf("Expected message to NOT contain another guild's 'Alice', got: %q", message)
+	}
+}
+
+func TestGuildSettings_DefaultsAndUpdates(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+	service := birthday.NewServiceDB(&MockTimeProvider{}, db)
+
+	// Act & Assert: an unconfigured guild gets the defaults
+	settings, err := service.GetGuildSettings(ctx, testGuildID)
+	if err != nil {
+		t.Fatalf("GetGuildSettings returned error: %v", err)
+	}
+	if settings.Hour != 9 || settings.Minute != 0 || settings.Timezone != "UTC" || settings.ChannelID != "" {
+		t.Errorf("Expected default settings, got %+v", settings)
+	}
+
+	// Act & Assert: each setting can be changed on its own
+	if err := service.SetAnnouncementChannel(ctx, testGuildID, "100"); err != nil {
+		t.Fatalf("SetAnnouncementChannel returned error: %v", err)
+	}
+	if err := service.SetAnnouncementTime(ctx, testGuildID, 8, 30); err != nil {
+		t.Fatalf("SetAnnouncementTime returned error: %v", err)
 	}
+	if err := service.SetTimezone(ctx, testGuildID, "Europe/London"); err != nil {
+		t.Fatalf("SetTimezone returned error: %v", err)
+	}
+
+	settings, _ = service.GetGuildSettings(ctx, testGuildID)
+	if settings.ChannelID != "100" || settings.Hour != 8 || settings.Minute != 30 || settings.Timezone != "Europe/London" {
+		t.Errorf("Expected updated settings, got %+v", settings)
+	}
+}
+
+func TestGuildSettings_RejectsInvalidValues(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+	service := birthday.NewServiceDB(&MockTimeProvider{}, db)
+
+	// Act & Assert
+	if err := service.SetTimezone(ctx, testGuildID, "Mars/Olympus_Mons"); err == nil {
+		t.Error("Expected error for unknown timezone")
+	}
+	if err := service.SetTimezone(ctx, testGuildID, "Local"); err == nil {
+		t.Error("Expected error for the container's Local timezone")
+	}
+	if err := service.SetAnnouncementTime(ctx, testGuildID, 24, 0); err == nil {
+		t.Error("Expected error for hour 24")
+	}
+	if err := service.SetAnnouncementTime(ctx, testGuildID, 8, 60); err == nil {
+		t.Error("Expected error for minute 60")
+	}
+	if err := service.SetLeapDayPolicy(ctx, testGuildID, "feb30"); err == nil {
+		t.Error("Expected error for unknown leap day policy")
+	}
+}
+
+func TestGetBirthdayMessage_Ages(t *testing.T) {
+	tests := []struct {
+		name      string
+		year      *int
+		private   bool
+		wantText  string
+		wantNoAge bool
+	}{
+		{"Regular age", intPtr(1996), false, "John is turning 29!", false},
+		{"Milestone 30", intPtr(1995), false, "John is turning **30**, a milestone birthday! 🥳", false},
+		{"Milestone 18", intPtr(2007), false, "John is turning **18**, a milestone birthday!", false},
+		{"Milestone 21", intPtr(2004), false, "John is turning **21**, a milestone birthday!", false},
+		{"Milestone 50", intPtr(1975), false, "John is turning **50**, a milestone birthday!", false},
+		{"Private year", intPtr(1995), true, "", true},
+		{"No year", nil, false, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			db := setupTestDB(t)
+			male := database.HeHim
+			if err := db.AddBirthday(ctx, testGuildID, "John", 3, 15, tt.year, tt.private, &male, nil); err != nil {
+				t.Fatalf("Failed to add test birthday: %v", err)
+			}
+			timeProvider := &MockTimeProvider{CurrentTime: time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC)}
+			service := birthday.NewServiceDB(timeProvider, db)
+
+			// Act
+			message := birthdayMessage(t, service)
+
+			// Assert
+			if !strings.Contains(message, "Today is **John's birthday**! 🎉") {
+				t.Errorf("Expected the birthday message, got: %q", message)
+			}
+			if tt.wantNoAge && strings.Contains(message, "turning") {
+				t.Errorf("Expected no age in message, got: %q", message)
+			}
+			if !tt.wantNoAge && !strings.Contains(message, tt.wantText) {
+				t.Errorf("Expected message to contain %q, got: %q", tt.wantText, message)
+			}
+		})
+	}
+}
+
+func TestAddBirthday_RejectsInvalidYear(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+	timeProvider := &MockTimeProvider{CurrentTime: time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC)}
 	service := birthday.NewServiceDB(timeProvider, db)
 
+	for _, year := range []int{1899, 2026} {
+		// Act
+		err := service.AddBirthday(ctx, testGuildID, "John", 4, 1, intPtr(year), false, nil, nil)
+
+		// Assert
+		if err == nil || !strings.Contains(err.Error(), "year must be between") {
+			t.Errorf("Expected invalid year error for %d, got: %v", year, err)
+		}
+	}
+}
+
+func TestGetAllBirthdays_HidesPrivateYears(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+	_ = db.AddBirthday(ctx, testGuildID, "Alice", 1, 25, intPtr(1990), false, nil, nil)
+	_ = db.AddBirthday(ctx, testGuildID, "Bob", 6, 10, intPtr(1991), true, nil, nil)
+	service := birthday.NewServiceDB(&MockTimeProvider{}, db)
+
 	// Act
-	people := service.GetBirthdays()
+	birthdays, err := service.GetAllBirthdays(ctx, testGuildID)
 
 	// Assert
-	if len(people.People) != 1 {
-		t.Fatalf("Expected 1 person, got %d", len(people.People))
+	if err != nil {
+		t.Fatalf("GetAllBirthdays returned error: %v", err)
+	}
+	if len(birthdays) != 2 {
+		t.Fatalf("Expected 2 birthdays, got %d", len(birthdays))
 	}
-	if people.People[0].Name != "John" {
-		t.Errorf("Expected name 'John', got %q", people.People[0].Name)
+	if birthdays[0].Year == nil || *birthdays[0].Year != 1990 {
+		t.Errorf("Expected Alice's year 1990, got %v", birthdays[0].Year)
 	}
-	if people.People[0].Birthday.Month != 3 {
-		t.Errorf("Expected month 3, got %d", people.People[0].Birthday.Month)
+	if birthdays[1].Year != nil {
+		t.Errorf("Expected Bob's private year to be hidden, got %v", *birthdays[1].Year)
 	}
-	if people.People[0].Birthday.Day != 15 {
-		t.Errorf("Expected day 15, got %d", people.People[0].Birthday.Day)
+
+	stored, _ := db.GetBirthday(ctx, testGuildID, "Bob")
+	if stored.Year == nil || *stored.Year != 1991 {
+		t.Errorf("Expected Bob's year to still be stored, got %v", stored.Year)
 	}
-	if people.People[0].Gender == nil || *people.People[0].Gender != "male" {
-		t.Errorf("Expected gender 'male', got %v", people.People[0].Gender)
+}
+
+func TestGetNextAndUpcomingBirthdays_AcrossYearEnd(t *testing.T) {
+	// Arrange: late on December 31st in New York, already January 1st in UTC
+	db := setupTestDB(t)
+	addTestBirthday(t, db, "Alice", 12, 31, nil)
+	addTestBirthday(t, db, "Bob", 1, 1, nil)
+	addTestBirthday(t, db, "Carol", 1, 3, nil)
+	addTestBirthday(t, db, "Dave", 1, 3, nil)
+	addTestBirthday(t, db, "Erin", 6, 1, nil)
+	timeProvider := &MockTimeProvider{CurrentTime: time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC)}
+	service := birthday.NewServiceDB(timeProvider, db)
+	if err := service.SetTimezone(ctx, testGuildID, "America/New_York"); err != nil {
+		t.Fatalf("SetTimezone returned error: %v", err)
+	}
+
+	names := func(upcoming []birthday.Upcoming) string {
+		var result []string
+		for _, u := range upcoming {
+			result = append(result, fmt.Sprintf("%s+%d", u.Name, u.DaysUntil))
+		}
+		return strings.Join(result, " ")
+	}
+
+	tests := []struct {
+		name string
+		get  func() ([]birthday.Upcoming, error)
+		want string
+	}{
+		{"Next", func() ([]birthday.Upcoming, error) { return service.GetNextBirthdays(ctx, testGuildID, 1) }, "Alice+0"},
+		{"Next includes everyone on the last day", func() ([]birthday.Upcoming, error) { return service.GetNextBirthdays(ctx, testGuildID, 3) }, "Alice+0 Bob+1 Carol+3 Dave+3"},
+		{"Next more than there are", func() ([]birthday.Upcoming, error) { return service.GetNextBirthdays(ctx, testGuildID, 10) }, "Alice+0 Bob+1 Carol+3 Dave+3 Erin+152"},
+		{"Upcoming", func() ([]birthday.Upcoming, error) { return service.GetUpcomingBirthdays(ctx, testGuildID, 2) }, "Alice+0 Bob+1"},
+		{"Upcoming includes the last day", func() ([]birthday.Upcoming, error) { return service.GetUpcomingBirthdays(ctx, testGuildID, 3) }, "Alice+0 Bob+1 Carol+3 Dave+3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			upcoming, err := tt.get()
+
+			// Assert
+			if err != nil {
+				t.Fatalf("returned error: %v", err)
+			}
+			if got := names(upcoming); got != tt.want {
+				t.Errorf("got %q; want %q", got, tt.want)
+			}
+		})
 	}
 }
+
+func TestTemplates_RejectsInvalidValues(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+	service := birthday.NewServiceDB(&MockTimeProvider{CurrentTime: time.Now()}, db)
+
+	tests := []struct {
+		name      string
+		kind      string
+		templates []string
+	}{
+		{"Unknown kind", "weekly", []string{"Hi"}},
+		{"No templates", database.AnnouncementBirthday, nil},
+		{"Too many templates", database.AnnouncementBirthday, []string{"1", "2", "3", "4", "5", "6"}},
+		{"Blank template", database.AnnouncementBirthday, []string{"Hi", "  "}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			err := service.SetTemplates(ctx, testGuildID, tt.kind, tt.templates)
+
+			// Assert
+			if !errors.Is(err, database.ErrInvalidValue) {
+				t.Errorf("SetTemplates() error = %v; want ErrInvalidValue", err)
+			}
+		})
+	}
+
+	if err := service.ResetTemplates(ctx, testGuildID, "weekly"); !errors.Is(err, database.ErrInvalidValue) {
+		t.Errorf("ResetTemplates() error = %v; want ErrInvalidValue", err)
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,101 @@
+package birthday
+
+import (
+	"sort"
+	"time"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+)
+
+// Celebration is a birthday and the day it is celebrated on this year. The two
+// only differ for February 29 birthdays in non-leap years. Today and Past are
+// relative to the guild's current date: Past is only set for earlier days of
+// the current month.
+type Celebration struct {
+	database.Birthday
+	Month time.Month
+	Day   int
+	Today bool
+	Past  bool
+}
+
+// MonthGroup is the birthdays celebrated in one month, in date order
+type MonthGroup struct {
+	Month        time.Month
+	Celebrations []Celebration
+}
+
+// GroupByMonth files birthdays under the month they are celebrated in this
+// year, following the leap day policy. Months come in calendar order and are
+// left out if nobody celebrates in them; within a month birthdays are sorted by
+// day, then name. today is the current time in the guild's timezone.
+func GroupByMonth(birthdays []database.Birthday, today time.Time, policy string) []MonthGroup {
+	celebrations := make([]Celebration, 0, len(birthdays))
+	for _, b := range birthdays {
+		month, day := CelebrationDate(b.Month, b.Day, today.Year(), policy)
+		celebrations = append(celebrations, Celebration{
+			Birthday: b,
+			Month:    month,
+			Day:      day,
+			Today:    month == today.Month() && day == today.Day(),
+			Past:     month == today.Month() && day < today.Day(),
+		})
+	}
+
+	sort.SliceStable(celebrations, func(i, j int) bool {
+		a, b := celebrations[i], celebrations[j]
+		if a.Month != b.Month {
+			return a.Month < b.Month
+		}
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		return a.Name < b.Name
+	})
+
+	var groups []MonthGroup
+	for _, c := range celebrations {
+		if len(groups) == 0 || groups[len(groups)-1].Month != c.Month {
+			groups = append(groups, MonthGroup{Month: c.Month})
+		}
+		group := &groups[len(groups)-1]
+		group.Celebrations = append(group.Celebrations, c)
+	}
+	return groups
+}
+
+// Upcoming is a birthday's next celebration and how many days away it is
+type Upcoming struct {
+	Celebration
+	Year      int
+	DaysUntil int
+}
+
+// UpcomingBirthdays returns the next celebration of every birthday, on or
+// after today, soonest first and then by name. February 29 birthdays follow the
+// leap day policy in non-leap years. today is the current time in the guild's
+// timezone.
+func UpcomingBirthdays(birthdays []database.Birthday, today time.Time, policy string) []Upcoming {
+	upcoming := make([]Upcoming, 0, len(birthdays))
+	for _, b := range birthdays {
+		date, daysUntil := NextCelebration(b.Month, b.Day, today, policy)
+		upcoming = append(upcoming, Upcoming{
+			Celebration: Celebration{
+				Birthday: b,
+				Month:    date.Month(),
+				Day:      date.Day(),
+				Today:    daysUntil == 0,
+			},
+			Year:      date.Year(),
+			DaysUntil: daysUntil,
+		})
+	}
+
+	sort.SliceStable(upcoming, func(i, j int) bool {
+		if upcoming[i].DaysUntil != upcoming[j].DaysUntil {
+			return upcoming[i].DaysUntil < upcoming[j].DaysUntil
+		}
+		return upcoming[i].Name < upcoming[j].Name
+	})
+	return upcoming
+}
@@ -0,0 +1,81 @@
+package birthday_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/birthday"
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+)
+
+func TestGroupByMonth(t *testing.T) {
+	// Arrange: listed out of order, with a leap day birthday in a non-leap year
+	birthdays := []database.Birthday{
+		{Name: "John", Month: 3, Day: 15},
+		{Name: "Leah", Month: 2, Day: 29},
+		{Name: "Bob", Month: 3, Day: 10},
+		{Name: "Alice", Month: 3, Day: 10},
+		{Name: "Casey", Month: 1, Day: 6},
+		{Name: "Marge", Month: 3, Day: 1},
+	}
+	today := time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC)
+
+	// Act
+	groups := birthday.GroupByMonth(birthdays, today, database.LeapDayMar1)
+
+	// Assert
+	var got []string
+	for _, group := range groups {
+		for _, c := range group.Celebrations {
+			got = append(got, fmt.Sprintf("%s %s %d today=%v past=%v", group.Month, c.Name, c.Day, c.Today, c.Past))
+		}
+	}
+	want := []string{
+		"January Casey 6 today=false past=false",
+		"March Leah 1 today=false past=true",
+		"March Marge 1 today=false past=true",
+		"March Alice 10 today=true past=false",
+		"March Bob 10 today=true past=false",
+		"March John 15 today=false past=false",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("GroupByMonth() =\n%v\nwant\n%v", got, want)
+	}
+	if len(groups) != 2 {
+		t.Errorf("Expected a group for January and March, got %d groups", len(groups))
+	}
+}
+
+func TestUpcomingBirthdays_AcrossYearEnd(t *testing.T) {
+	// Arrange: on December 30th 2027, next year is a leap year
+	birthdays := []database.Birthday{
+		{Name: "Leah", Month: 2, Day: 29},
+		{Name: "Carol", Month: 1, Day: 2},
+		{Name: "Bob", Month: 12, Day: 31},
+		{Name: "Alice", Month: 12, Day: 30},
+		{Name: "Zoe", Month: 12, Day: 29},
+		{Name: "Abe", Month: 1, Day: 2},
+	}
+	today := time.Date(2027, 12, 30, 23, 0, 0, 0, time.UTC)
+
+	// Act
+	upcoming := birthday.UpcomingBirthdays(birthdays, today, database.LeapDayMar1)
+
+	// Assert
+	var got []string
+	for _, u := range upcoming {
+		got = append(got, fmt.Sprintf("%s %s %d %d in=%d today=%v", u.Name, u.Month, u.Day, u.Year, u.DaysUntil, u.Today))
+	}
+	want := []string{
+		"Alice December 30 2027 in=0 today=true",
+		"Bob December 31 2027 in=1 today=false",
+		"Abe January 2 2028 in=3 today=false",
+		"Carol January 2 2028 in=3 today=false",
+		"Leah February 29 2028 in=61 today=false",
+		"Zoe December 29 2028 in=365 today=false",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("UpcomingBirthdays() =\n%v\nwant\n%v", got, want)
+	}
+}
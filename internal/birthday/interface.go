@@ -1,21 +1,172 @@
 package birthday
 
-import "github.com/nrzaman/baos-birthday-bot/util"
+import (
+	"context"
+	"time"
 
-// BirthdayService defines the interface for birthday-related operations
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+)
+
+// BirthdayService defines the interface for birthday-related operations.
+// Every birthday belongs to a Discord guild, and "today" is evaluated in that
+// guild's configured timezone. The service returns data, not messages; turning
+// it into text is left to the render package.
+//
+// Methods that change birthdays return errors wrapping database.ErrInvalidDate,
+// database.ErrInvalidValue, database.ErrDuplicateName or database.ErrNotFound
+// when the request itself is at fault; any other error is a storage failure.
 type BirthdayService interface {
 	// IsBirthdayToday checks if the given month and day match today's date
 	IsBirthdayToday(month int, day int) bool
 
-	// GetBirthdayMessage generates a birthday message for anyone with a birthday today
-	GetBirthdayMessage() string
+	// Now returns the current time in the guild's timezone
+	Now(ctx context.Context, guildID string) (time.Time, error)
+
+	// GetBirthdaysToday returns everyone in the guild with a birthday today,
+	// following the guild's leap day policy in non-leap years
+	GetBirthdaysToday(ctx context.Context, guildID string) ([]database.Birthday, error)
+
+	// GetMonthBirthdays returns the birthdays celebrated in the guild in the current month, in date order
+	GetMonthBirthdays(ctx context.Context, guildID string) (MonthGroup, error)
+
+	// GetBirthdaysByMonth returns every birthday in the guild grouped by the month it is celebrated in this year
+	GetBirthdaysByMonth(ctx context.Context, guildID string) ([]MonthGroup, error)
+
+	// GetNextBirthdays returns the next count birthdays celebrated in the guild,
+	// soonest first, along with anyone else celebrating on the same day as the last of them
+	GetNextBirthdays(ctx context.Context, guildID string, count int) ([]Upcoming, error)
+
+	// GetUpcomingBirthdays returns the birthdays celebrated in the guild from today until days days from now, soonest first
+	GetUpcomingBirthdays(ctx context.Context, guildID string, days int) ([]Upcoming, error)
+
+	// GetAllBirthdays returns every birthday in the guild in date order, without private years of birth
+	GetAllBirthdays(ctx context.Context, guildID string) ([]database.Birthday, error)
+
+	// FindBirthdays returns the birthdays in the guild whose name matches a
+	// search, ignoring case and small typos, soonest first
+	FindBirthdays(ctx context.Context, guildID, name string) ([]Upcoming, error)
+
+	// FindBirthdayByDiscordID returns the next celebration of the birthday linked to a Discord user, or nil if there is none
+	FindBirthdayByDiscordID(ctx context.Context, guildID, discordID string) (*Upcoming, error)
+
+	// AddBirthday adds a new birthday, rejecting invalid dates and duplicate names.
+	// year is optional; yearPrivate keeps it out of announcements.
+	AddBirthday(ctx context.Context, guildID, name string, month, day int, year *int, yearPrivate bool, pronouns *database.Pronouns, discordID *string) error
+
+	// UpdateBirthday changes the date of an existing birthday. A nil year,
+	// yearPrivate, pronouns or discordID leaves the stored value unchanged.
+	UpdateBirthday(ctx context.Context, guildID, name string, month, day int, year *int, yearPrivate *bool, pronouns *database.Pronouns, discordID *string) error
+
+	// RemoveBirthday removes an existing birthday
+	RemoveBirthday(ctx context.Context, guildID, name string) error
+
+	// GetBirthdayByDiscordID returns the birthday linked to a Discord user, or nil if there is none
+	GetBirthdayByDiscordID(ctx context.Context, guildID, discordID string) (*database.Birthday, error)
+
+	// UpsertByDiscordID registers or changes the birthday linked to a Discord user.
+	// A nil year, yearPrivate or pronouns leaves the stored value unchanged.
+	UpsertByDiscordID(ctx context.Context, guildID, discordID, name string, month, day int, year *int, yearPrivate *bool, pronouns *database.Pronouns) error
+
+	// RemoveByDiscordID removes the birthday linked to a Discord user
+	RemoveByDiscordID(ctx context.Context, guildID, discordID string) error
+
+	// GetGuilds returns the settings for every configured guild
+	GetGuilds(ctx context.Context) ([]database.Guild, error)
+
+	// GetGuildSettings returns a guild's settings, or the defaults if it has not been configured
+	GetGuildSettings(ctx context.Context, guildID string) (database.Guild, error)
+
+	// SetAnnouncementChannel sets the channel a guild's announcements are sent to
+	SetAnnouncementChannel(ctx context.Context, guildID, channelID string) error
+
+	// SetAnnouncementTime sets the time of day a guild's announcements are sent
+	SetAnnouncementTime(ctx context.Context, guildID string, hour, minute int) error
+
+	// SetTimezone sets a guild's timezone, rejecting unknown IANA names
+	SetTimezone(ctx context.Context, guildID, timezone string) error
+
+	// SetLeapDayPolicy sets when a guild celebrates February 29 birthdays in
+	// non-leap years: database.LeapDayFeb28 or database.LeapDayMar1
+	SetLeapDayPolicy(ctx context.Context, guildID, policy string) error
+
+	// MarkAnnounced records that the guild's announcements for the given local date were sent
+	MarkAnnounced(ctx context.Context, guildID string, date time.Time) error
+
+	// ClaimAnnouncement records an announcement of the given kind for the guild's
+	// local date in the ledger, returning false if it was already made
+	ClaimAnnouncement(ctx context.Context, guildID string, date time.Time, kind string) (bool, error)
+
+	// ReleaseAnnouncement removes a claimed announcement so it can be retried
+	ReleaseAnnouncement(ctx context.Context, guildID string, date time.Time, kind string) error
+
+	// GetAnnouncementProgress returns how many of the messages making up an
+	// announcement for the guild's local date were delivered, so a retry can resume
+	GetAnnouncementProgress(ctx context.Context, guildID string, date time.Time, kind string) (int, error)
+
+	// SetAnnouncementProgress records how many of the messages making up an
+	// announcement for the guild's local date were delivered
+	SetAnnouncementProgress(ctx context.Context, guildID string, date time.Time, kind string, sent int) error
+
+	// GetAnnouncementHistory returns the guild's most recent announcements, newest first
+	GetAnnouncementHistory(ctx context.Context, guildID string, limit int) ([]database.Announcement, error)
+
+	// GetTemplates returns the guild's templates for a kind of announcement
+	// (database.AnnouncementBirthday or database.AnnouncementMonthly), or nil if it uses the default
+	GetTemplates(ctx context.Context, guildID, kind string) ([]string, error)
+
+	// SetTemplates replaces the guild's templates for a kind of announcement.
+	// One variant is picked for each day by PickTemplate.
+	SetTemplates(ctx context.Context, guildID, kind string, templates []string) error
+
+	// ResetTemplates makes the guild use the default template for a kind of announcement
+	ResetTemplates(ctx context.Context, guildID, kind string) error
+
+	// PickTemplate picks one of the guild's templates for a kind of announcement,
+	// or returns "" if it uses the default. The pick changes from day to day but
+	// not within a day, so a retried announcement is rendered the same way.
+	PickTemplate(ctx context.Context, guildID, kind string) (string, error)
+
+	// GetBirthdayTemplates returns the template to announce each of today's
+	// birthdays with, in the same order: the person's override for today, the
+	// override for everyone today, a random guild template or "" for the default
+	GetBirthdayTemplates(ctx context.Context, guildID string, birthdays []database.Birthday) ([]string, error)
+
+	// SetOverride attaches a custom message to a date, for the named person or
+	// for everyone celebrating on it if name is empty
+	SetOverride(ctx context.Context, guildID string, month, day int, name, message string) error
+
+	// RemoveOverride removes the custom message for a date and person, or for everyone if name is empty
+	RemoveOverride(ctx context.Context, guildID string, month, day int, name string) error
+
+	// GetOverrides returns the guild's custom messages in date order
+	GetOverrides(ctx context.Context, guildID string) ([]database.Override, error)
+
+	// GetReminderSettings returns the guild's advance reminder settings
+	GetReminderSettings(ctx context.Context, guildID string) (database.ReminderSettings, error)
+
+	// SetReminderSettings replaces the guild's advance reminder settings.
+	// Settings without days turn reminders off.
+	SetReminderSettings(ctx context.Context, settings database.ReminderSettings) error
+
+	// GetDueReminders returns the guild's advance reminders due today, one for
+	// each of its reminder days with birthdays that many days away
+	GetDueReminders(ctx context.Context, guildID string) ([]Reminder, error)
+
+	// Subscribe subscribes a user to a DM the day before the named person's
+	// birthday, or before every birthday in the guild if name is empty
+	Subscribe(ctx context.Context, guildID, userID, name string) error
+
+	// Unsubscribe stops a user's DMs about the named person's birthday, or about every birthday if name is empty
+	Unsubscribe(ctx context.Context, guildID, userID, name string) error
 
-	// ListCurrentMonthBirthdays returns a string listing all birthdays in the current month
-	ListCurrentMonthBirthdays() string
+	// GetSubscriptions returns a user's subscriptions in the guild, with the last delivery failure if any
+	GetSubscriptions(ctx context.Context, guildID, userID string) ([]database.Subscription, error)
 
-	// ListAllBirthdays returns a string listing all birthdays
-	ListAllBirthdays() string
+	// GetDueNotifications returns the DMs due today in the guild, one for each
+	// subscriber with the birthdays they subscribed to that are tomorrow
+	GetDueNotifications(ctx context.Context, guildID string) ([]Notification, error)
 
-	// GetBirthdays returns all birthdays (for compatibility with existing code)
-	GetBirthdays() util.People
+	// RecordNotification records why the last DM to a subscriber could not be
+	// sent, or that it was if failure is empty
+	RecordNotification(ctx context.Context, guildID, userID, failure string) error
 }
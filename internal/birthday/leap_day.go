@@ -0,0 +1,40 @@
+package birthday
+
+import (
+	"time"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+)
+
+// IsLeapYear reports whether February has 29 days in the given year
+func IsLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+// CelebrationDate returns the month and day a birthday is celebrated in the
+// given year. February 29 birthdays move to February 28 or March 1 in non-leap
+// years, depending on the leap day policy; every other date is unchanged.
+func CelebrationDate(month, day, year int, policy string) (time.Month, int) {
+	if month != int(time.February) || day != 29 || IsLeapYear(year) {
+		return time.Month(month), day
+	}
+	if policy == database.LeapDayMar1 {
+		return time.March, 1
+	}
+	return time.February, 28
+}
+
+// NextCelebration returns the next date, on or after the day of now, a birthday
+// is celebrated, and how many days away it is
+func NextCelebration(month, day int, now time.Time, policy string) (time.Time, int) {
+	// Count whole calendar days in UTC so daylight saving changes don't shorten a day
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	for year := now.Year(); ; year++ {
+		m, d := CelebrationDate(month, day, year, policy)
+		date := time.Date(year, m, d, 0, 0, 0, 0, time.UTC)
+		if !date.Before(today) {
+			return date, int(date.Sub(today).Hours() / 24)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package birthday_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/birthday"
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+)
+
+func TestIsLeapYear(t *testing.T) {
+	tests := []struct {
+		year int
+		want bool
+	}{
+		{2024, true},
+		{2025, false},
+		{2028, true},
+		{1900, false},
+		{2000, true},
+		{2100, false},
+	}
+
+	for _, tt := range tests {
+		if got := birthday.IsLeapYear(tt.year); got != tt.want {
+			t.Errorf("IsLeapYear(%d) = %v; want %v", tt.year, got, tt.want)
+		}
+	}
+}
+
+func TestCelebrationDate(t *testing.T) {
+	tests := []struct {
+		name      string
+		month     int
+		day       int
+		year      int
+		policy    string
+		wantMonth time.Month
+		wantDay   int
+	}{
+		{"Leap day in leap year", 2, 29, 2024, database.LeapDayFeb28, time.February, 29},
+		{"Leap day in leap year ignores March 1 policy", 2, 29, 2024, database.LeapDayMar1, time.February, 29},
+		{"Leap day in non-leap year, February 28", 2, 29, 2025, database.LeapDayFeb28, time.February, 28},
+		{"Leap day in non-leap year, March 1", 2, 29, 2025, database.LeapDayMar1, time.March, 1},
+		{"Leap day in century non-leap year", 2, 29, 2100, database.LeapDayMar1, time.March, 1},
+		{"Unknown policy falls back to February 28", 2, 29, 2025, "", time.February, 28},
+		{"February 28 is unchanged", 2, 28, 2025, database.LeapDayMar1, time.February, 28},
+		{"March 1 is unchanged", 3, 1, 2025, database.LeapDayFeb28, time.March, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			month, day := birthday.CelebrationDate(tt.month, tt.day, tt.year, tt.policy)
+			if month != tt.wantMonth || day != tt.wantDay {
+				t.Errorf("CelebrationDate(%d, %d, %d, %q) = %s %d; want %s %d",
+					tt.month, tt.day, tt.year, tt.policy, month, day, tt.wantMonth, tt.wantDay)
+			}
+		})
+	}
+}
+
+func TestGetBirthdaysToday_LeapDay(t *testing.T) {
+	tests := []struct {
+		name      string
+		today     time.Time
+		policy    string
+		wantNames []string
+	}{
+		{"Leap year, February 28", time.Date(2024, 2, 28, 10, 0, 0, 0, time.UTC), database.LeapDayFeb28, []string{"Frank"}},
+		{"Leap year, February 29", time.Date(2024, 2, 29, 10, 0, 0, 0, time.UTC), database.LeapDayFeb28, []string{"Leah"}},
+		{"Leap year, March 1", time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), database.LeapDayMar1, []string{"Marge"}},
+		{"Non-leap year, February 28 policy on February 28", time.Date(2025, 2, 28, 10, 0, 0, 0, time.UTC), database.LeapDayFeb28, []string{"Frank", "Leah"}},
+		{"Non-leap year, February 28 policy on March 1", time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC), database.LeapDayFeb28, []string{"Marge"}},
+		{"Non-leap year, March 1 policy on February 28", time.Date(2025, 2, 28, 10, 0, 0, 0, time.UTC), database.LeapDayMar1, []string{"Frank"}},
+		{"Non-leap year, March 1 policy on March 1", time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC), database.LeapDayMar1, []string{"Marge", "Leah"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			db := setupTestDB(t)
+			addTestBirthday(t, db, "Frank", 2, 28, nil)
+			addTestBirthday(t, db, "Leah", 2, 29, nil)
+			addTestBirthday(t, db, "Marge", 3, 1, nil)
+			service := birthday.NewServiceDB(&MockTimeProvider{CurrentTime: tt.today}, db)
+			if err := service.SetLeapDayPolicy(ctx, testGuildID, tt.policy); err != nil {
+				t.Fatalf("SetLeapDayPolicy returned error: %v", err)
+			}
+
+			// Act
+			birthdays, err := service.GetBirthdaysToday(ctx, testGuildID)
+			message := birthdayMessage(t, service)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("GetBirthdaysToday returned error: %v", err)
+			}
+			var names []string
+			for _, b := range birthdays {
+				names = append(names, b.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("Birthdays today = %v; want %v", names, tt.wantNames)
+			}
+			for _, name := range tt.wantNames {
+				if !strings.Contains(message, name) {
+					t.Errorf("Expected message to mention %s, got %q", name, message)
+				}
+			}
+		})
+	}
+}
+
+func TestGetMonthBirthdays_LeapDay(t *testing.T) {
+	tests := []struct {
+		name   string
+		today  time.Time
+		policy string
+		want   string // empty if Leah isn't listed this month
+	}{
+		{"Leap year, February", time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC), database.LeapDayMar1, "Leah, February 29"},
+		{"Leap year, March", time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), database.LeapDayMar1, ""},
+		{"Non-leap year, February 28 policy in February", time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC), database.LeapDayFeb28, "Leah, February 28 (February 29)"},
+		{"Non-leap year, February 28 policy in March", time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC), database.LeapDayFeb28, ""},
+		{"Non-leap year, March 1 policy in February", time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC), database.LeapDayMar1, ""},
+		{"Non-leap year, March 1 policy in March", time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC), database.LeapDayMar1, "Leah, March 1 (February 29)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			db := setupTestDB(t)
+			addTestBirthday(t, db, "Leah", 2, 29, nil)
+			service := birthday.NewServiceDB(&MockTimeProvider{CurrentTime: tt.today}, db)
+			if err := service.SetLeapDayPolicy(ctx, testGuildID, tt.policy); err != nil {
+				t.Fatalf("SetLeapDayPolicy returned error: %v", err)
+			}
+
+			// Act
+			result := monthBirthdays(t, service)
+
+			// Assert
+			if tt.want == "" {
+				if result != "" {
+					t.Errorf("Expected Leah not to be listed, got %q", result)
+				}
+				return
+			}
+			if !strings.Contains(result, tt.want) {
+				t.Errorf("Expected %q in listing, got %q", tt.want, result)
+			}
+		})
+	}
+}
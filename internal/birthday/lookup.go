@@ -0,0 +1,105 @@
+package birthday
+
+import (
+	"context"
+	"strings"
+)
+
+// maxNameDistance is the most single-letter edits a name may be from a search
+// and still match it, so small typos still find the person
+const maxNameDistance = 2
+
+// FindBirthdays returns the birthdays in the guild whose name matches a search,
+// soonest first. An exact match, ignoring case, is returned on its own;
+// otherwise names containing the search match, and failing that names within a
+// couple of typos of it. Private years of birth are left out.
+func (s *ServiceDB) FindBirthdays(ctx context.Context, guildID, name string) ([]Upcoming, error) {
+	upcoming, err := s.upcoming(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	return MatchName(upcoming, name), nil
+}
+
+// FindBirthdayByDiscordID returns the next celebration of the birthday linked to
+// a Discord user, or nil if there is none. A private year of birth is left out.
+func (s *ServiceDB) FindBirthdayByDiscordID(ctx context.Context, guildID, discordID string) (*Upcoming, error) {
+	upcoming, err := s.upcoming(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range upcoming {
+		if id := upcoming[i].DiscordID; id != nil && *id == discordID {
+			return &upcoming[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// MatchName returns the birthdays whose name best matches a search, keeping
+// their order. Exact matches, ignoring case, beat names containing the search,
+// which beat names within maxNameDistance edits of it.
+func MatchName(upcoming []Upcoming, name string) []Upcoming {
+	query := strings.ToLower(strings.TrimSpace(name))
+	if query == "" {
+		return nil
+	}
+
+	var exact, partial, similar []Upcoming
+	for _, u := range upcoming {
+		candidate := strings.ToLower(u.Name)
+		switch {
+		case candidate == query:
+			exact = append(exact, u)
+		case strings.Contains(candidate, query):
+			partial = append(partial, u)
+		case editDistance(candidate, query) <= maxNameDistance:
+			similar = append(similar, u)
+		}
+	}
+
+	switch {
+	case len(exact) > 0:
+		return exact
+	case len(partial) > 0:
+		return partial
+	default:
+		return similar
+	}
+}
+
+// editDistance returns the Levenshtein distance between two strings: the
+// fewest single-letter insertions, deletions and substitutions turning one
+// into the other
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// minInt returns the smallest of its arguments
+func minInt(first int, rest ...int) int {
+	result := first
+	for _, n := range rest {
+		if n < result {
+			result = n
+		}
+	}
+	return result
+}
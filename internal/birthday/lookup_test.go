@@ -0,0 +1,82 @@
+package birthday_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/birthday"
+)
+
+func TestFindBirthdays(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+	addTestBirthday(t, db, "Alice", 3, 20, nil)
+	addTestBirthday(t, db, "Alicia", 3, 15, nil)
+	addTestBirthday(t, db, "Malik", 1, 5, nil)
+	addTestBirthday(t, db, "Bob", 7, 4, nil)
+	addTestBirthday(t, db, "Robert", 8, 1, nil)
+	timeProvider := &MockTimeProvider{CurrentTime: time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC)}
+	service := birthday.NewServiceDB(timeProvider, db)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"Exact match wins", "ALICE", []string{"Alice"}},
+		{"Partial matches, soonest first", "ali", []string{"Alicia", "Alice", "Malik"}},
+		{"Typo", "Bbo", []string{"Bob"}},
+		{"Surrounding spaces", "  robert ", []string{"Robert"}},
+		{"Too different", "Zed", nil},
+		{"Empty", " ", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			matches, err := service.FindBirthdays(ctx, testGuildID, tt.query)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("FindBirthdays returned error: %v", err)
+			}
+			var got []string
+			for _, match := range matches {
+				got = append(got, match.Name)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("FindBirthdays(%q) = %v; want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindBirthdayByDiscordID_HidesPrivateYear(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+	year, discordID := 1990, "42"
+	if err := db.AddBirthday(ctx, testGuildID, "Alice", 1, 25, &year, true, nil, &discordID); err != nil {
+		t.Fatalf("Failed to add test birthday: %v", err)
+	}
+	timeProvider := &MockTimeProvider{CurrentTime: time.Date(2025, 12, 31, 10, 0, 0, 0, time.UTC)}
+	service := birthday.NewServiceDB(timeProvider, db)
+
+	// Act
+	match, err := service.FindBirthdayByDiscordID(ctx, testGuildID, "42")
+	missing, missingErr := service.FindBirthdayByDiscordID(ctx, testGuildID, "7")
+
+	// Assert
+	if err != nil || missingErr != nil {
+		t.Fatalf("FindBirthdayByDiscordID returned errors: %v, %v", err, missingErr)
+	}
+	if match == nil || match.Name != "Alice" || match.Year != 2026 || match.DaysUntil != 25 {
+		t.Fatalf("Expected Alice on January 25 2026 in 25 days, got %+v", match)
+	}
+	if match.Birthday.Year != nil {
+		t.Errorf("Expected the private year of birth to be left out, got %d", *match.Birthday.Year)
+	}
+	if missing != nil {
+		t.Errorf("Expected no birthday for an unlinked user, got %+v", missing)
+	}
+}
@@ -0,0 +1,155 @@
+package birthday
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+)
+
+// SetOverride attaches a custom message to a date, for the named person or for
+// everyone celebrating on it if name is empty. The message is a birthday
+// announcement template. A name must belong to a birthday in the guild on that
+// date, matched ignoring case, or the message would never be used. The message
+// is stored against the birthday rather than its name, so it follows a rename
+// and is deleted with the birthday.
+func (s *ServiceDB) SetOverride(ctx context.Context, guildID string, month, day int, name, message string) error {
+	if strings.TrimSpace(message) == "" {
+		return fmt.Errorf("%w: the message cannot be empty", database.ErrInvalidValue)
+	}
+	var birthdayID *int
+	if name = strings.TrimSpace(name); name != "" {
+		if err := database.ValidateDate(month, day); err != nil {
+			return err
+		}
+		b, err := s.findBirthday(ctx, guildID, name)
+		if err != nil {
+			return err
+		}
+		if b.Month != month || b.Day != day {
+			return fmt.Errorf("%w: %s's birthday is on %s %d, not %s %d", database.ErrInvalidValue,
+				b.Name, time.Month(b.Month), b.Day, time.Month(month), day)
+		}
+		birthdayID = &b.ID
+	}
+	return s.db.SetOverride(ctx, guildID, month, day, birthdayID, message)
+}
+
+// findBirthday returns the guild's birthday with a name. An exact match wins;
+// otherwise the name is matched ignoring case, and an ErrInvalidValue error is
+// returned if that matches several birthdays (e.g. "Alex" and "alex" when
+// looking for "ALEX"), or an ErrNotFound error if it matches none.
+func (s *ServiceDB) findBirthday(ctx context.Context, guildID, name string) (*database.Birthday, error) {
+	birthdays, err := s.db.GetAllBirthdays(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*database.Birthday
+	for i := range birthdays {
+		if birthdays[i].Name == name {
+			return &birthdays[i], nil
+		}
+		if strings.EqualFold(birthdays[i].Name, name) {
+			matches = append(matches, &birthdays[i])
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w: no birthday found for %s", database.ErrNotFound, name)
+	case 1:
+		return matches[0], nil
+	default:
+		names := make([]string, len(matches))
+		for i, b := range matches {
+			names[i] = b.Name
+		}
+		return nil, fmt.Errorf("%w: %s matches several birthdays (%s); use the exact name", database.ErrInvalidValue,
+			name, strings.Join(names, ", "))
+	}
+}
+
+// RemoveOverride removes the custom message for a date and person, or for
+// everyone if name is empty
+func (s *ServiceDB) RemoveOverride(ctx context.Context, guildID string, month, day int, name string) error {
+	var birthdayID *int
+	if name = strings.TrimSpace(name); name != "" {
+		b, err := s.findBirthday(ctx, guildID, name)
+		if err != nil {
+			return err
+		}
+		birthdayID = &b.ID
+	}
+	return s.db.DeleteOverride(ctx, guildID, month, day, birthdayID)
+}
+
+// GetOverrides returns the guild's custom messages in date order
+func (s *ServiceDB) GetOverrides(ctx context.Context, guildID string) ([]database.Override, error) {
+	return s.db.GetOverrides(ctx, guildID)
+}
+
+// PickTemplate picks one of the guild's templates for a kind of announcement,
+// or returns "" if it uses the default. The pick varies from day to day but is
+// the same all day, as it hashes the guild, its local date and the kind, so a
+// retried announcement renders the same messages and can resume where it stopped.
+func (s *ServiceDB) PickTemplate(ctx context.Context, guildID, kind string) (string, error) {
+	templates, err := s.GetTemplates(ctx, guildID, kind)
+	if err != nil || len(templates) == 0 {
+		return "", err
+	}
+	now, _, err := s.today(ctx, guildID)
+	if err != nil {
+		return "", err
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(guildID + "|" + now.Format("2006-01-02") + "|" + kind)) // Never fails
+	return templates[hash.Sum32()%uint32(len(templates))], nil
+}
+
+// GetBirthdayTemplates returns the template to announce each of today's
+// birthdays with, in the same order. Overrides are looked up by the date each
+// birthday is stored on rather than today's date, so an override for February
+// 29th is used when that birthday is celebrated on February 28th or March 1st.
+// The first of these that exists is used:
+//
+//  1. the override for the person on their birthday
+//  2. the override for everyone on their birthday
+//  3. one of the guild's birthday templates, picked once per announcement by PickTemplate
+//  4. "", meaning the default template
+func (s *ServiceDB) GetBirthdayTemplates(ctx context.Context, guildID string, birthdays []database.Birthday) ([]string, error) {
+	template, err := s.PickTemplate(ctx, guildID, database.AnnouncementBirthday)
+	if err != nil {
+		return nil, err
+	}
+
+	// Birthdays announced together are on at most a few dates
+	overridesByDate := make(map[[2]int][]database.Override)
+	templates := make([]string, len(birthdays))
+	for i, b := range birthdays {
+		date := [2]int{b.Month, b.Day}
+		overrides, ok := overridesByDate[date]
+		if !ok {
+			if overrides, err = s.db.GetOverridesByDate(ctx, guildID, b.Month, b.Day); err != nil {
+				return nil, err
+			}
+			overridesByDate[date] = overrides
+		}
+
+		templates[i] = template
+		for _, o := range overrides {
+			if o.BirthdayID == nil {
+				templates[i] = o.Message
+			}
+		}
+		for _, o := range overrides {
+			if o.BirthdayID != nil && *o.BirthdayID == b.ID {
+				templates[i] = o.Message
+			}
+		}
+	}
+	return templates, nil
+}
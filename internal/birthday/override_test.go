@@ -0,0 +1,217 @@
+package birthday_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/birthday"
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+)
+
+func TestGetBirthdayTemplates_Precedence(t *testing.T) {
+	// Arrange: everyone celebrates on March 14
+	db := setupTestDB(t)
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		addTestBirthday(t, db, name, 3, 14, nil)
+	}
+	if err := db.AddBirthday(ctx, "guild-2", "Alice", 3, 14, nil, false, nil, nil); err != nil {
+		t.Fatalf("Failed to add birthday: %v", err)
+	}
+	timeProvider := &MockTimeProvider{CurrentTime: time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC)}
+	service := birthday.NewServiceDB(timeProvider, db)
+	birthdays, err := service.GetBirthdaysToday(ctx, testGuildID)
+	if err != nil {
+		t.Fatalf("GetBirthdaysToday returned error: %v", err)
+	}
+
+	steps := []struct {
+		name   string
+		change func() error
+		want   []string
+	}{
+		{"Default", func() error { return nil }, []string{"", "", ""}},
+		{"Guild template", func() error {
+			return service.SetTemplates(ctx, testGuildID, database.AnnouncementBirthday, []string{"guild"})
+		}, []string{"guild", "guild", "guild"}},
+		{"Override for someone else's date", func() error {
+			return service.SetOverride(ctx, testGuildID, 3, 15, "", "tomorrow")
+		}, []string{"guild", "guild", "guild"}},
+		{"Override for everyone", func() error {
+			return service.SetOverride(ctx, testGuildID, 3, 14, "", "everyone")
+		}, []string{"everyone", "everyone", "everyone"}},
+		{"Override for a person, ignoring case", func() error {
+			return service.SetOverride(ctx, testGuildID, 3, 14, "bob", "bob")
+		}, []string{"everyone", "bob", "everyone"}},
+		{"Override for a person without the everyone override", func() error {
+			return service.RemoveOverride(ctx, testGuildID, 3, 14, "")
+		}, []string{"guild", "bob", "guild"}},
+		{"Override for another guild", func() error {
+			return service.SetOverride(ctx, "guild-2", 3, 14, "Alice", "other guild")
+		}, []string{"guild", "bob", "guild"}},
+	}
+
+	for _, step := range steps {
+		// Act
+		if err := step.change(); err != nil {
+			t.Fatalf("%s: change returned error: %v", step.name, err)
+		}
+		templates, err := service.GetBirthdayTemplates(ctx, testGuildID, birthdays)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("%s: GetBirthdayTemplates returned error: %v", step.name, err)
+		}
+		if fmt.Sprint(templates) != fmt.Sprint(step.want) {
+			t.Errorf("%s: GetBirthdayTemplates() = %q; want %q", step.name, templates, step.want)
+		}
+	}
+}
+
+func TestGetBirthdayTemplates_LeapDayOverride(t *testing.T) {
+	// Arrange: in 2025 February 29th birthdays are celebrated on March 1st,
+	// alongside Bob's
+	db := setupTestDB(t)
+	addTestBirthday(t, db, "Alice", 2, 29, nil)
+	addTestBirthday(t, db, "Bob", 3, 1, nil)
+	timeProvider := &MockTimeProvider{CurrentTime: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)}
+	service := birthday.NewServiceDB(timeProvider, db)
+	if err := service.SetLeapDayPolicy(ctx, testGuildID, database.LeapDayMar1); err != nil {
+		t.Fatalf("SetLeapDayPolicy returned error: %v", err)
+	}
+	for _, err := range []error{
+		service.SetOverride(ctx, testGuildID, 2, 29, "Alice", "leap"),
+		service.SetOverride(ctx, testGuildID, 3, 1, "", "march"),
+	} {
+		if err != nil {
+			t.Fatalf("SetOverride returned error: %v", err)
+		}
+	}
+	birthdays, err := service.GetBirthdaysToday(ctx, testGuildID)
+	if err != nil {
+		t.Fatalf("GetBirthdaysToday returned error: %v", err)
+	}
+
+	// Act
+	templates, err := service.GetBirthdayTemplates(ctx, testGuildID, birthdays)
+
+	// Assert: each birthday gets the overrides for the date it is stored on
+	if err != nil {
+		t.Fatalf("GetBirthdayTemplates returned error: %v", err)
+	}
+	got := make(map[string]string)
+	for i, b := range birthdays {
+		got[b.Name] = templates[i]
+	}
+	if got["Alice"] != "leap" || got["Bob"] != "march" {
+		t.Errorf("GetBirthdayTemplates() = %v; want Alice's leap day override and Bob's March 1st one", got)
+	}
+}
+
+func TestOverrides_SetReplaceAndRemove(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+	addTestBirthday(t, db, "Casey", 1, 6, nil)
+	service := birthday.NewServiceDB(&MockTimeProvider{CurrentTime: time.Now()}, db)
+
+	// Act
+	errs := []error{
+		service.SetOverride(ctx, testGuildID, 1, 6, "Casey", "first"),
+		service.SetOverride(ctx, testGuildID, 1, 6, "casey ", "second"),
+		service.SetOverride(ctx, testGuildID, 1, 1, "", "new year"),
+	}
+	overrides, err := service.GetOverrides(ctx, testGuildID)
+
+	// Assert
+	for _, err := range append(errs, err) {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if len(overrides) != 2 || overrides[0].Message != "new year" || overrides[1].Message != "second" {
+		t.Errorf("Expected setting the same date and name again to replace the message, got %+v", overrides)
+	}
+	if err := service.SetOverride(ctx, testGuildID, 2, 30, "", "hi"); !errors.Is(err, database.ErrInvalidDate) {
+		t.Errorf("SetOverride() on an impossible date error = %v; want ErrInvalidDate", err)
+	}
+	if err := service.SetOverride(ctx, testGuildID, 1, 6, "", " "); !errors.Is(err, database.ErrInvalidValue) {
+		t.Errorf("SetOverride() with an empty message error = %v; want ErrInvalidValue", err)
+	}
+	if err := service.SetOverride(ctx, testGuildID, 1, 6, "Cassie", "hi"); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("SetOverride() for a name without a birthday error = %v; want ErrNotFound", err)
+	}
+	if err := service.SetOverride(ctx, testGuildID, 1, 7, "Casey", "hi"); !errors.Is(err, database.ErrInvalidValue) {
+		t.Errorf("SetOverride() on a date other than the person's birthday error = %v; want ErrInvalidValue", err)
+	}
+	if overrides[1].Name != "Casey" {
+		t.Errorf("Override name = %q; want the stored spelling %q", overrides[1].Name, "Casey")
+	}
+	if err := service.RemoveOverride(ctx, testGuildID, 1, 6, "CASEY"); err != nil {
+		t.Errorf("RemoveOverride() returned error: %v", err)
+	}
+	if err := service.RemoveOverride(ctx, testGuildID, 1, 6, "Casey"); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("RemoveOverride() of a missing override error = %v; want ErrNotFound", err)
+	}
+}
+
+func TestOverrides_AreDeletedWithTheBirthday(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+	addTestBirthday(t, db, "Casey", 1, 6, nil)
+	service := birthday.NewServiceDB(&MockTimeProvider{CurrentTime: time.Now()}, db)
+	if err := service.SetOverride(ctx, testGuildID, 1, 6, "Casey", "for the first Casey"); err != nil {
+		t.Fatalf("SetOverride returned error: %v", err)
+	}
+
+	// Act: Casey is removed and someone else is added under the same name
+	if err := db.DeleteBirthday(ctx, testGuildID, "Casey"); err != nil {
+		t.Fatalf("DeleteBirthday returned error: %v", err)
+	}
+	addTestBirthday(t, db, "Casey", 1, 6, nil)
+	birthdays, _ := db.GetBirthdaysByDate(ctx, testGuildID, 1, 6)
+	templates, err := service.GetBirthdayTemplates(ctx, testGuildID, birthdays)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetBirthdayTemplates returned error: %v", err)
+	}
+	if len(templates) != 1 || templates[0] != "" {
+		t.Errorf("GetBirthdayTemplates() = %q; want the new Casey not to get the old Casey's message", templates)
+	}
+}
+
+func TestPickTemplate(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+	timeProvider := &MockTimeProvider{CurrentTime: time.Now()}
+	service := birthday.NewServiceDB(timeProvider, db)
+
+	// Act & Assert: no templates means the default
+	if template, err := service.PickTemplate(ctx, testGuildID, database.AnnouncementMonthly); err != nil || template != "" {
+		t.Errorf("PickTemplate() = %q, %v; want the default", template, err)
+	}
+
+	// Every variant is eventually picked on some day, and each day always picks the same one
+	variants := []string{"a", "b", "c"}
+	if err := service.SetTemplates(ctx, testGuildID, database.AnnouncementMonthly, variants); err != nil {
+		t.Fatalf("SetTemplates returned error: %v", err)
+	}
+	picked := map[string]bool{}
+	day := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 200 && len(picked) < len(variants); i++ {
+		timeProvider.CurrentTime = day.AddDate(0, 0, i)
+		template, err := service.PickTemplate(ctx, testGuildID, database.AnnouncementMonthly)
+		if err != nil {
+			t.Fatalf("PickTemplate returned error: %v", err)
+		}
+		timeProvider.CurrentTime = timeProvider.CurrentTime.Add(6 * time.Hour)
+		if again, _ := service.PickTemplate(ctx, testGuildID, database.AnnouncementMonthly); again != template {
+			t.Fatalf("PickTemplate() on %s = %q, then %q later that day; want the same variant", day.AddDate(0, 0, i).Format("2006-01-02"), template, again)
+		}
+		picked[template] = true
+	}
+	if len(picked) != len(variants) {
+		t.Errorf("Expected every variant to be picked, got %v", picked)
+	}
+}
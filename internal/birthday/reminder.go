@@ -0,0 +1,82 @@
+package birthday
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+)
+
+// MaxReminders is the most reminders a guild can have before each birthday
+const MaxReminders = 5
+
+// MaxReminderDays is the furthest ahead of a birthday a reminder can be sent
+const MaxReminderDays = 60
+
+// Reminder is an advance reminder of the birthdays some days away
+type Reminder struct {
+	DaysBefore int
+	Birthdays  []Upcoming
+}
+
+// GetReminderSettings returns a guild's reminder settings
+func (s *ServiceDB) GetReminderSettings(ctx context.Context, guildID string) (database.ReminderSettings, error) {
+	return s.db.GetReminderSettings(ctx, guildID)
+}
+
+// SetReminderSettings replaces a guild's reminder settings, rejecting days out
+// of range and settings without an organizers channel to post in. Reminders
+// are never posted in the announcement channel, where the people having a
+// birthday would see them. Settings without days turn reminders off.
+func (s *ServiceDB) SetReminderSettings(ctx context.Context, settings database.ReminderSettings) error {
+	days := make([]int, 0, len(settings.Days))
+	seen := make(map[int]bool, len(settings.Days))
+	for _, d := range settings.Days {
+		if d < 1 || d > MaxReminderDays {
+			return fmt.Errorf("%w: reminders can be sent 1 to %d days before a birthday, got %d", database.ErrInvalidValue, MaxReminderDays, d)
+		}
+		if !seen[d] {
+			seen[d] = true
+			days = append(days, d)
+		}
+	}
+	if len(days) > MaxReminders {
+		return fmt.Errorf("%w: at most %d reminders are allowed, got %d", database.ErrInvalidValue, MaxReminders, len(days))
+	}
+	if len(days) > 0 && settings.ChannelID == "" {
+		return fmt.Errorf("%w: give an organizers channel to post reminders in", database.ErrInvalidValue)
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(days)))
+	settings.Days = days
+	return s.db.SetReminderSettings(ctx, settings)
+}
+
+// GetDueReminders returns the guild's reminders due today, furthest ahead
+// first: one for each of its reminder days with birthdays exactly that many
+// days away
+func (s *ServiceDB) GetDueReminders(ctx context.Context, guildID string) ([]Reminder, error) {
+	settings, err := s.db.GetReminderSettings(ctx, guildID)
+	if err != nil || len(settings.Days) == 0 {
+		return nil, err
+	}
+	upcoming, err := s.upcoming(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	var reminders []Reminder
+	for _, days := range settings.Days {
+		reminder := Reminder{DaysBefore: days}
+		for _, u := range upcoming {
+			if u.DaysUntil == days {
+				reminder.Birthdays = append(reminder.Birthdays, u)
+			}
+		}
+		if len(reminder.Birthdays) > 0 {
+			reminders = append(reminders, reminder)
+		}
+	}
+	return reminders, nil
+}
@@ -0,0 +1,109 @@
+package birthday_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/birthday"
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+)
+
+func TestGetDueReminders(t *testing.T) {
+	// Arrange: on December 25th, reminders go out 7 days and 1 day ahead
+	db := setupTestDB(t)
+	addTestBirthday(t, db, "Bob", 1, 1, nil)
+	addTestBirthday(t, db, "Abe", 1, 1, nil)
+	addTestBirthday(t, db, "Alice", 12, 26, nil)
+	addTestBirthday(t, db, "Carol", 12, 28, nil)
+	addTestBirthday(t, db, "Dana", 12, 25, nil)
+	timeProvider := &MockTimeProvider{CurrentTime: time.Date(2025, 12, 25, 10, 0, 0, 0, time.UTC)}
+	service := birthday.NewServiceDB(timeProvider, db)
+
+	settings := database.ReminderSettings{GuildID: testGuildID, ChannelID: "organizers", Days: []int{1, 7}}
+	if err := service.SetReminderSettings(ctx, settings); err != nil {
+		t.Fatalf("SetReminderSettings returned error: %v", err)
+	}
+
+	// Act
+	reminders, err := service.GetDueReminders(ctx, testGuildID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetDueReminders returned error: %v", err)
+	}
+	var got []string
+	for _, r := range reminders {
+		for _, u := range r.Birthdays {
+			got = append(got, fmt.Sprintf("%d: %s %s %d %d", r.DaysBefore, u.Name, u.Month, u.Day, u.Year))
+		}
+	}
+	want := []string{"7: Abe January 1 2026", "7: Bob January 1 2026", "1: Alice December 26 2025"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("GetDueReminders() = %v; want %v", got, want)
+	}
+}
+
+func TestGetDueReminders_Off(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+	addTestBirthday(t, db, "Alice", 12, 26, nil)
+	timeProvider := &MockTimeProvider{CurrentTime: time.Date(2025, 12, 25, 10, 0, 0, 0, time.UTC)}
+	service := birthday.NewServiceDB(timeProvider, db)
+
+	// Act
+	reminders, err := service.GetDueReminders(ctx, testGuildID)
+
+	// Assert
+	if err != nil || len(reminders) != 0 {
+		t.Errorf("GetDueReminders() = %+v, %v; want no reminders", reminders, err)
+	}
+}
+
+func TestSetReminderSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings database.ReminderSettings
+		wantErr  bool
+		wantDays []int
+	}{
+		{"Sorted furthest first without duplicates", database.ReminderSettings{ChannelID: "organizers", Days: []int{1, 7, 1, 14}}, false, []int{14, 7, 1}},
+		{"Off", database.ReminderSettings{}, false, nil},
+		{"No channel or role", database.ReminderSettings{Days: []int{7}}, true, nil},
+		{"Role without a channel", database.ReminderSettings{RoleID: "888", Days: []int{7}}, true, nil},
+		{"Too soon", database.ReminderSettings{ChannelID: "organizers", Days: []int{0}}, true, nil},
+		{"Too far ahead", database.ReminderSettings{ChannelID: "organizers", Days: []int{birthday.MaxReminderDays + 1}}, true, nil},
+		{"Too many", database.ReminderSettings{ChannelID: "organizers", Days: []int{1, 2, 3, 4, 5, 6}}, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			db := setupTestDB(t)
+			service := birthday.NewServiceDB(&MockTimeProvider{CurrentTime: time.Now()}, db)
+			tt.settings.GuildID = testGuildID
+
+			// Act
+			err := service.SetReminderSettings(ctx, tt.settings)
+
+			// Assert
+			if tt.wantErr {
+				if !errors.Is(err, database.ErrInvalidValue) {
+					t.Errorf("SetReminderSettings() error = %v; want ErrInvalidValue", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetReminderSettings returned error: %v", err)
+			}
+			saved, err := service.GetReminderSettings(ctx, testGuildID)
+			if err != nil {
+				t.Fatalf("GetReminderSettings returned error: %v", err)
+			}
+			if fmt.Sprint(saved.Days) != fmt.Sprint(tt.wantDays) {
+				t.Errorf("Saved days = %v; want %v", saved.Days, tt.wantDays)
+			}
+		})
+	}
+}
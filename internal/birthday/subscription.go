@@ -0,0 +1,109 @@
+package birthday
+
+import (
+	"context"
+	"strings"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+)
+
+// NotificationDays is how many days before a birthday subscribers get a DM
+const NotificationDays = 1
+
+// Notification is a DM telling a subscriber about the birthdays they
+// subscribed to that are NotificationDays away
+type Notification struct {
+	UserID string
+	Reminder
+}
+
+// Subscribe subscribes a user to DMs about the named person's birthday, or
+// about every birthday in the guild if name is empty. The name must belong to
+// a birthday in the guild, matched ignoring case. The subscription is stored
+// against the birthday rather than its name, so it follows a rename and is
+// deleted with the birthday.
+func (s *ServiceDB) Subscribe(ctx context.Context, guildID, userID, name string) error {
+	birthdayID, err := s.subscriptionBirthday(ctx, guildID, name)
+	if err != nil {
+		return err
+	}
+	return s.db.AddSubscription(ctx, guildID, userID, birthdayID)
+}
+
+// Unsubscribe stops a user's DMs about the named person's birthday, or about
+// every birthday if name is empty
+func (s *ServiceDB) Unsubscribe(ctx context.Context, guildID, userID, name string) error {
+	birthdayID, err := s.subscriptionBirthday(ctx, guildID, name)
+	if err != nil {
+		return err
+	}
+	return s.db.DeleteSubscription(ctx, guildID, userID, birthdayID)
+}
+
+// subscriptionBirthday returns the ID of the birthday a subscription to name
+// is for, or nil for every birthday if name is empty
+func (s *ServiceDB) subscriptionBirthday(ctx context.Context, guildID, name string) (*int, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, nil
+	}
+	b, err := s.findBirthday(ctx, guildID, name)
+	if err != nil {
+		return nil, err
+	}
+	return &b.ID, nil
+}
+
+// GetSubscriptions returns a user's subscriptions in the guild
+func (s *ServiceDB) GetSubscriptions(ctx context.Context, guildID, userID string) ([]database.Subscription, error) {
+	return s.db.GetSubscriptions(ctx, guildID, userID)
+}
+
+// GetDueNotifications returns the DMs due today in the guild: one for each
+// subscriber with the birthdays they subscribed to that are NotificationDays
+// away. Nobody is told about their own birthday.
+func (s *ServiceDB) GetDueNotifications(ctx context.Context, guildID string) ([]Notification, error) {
+	subscriptions, err := s.db.GetGuildSubscriptions(ctx, guildID)
+	if err != nil || len(subscriptions) == 0 {
+		return nil, err
+	}
+	upcoming, err := s.upcoming(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Subscriptions are ordered by user, so each user's are together
+	var notifications []Notification
+	for i := 0; i < len(subscriptions); {
+		userID := subscriptions[i].UserID
+		everyone := false
+		birthdayIDs := make(map[int]bool)
+		for ; i < len(subscriptions) && subscriptions[i].UserID == userID; i++ {
+			if id := subscriptions[i].BirthdayID; id != nil {
+				birthdayIDs[*id] = true
+			} else {
+				everyone = true
+			}
+		}
+
+		notification := Notification{UserID: userID, Reminder: Reminder{DaysBefore: NotificationDays}}
+		for _, u := range upcoming {
+			if u.DaysUntil != NotificationDays || (u.DiscordID != nil && *u.DiscordID == userID) {
+				continue
+			}
+			if everyone || birthdayIDs[u.ID] {
+				notification.Birthdays = append(notification.Birthdays, u)
+			}
+		}
+		if len(notification.Birthdays) > 0 {
+			notifications = append(notifications, notification)
+		}
+	}
+	return notifications, nil
+}
+
+// RecordNotification records whether the last DM to a subscriber was sent:
+// failure says why it could not be, or is empty if it was
+func (s *ServiceDB) RecordNotification(ctx context.Context, guildID, userID, failure string) error {
+	return s.db.SetSubscriptionFailure(ctx, guildID, userID, failure, s.timeProvider.Now())
+}
@@ -0,0 +1,168 @@
+package birthday_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/birthday"
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+)
+
+func TestGetDueNotifications(t *testing.T) {
+	// Arrange: on December 25th, Alice and Bob celebrate tomorrow and Carol in
+	// three days. Alice is a subscriber herself.
+	db := setupTestDB(t)
+	addTestBirthday(t, db, "Bob", 12, 26, nil)
+	addTestBirthday(t, db, "Carol", 12, 28, nil)
+	aliceID := "7"
+	if err := db.AddBirthday(ctx, testGuildID, "Alice", 12, 26, nil, false, nil, &aliceID); err != nil {
+		t.Fatalf("Failed to add birthday: %v", err)
+	}
+	timeProvider := &MockTimeProvider{CurrentTime: time.Date(2025, 12, 25, 10, 0, 0, 0, time.UTC)}
+	service := birthday.NewServiceDB(timeProvider, db)
+
+	subscriptions := [][2]string{
+		{"5", "bob"},   // Matched ignoring case
+		{"6", "Carol"}, // Not tomorrow
+		{aliceID, ""},  // Everyone but herself
+		{"8", ""},
+		{"8", "Alice"}, // Alice is only listed once
+	}
+	for _, s := range subscriptions {
+		if err := service.Subscribe(ctx, testGuildID, s[0], s[1]); err != nil {
+			t.Fatalf("Subscribe(%q, %q) returned error: %v", s[0], s[1], err)
+		}
+	}
+
+	// Act
+	notifications, err := service.GetDueNotifications(ctx, testGuildID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("GetDueNotifications returned error: %v", err)
+	}
+	var got []string
+	for _, n := range notifications {
+		for _, u := range n.Birthdays {
+			got = append(got, fmt.Sprintf("%s: %s in %d", n.UserID, u.Name, n.DaysBefore))
+		}
+	}
+	want := []string{"5: Bob in 1", "7: Bob in 1", "8: Alice in 1", "8: Bob in 1"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("GetDueNotifications() = %v; want %v", got, want)
+	}
+}
+
+func TestSubscriptions(t *testing.T) {
+	// Arrange
+	db := setupTestDB(t)
+	addTestBirthday(t, db, "Alice", 12, 26, nil)
+	timeProvider := &MockTimeProvider{CurrentTime: time.Date(2025, 12, 25, 10, 0, 0, 0, time.UTC)}
+	service := birthday.NewServiceDB(timeProvider, db)
+
+	// Act & Assert: subscribing uses the stored spelling of the name
+	if err := service.Subscribe(ctx, testGuildID, "5", " alice "); err != nil {
+		t.Fatalf("Subscribe returned error: %v", err)
+	}
+	if err := service.Subscribe(ctx, testGuildID, "5", "Zed"); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("Subscribe() to an unknown name returned %v; want ErrNotFound", err)
+	}
+	subscriptions, err := service.GetSubscriptions(ctx, testGuildID, "5")
+	if err != nil || len(subscriptions) != 1 || subscriptions[0].Name != "Alice" {
+		t.Fatalf("GetSubscriptions() = %+v, %v; want a subscription to Alice", subscriptions, err)
+	}
+
+	// Failures are recorded with the time and cleared by the next DM sent
+	if err := service.RecordNotification(ctx, testGuildID, "5", "DMs are off"); err != nil {
+		t.Fatalf("RecordNotification returned error: %v", err)
+	}
+	subscriptions, _ = service.GetSubscriptions(ctx, testGuildID, "5")
+	if subscriptions[0].Failure != "DMs are off" || !subscriptions[0].FailedAt.Equal(timeProvider.CurrentTime) {
+		t.Errorf("Subscription = %+v; want the failure recorded at %s", subscriptions[0], timeProvider.CurrentTime)
+	}
+	if err := service.RecordNotification(ctx, testGuildID, "5", ""); err != nil {
+		t.Fatalf("RecordNotification returned error: %v", err)
+	}
+	subscriptions, _ = service.GetSubscriptions(ctx, testGuildID, "5")
+	if subscriptions[0].Failure != "" || !subscriptions[0].FailedAt.IsZero() {
+		t.Errorf("Subscription = %+v; want the failure cleared", subscriptions[0])
+	}
+
+	if err := service.Unsubscribe(ctx, testGuildID, "5", "ALICE"); err != nil {
+		t.Fatalf("Unsubscribe returned error: %v", err)
+	}
+	if err := service.Unsubscribe(ctx, testGuildID, "5", "Alice"); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("Unsubscribe() twice returned %v; want ErrNotFound", err)
+	}
+}
+
+func TestSubscriptions_FollowTheBirthday(t *testing.T) {
+	// Arrange: a member subscribes to Alice and to Bob, both tomorrow
+	db := setupTestDB(t)
+	addTestBirthday(t, db, "Bob", 12, 26, nil)
+	aliceID := "7"
+	if err := db.AddBirthday(ctx, testGuildID, "Alice", 12, 26, nil, false, nil, &aliceID); err != nil {
+		t.Fatalf("Failed to add birthday: %v", err)
+	}
+	timeProvider := &MockTimeProvider{CurrentTime: time.Date(2025, 12, 25, 10, 0, 0, 0, time.UTC)}
+	service := birthday.NewServiceDB(timeProvider, db)
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := service.Subscribe(ctx, testGuildID, "5", name); err != nil {
+			t.Fatalf("Subscribe(%q) returned error: %v", name, err)
+		}
+	}
+
+	// Act: Alice renames herself and Bob's birthday is removed
+	if err := service.UpsertByDiscordID(ctx, testGuildID, aliceID, "Alicia", 12, 26, nil, nil, nil); err != nil {
+		t.Fatalf("UpsertByDiscordID returned error: %v", err)
+	}
+	if err := service.RemoveBirthday(ctx, testGuildID, "Bob"); err != nil {
+		t.Fatalf("RemoveBirthday returned error: %v", err)
+	}
+	subscriptions, err := service.GetSubscriptions(ctx, testGuildID, "5")
+	if err != nil {
+		t.Fatalf("GetSubscriptions returned error: %v", err)
+	}
+	notifications, err := service.GetDueNotifications(ctx, testGuildID)
+	if err != nil {
+		t.Fatalf("GetDueNotifications returned error: %v", err)
+	}
+
+	// Assert
+	if len(subscriptions) != 1 || subscriptions[0].Name != "Alicia" {
+		t.Errorf("GetSubscriptions() = %+v; want only the subscription to Alicia", subscriptions)
+	}
+	if len(notifications) != 1 || len(notifications[0].Birthdays) != 1 || notifications[0].Birthdays[0].Name != "Alicia" {
+		t.Errorf("GetDueNotifications() = %+v; want a DM about Alicia", notifications)
+	}
+	if err := service.Unsubscribe(ctx, testGuildID, "5", "alicia"); err != nil {
+		t.Errorf("Unsubscribe() by the new name returned error: %v", err)
+	}
+}
+
+func TestSubscribe_PrefersTheExactName(t *testing.T) {
+	// Arrange: names are only unique by exact spelling
+	db := setupTestDB(t)
+	addTestBirthday(t, db, "Alex", 1, 1, nil)
+	addTestBirthday(t, db, "alex", 2, 2, nil)
+	service := birthday.NewServiceDB(&MockTimeProvider{CurrentTime: time.Now()}, db)
+
+	// Act
+	err := service.Subscribe(ctx, testGuildID, "5", "alex")
+	ambiguous := service.Subscribe(ctx, testGuildID, "5", "ALEX")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Subscribe() with an exact name returned error: %v", err)
+	}
+	subscriptions, _ := service.GetSubscriptions(ctx, testGuildID, "5")
+	if len(subscriptions) != 1 || subscriptions[0].Name != "alex" {
+		t.Errorf("GetSubscriptions() = %+v; want a subscription to alex", subscriptions)
+	}
+	if !errors.Is(ambiguous, database.ErrInvalidValue) || !strings.Contains(ambiguous.Error(), "Alex, alex") {
+		t.Errorf("Subscribe() with an ambiguous name returned %v; want ErrInvalidValue naming both birthdays", ambiguous)
+	}
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of announcement recorded in the ledger
+const (
+	AnnouncementMonthly       = "monthly"       // Roundup of the month's birthdays on the 1st
+	AnnouncementBirthday      = "birthday"      // Today's birthday message
+	AnnouncementNotifications = "notifications" // DMs to subscribers the day before birthdays
+)
+
+// reminderKindPrefix starts the kind of an advance reminder, which is followed
+// by how many days ahead it is, e.g. "reminder-7"
+const reminderKindPrefix = "reminder-"
+
+// ReminderKind returns the kind of the reminder sent days before birthdays
+func ReminderKind(days int) string {
+	return reminderKindPrefix + strconv.Itoa(days)
+}
+
+// ReminderDays returns how many days ahead a reminder kind is, or false if
+// kind is not a reminder
+func ReminderDays(kind string) (int, bool) {
+	if !strings.HasPrefix(kind, reminderKindPrefix) {
+		return 0, false
+	}
+	days, err := strconv.Atoi(strings.TrimPrefix(kind, reminderKindPrefix))
+	return days, err == nil
+}
+
+// Announcement is an entry in the announcement ledger
+type Announcement struct {
+	GuildID   string
+	Date      string // YYYY-MM-DD in the guild's timezone
+	Kind      string
+	CreatedAt time.Time
+}
+
+// ClaimAnnouncement records an announcement in the ledger before it is sent. It
+// returns false if the announcement was already claimed, in which case it must
+// not be sent again. The insert is a single statement, so two processes sharing
+// the database cannot both claim the same announcement.
+func (db *DB) ClaimAnnouncement(ctx context.Context, guildID, date, kind string) (bool, error) {
+	query := `INSERT OR IGNORE INTO announcements (guild_id, date, kind) VALUES (?, ?, ?)`
+	result, err := db.conn.ExecContext(ctx, query, guildID, date, kind)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim announcement: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows == 1, nil
+}
+
+// ReleaseAnnouncement removes a claimed announcement from the ledger, e.g. when
+// sending it failed and it should be retried
+func (db *DB) ReleaseAnnouncement(ctx context.Context, guildID, date, kind string) error {
+	query := `DELETE FROM announcements WHERE guild_id = ? AND date = ? AND kind = ?`
+	if _, err := db.conn.ExecContext(ctx, query, guildID, date, kind); err != nil {
+		return fmt.Errorf("failed to release announcement: %w", err)
+	}
+	return nil
+}
+
+// GetAnnouncements returns the guild's most recent announcements, newest first
+func (db *DB) GetAnnouncements(ctx context.Context, guildID string, limit int) ([]Announcement, error) {
+	query := `SELECT guild_id, date, kind, created_at FROM announcements
+	          WHERE guild_id = ? ORDER BY date DESC, created_at DESC, kind LIMIT ?`
+
+	rows, err := db.conn.QueryContext(ctx, query, guildID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query announcements: %w", err)
+	}
+	defer func() {
+		_ = rows.Close() // Best effort close
+	}()
+
+	var announcements []Announcement
+	for rows.Next() {
+		var a Announcement
+		if err := rows.Scan(&a.GuildID, &a.Date, &a.Kind, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan announcement: %w", err)
+		}
+		announcements = append(announcements, a)
+	}
+
+	return announcements, nil
+}
+
+// GetAnnouncementProgress returns how many of an announcement's messages were
+// delivered, or 0 if none were
+func (db *DB) GetAnnouncementProgress(ctx context.Context, guildID, date, kind string) (int, error) {
+	var sent int
+	query := `SELECT sent FROM announcement_progress WHERE guild_id = ? AND date = ? AND kind = ?`
+	err := db.conn.QueryRowContext(ctx, query, guildID, date, kind).Scan(&sent)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get announcement progress: %w", err)
+	}
+	return sent, nil
+}
+
+// SetAnnouncementProgress records how many of an announcement's messages were delivered
+func (db *DB) SetAnnouncementProgress(ctx context.Context, guildID, date, kind string, sent int) error {
+	query := `INSERT INTO announcement_progress (guild_id, date, kind, sent) VALUES (?, ?, ?, ?)
+	          ON CONFLICT(guild_id, date, kind) DO UPDATE SET sent = excluded.sent`
+	if _, err := db.conn.ExecContext(ctx, query, guildID, date, kind, sent); err != nil {
+		return fmt.Errorf("failed to save announcement progress: %w", err)
+	}
+	return nil
+}
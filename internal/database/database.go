@@ -1,27 +1,47 @@
 package database
 
 import (
+	"context"
 	"database/sql"
-	_ "embed"
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-//go:embed schema.sql
-var schema string
-
 // Birthday represents a birthday record in the database
 type Birthday struct {
 	ID        int
+	GuildID   string // Discord server the birthday belongs to
 	Name      string
 	Month     int
 	Day       int
-	Gender    *string // Nullable for pronoun reference
-	DiscordID *string // Nullable Discord user ID
+	Pronouns  *Pronouns // Nullable; see PronounSet
+	DiscordID *string   // Nullable Discord user ID
 	CreatedAt time.Time
 	UpdatedAt time.Time
+
+	// Year is the nullable year of birth. When YearPrivate is set the year is
+	// stored but never shown: ages are not announced and listings leave it out.
+	Year        *int
+	YearPrivate bool
+}
+
+// birthdayColumns are the columns scanBirthday reads, in order
+const birthdayColumns = `id, guild_id, name, month, day, pronouns, discord_id, created_at, updated_at, year, year_private`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanBirthday reads a row selected with birthdayColumns
+func scanBirthday(row rowScanner) (Birthday, error) {
+	var b Birthday
+	err := row.Scan(&b.ID, &b.GuildID, &b.Name, &b.Month, &b.Day, &b.Pronouns, &b.DiscordID, &b.CreatedAt, &b.UpdatedAt, &b.Year, &b.YearPrivate)
+	return b, err
 }
 
 // DB wraps the database connection
@@ -29,26 +49,71 @@ type DB struct {
 	conn *sql.DB
 }
 
-// New creates a new database connection and initializes the schema
+// New opens a database and applies any pending schema migrations
 func New(dbPath string) (*DB, error) {
-	conn, err := sql.Open("sqlite3", dbPath)
+	db, err := Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Migrate(); err != nil {
+		_ = db.Close() // Best effort close on error
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
+	return db, nil
+}
+
+// BusyTimeout is how long a statement waits for a lock held by another
+// connection, such as another replica sharing the file, before failing with
+// SQLITE_BUSY
+const BusyTimeout = 10 * time.Second
+
+// Open opens a database without touching its schema. Use New unless you need to
+// inspect migration status before applying migrations.
+//
+// dbPath may end with go-sqlite3 options after a "?". Unless they say
+// otherwise, statements wait up to BusyTimeout for locks and the database uses
+// write-ahead logging, so readers don't block the writer. WAL needs shared
+// memory between every process using the file, so add _journal_mode=DELETE
+// when the file is on a network volume shared by several nodes.
+func Open(dbPath string) (*DB, error) {
+	conn, err := sql.Open("sqlite3", dataSourceName(dbPath))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
+	// SQLite allows one writer at a time, and pragmas and :memory: databases are
+	// per connection, so use a single connection for everything
+	conn.SetMaxOpenConns(1)
+
 	// Enable foreign keys
 	if _, err := conn.Exec("PRAGMA foreign_keys = ON"); err != nil {
 		_ = conn.Close() // Best effort close on error
 		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
 	}
 
-	// Initialize schema
-	if _, err := conn.Exec(schema); err != nil {
-		_ = conn.Close() // Best effort close on error
-		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+	return &DB{conn: conn}, nil
+}
+
+// dataSourceName adds the default busy timeout and journal mode to a database
+// path, keeping any options it already has
+func dataSourceName(dbPath string) string {
+	path, rawQuery, _ := strings.Cut(dbPath, "?")
+	options, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return dbPath // Let the driver report malformed options
 	}
 
-	return &DB{conn: conn}, nil
+	if options.Get("_busy_timeout") == "" && options.Get("_timeout") == "" {
+		options.Set("_busy_timeout", fmt.Sprint(BusyTimeout.Milliseconds()))
+	}
+	// In-memory databases have no journal to share
+	inMemory := path == ":memory:" || options.Get("mode") == "memory"
+	if !inMemory && options.Get("_journal_mode") == "" && options.Get("_journal") == "" {
+		options.Set("_journal_mode", "WAL")
+	}
+	return path + "?" + options.Encode()
 }
 
 // Close closes the database connection
@@ -57,24 +122,25 @@ func (db *DB) Close() error {
 }
 
 // AddBirthday adds a new birthday to the database
-func (db *DB) AddBirthday(name string, month, day int, gender, discordID *string) error {
-	query := `INSERT INTO birthdays (name, month, day, gender, discord_id) VALUES (?, ?, ?, ?, ?)`
-	_, err := db.conn.Exec(query, name, month, day, gender, discordID)
+func (db *DB) AddBirthday(ctx context.Context, guildID, name string, month, day int, year *int, yearPrivate bool, pronouns *Pronouns, discordID *string) error {
+	if err := ValidateDate(month, day); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO birthdays (guild_id, name, month, day, year, year_private, pronouns, discord_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := db.conn.ExecContext(ctx, query, guildID, name, month, day, year, yearPrivate, pronouns, discordID)
 	if err != nil {
-		return fmt.Errorf("failed to add birthday: %w", err)
+		return birthdayWriteError("add birthday", name, err)
 	}
 	return nil
 }
 
 // GetBirthday gets a birthday by name
-func (db *DB) GetBirthday(name string) (*Birthday, error) {
-	query := `SELECT id, name, month, day, gender, discord_id, created_at, updated_at
-	          FROM birthdays WHERE name = ?`
+func (db *DB) GetBirthday(ctx context.Context, guildID, name string) (*Birthday, error) {
+	query := `SELECT ` + birthdayColumns + `
+	          FROM birthdays WHERE guild_id = ? AND name = ?`
 
-	var b Birthday
-	err := db.conn.QueryRow(query, name).Scan(
-		&b.ID, &b.Name, &b.Month, &b.Day, &b.Gender, &b.DiscordID, &b.CreatedAt, &b.UpdatedAt,
-	)
+	b, err := scanBirthday(db.conn.QueryRowContext(ctx, query, guildID, name))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -84,12 +150,69 @@ func (db *DB) GetBirthday(name string) (*Birthday, error) {
 	return &b, nil
 }
 
-// GetAllBirthdays returns all birthdays
-func (db *DB) GetAllBirthdays() ([]Birthday, error) {
-	query := `SELECT id, name, month, day, gender, discord_id, created_at, updated_at
-	          FROM birthdays ORDER BY month, day`
+// GetBirthdayByDiscordID gets the birthday linked to a Discord user ID
+func (db *DB) GetBirthdayByDiscordID(ctx context.Context, guildID, discordID string) (*Birthday, error) {
+	query := `SELECT ` + birthdayColumns + `
+	          FROM birthdays WHERE guild_id = ? AND discord_id = ?`
+
+	b, err := scanBirthday(db.conn.QueryRowContext(ctx, query, guildID, discordID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get birthday by discord id: %w", err)
+	}
+	return &b, nil
+}
 
-	rows, err := db.conn.Query(query)
+// UpsertByDiscordID creates or updates the birthday linked to a Discord user ID.
+// When a row already exists a nil year, yearPrivate or pronouns leaves the stored
+// value unchanged.
+func (db *DB) UpsertByDiscordID(ctx context.Context, guildID, discordID, name string, month, day int, year *int, yearPrivate *bool, pronouns *Pronouns) error {
+	if err := ValidateDate(month, day); err != nil {
+		return err
+	}
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // No-op after a successful commit
+	}()
+
+	query := `UPDATE birthdays SET name = ?, month = ?, day = ?, year = COALESCE(?, year),
+	          year_private = COALESCE(?, year_private), pronouns = COALESCE(?, pronouns)
+	          WHERE guild_id = ? AND discord_id = ?`
+	result, err := tx.ExecContext(ctx, query, name, month, day, year, yearPrivate, pronouns, guildID, discordID)
+	if err != nil {
+		return birthdayWriteError("update birthday", name, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		query = `INSERT INTO birthdays (guild_id, name, month, day, year, year_private, pronouns, discord_id)
+		         VALUES (?, ?, ?, ?, ?, COALESCE(?, 0), ?, ?)`
+		if _, err := tx.ExecContext(ctx, query, guildID, name, month, day, year, yearPrivate, pronouns, discordID); err != nil {
+			return birthdayWriteError("add birthday", name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit birthday: %w", err)
+	}
+	return nil
+}
+
+// GetAllBirthdays returns all birthdays in a guild
+func (db *DB) GetAllBirthdays(ctx context.Context, guildID string) ([]Birthday, error) {
+	query := `SELECT ` + birthdayColumns + `
+	          FROM birthdays WHERE guild_id = ? ORDER BY month, day`
+
+	rows, err := db.conn.QueryContext(ctx, query, guildID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query birthdays: %w", err)
 	}
@@ -99,8 +222,8 @@ func (db *DB) GetAllBirthdays() ([]Birthday, error) {
 
 	var birthdays []Birthday
 	for rows.Next() {
-		var b Birthday
-		if err := rows.Scan(&b.ID, &b.Name, &b.Month, &b.Day, &b.Gender, &b.DiscordID, &b.CreatedAt, &b.UpdatedAt); err != nil {
+		b, err := scanBirthday(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan birthday: %w", err)
 		}
 		birthdays = append(birthdays, b)
@@ -109,12 +232,12 @@ func (db *DB) GetAllBirthdays() ([]Birthday, error) {
 	return birthdays, nil
 }
 
-// GetBirthdaysByMonth returns all birthdays in a specific month
-func (db *DB) GetBirthdaysByMonth(month int) ([]Birthday, error) {
-	query := `SELECT id, name, month, day, gender, discord_id, created_at, updated_at
-	          FROM birthdays WHERE month = ? ORDER BY day`
+// GetBirthdaysByMonth returns all birthdays in a guild in a specific month
+func (db *DB) GetBirthdaysByMonth(ctx context.Context, guildID string, month int) ([]Birthday, error) {
+	query := `SELECT ` + birthdayColumns + `
+	          FROM birthdays WHERE guild_id = ? AND month = ? ORDER BY day`
 
-	rows, err := db.conn.Query(query, month)
+	rows, err := db.conn.QueryContext(ctx, query, guildID, month)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query birthdays by month: %w", err)
 	}
@@ -124,8 +247,8 @@ func (db *DB) GetBirthdaysByMonth(month int) ([]Birthday, error) {
 
 	var birthdays []Birthday
 	for rows.Next() {
-		var b Birthday
-		if err := rows.Scan(&b.ID, &b.Name, &b.Month, &b.Day, &b.Gender, &b.DiscordID, &b.CreatedAt, &b.UpdatedAt); err != nil {
+		b, err := scanBirthday(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan birthday: %w", err)
 		}
 		birthdays = append(birthdays, b)
@@ -134,12 +257,12 @@ func (db *DB) GetBirthdaysByMonth(month int) ([]Birthday, error) {
 	return birthdays, nil
 }
 
-// GetBirthdaysByDate returns all birthdays on a specific date
-func (db *DB) GetBirthdaysByDate(month, day int) ([]Birthday, error) {
-	query := `SELECT id, name, month, day, gender, discord_id, created_at, updated_at
-	          FROM birthdays WHERE month = ? AND day = ?`
+// GetBirthdaysByDate returns all birthdays in a guild on a specific date
+func (db *DB) GetBirthdaysByDate(ctx context.Context, guildID string, month, day int) ([]Birthday, error) {
+	query := `SELECT ` + birthdayColumns + `
+	          FROM birthdays WHERE guild_id = ? AND month = ? AND day = ?`
 
-	rows, err := db.conn.Query(query, month, day)
+	rows, err := db.conn.QueryContext(ctx, query, guildID, month, day)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query birthdays by date: %w", err)
 	}
@@ -147,8 +270,8 @@ func (db *DB) GetBirthdaysByDate(month, day int) ([]Birthday, error) {
 
 	var birthdays []Birthday
 	for rows.Next() {
-		var b Birthday
-		if err := rows.Scan(&b.ID, &b.Name, &b.Month, &b.Day, &b.Gender, &b.DiscordID, &b.CreatedAt, &b.UpdatedAt); err != nil {
+		b, err := scanBirthday(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan birthday: %w", err)
 		}
 		birthdays = append(birthdays, b)
@@ -158,11 +281,16 @@ func (db *DB) GetBirthdaysByDate(month, day int) ([]Birthday, error) {
 }
 
 // UpdateBirthday updates an existing birthday
-func (db *DB) UpdateBirthday(name string, month, day int, gender, discordID *string) error {
-	query := `UPDATE birthdays SET month = ?, day = ?, gender = ?, discord_id = ? WHERE name = ?`
-	result, err := db.conn.Exec(query, month, day, gender, discordID, name)
+func (db *DB) UpdateBirthday(ctx context.Context, guildID, name string, month, day int, year *int, yearPrivate bool, pronouns *Pronouns, discordID *string) error {
+	if err := ValidateDate(month, day); err != nil {
+		return err
+	}
+
+	query := `UPDATE birthdays SET month = ?, day = ?, year = ?, year_private = ?, pronouns = ?, discord_id = ?
+	          WHERE guild_id = ? AND name = ?`
+	result, err := db.conn.ExecContext(ctx, query, month, day, year, yearPrivate, pronouns, discordID, guildID, name)
 	if err != nil {
-		return fmt.Errorf("failed to update birthday: %w", err)
+		return birthdayWriteError("update birthday", name, err)
 	}
 
 	rows, err := result.RowsAffected()
@@ -170,16 +298,16 @@ func (db *DB) UpdateBirthday(name string, month, day int, gender, discordID *str
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 	if rows == 0 {
-		return fmt.Errorf("no birthday found for %s", name)
+		return fmt.Errorf("%w for %s", ErrNotFound, name)
 	}
 
 	return nil
 }
 
 // DeleteBirthday removes a birthday from the database
-func (db *DB) DeleteBirthday(name string) error {
-	query := `DELETE FROM birthdays WHERE name = ?`
-	result, err := db.conn.Exec(query, name)
+func (db *DB) DeleteBirthday(ctx context.Context, guildID, name string) error {
+	query := `DELETE FROM birthdays WHERE guild_id = ? AND name = ?`
+	result, err := db.conn.ExecContext(ctx, query, guildID, name)
 	if err != nil {
 		return fmt.Errorf("failed to delete birthday: %w", err)
 	}
@@ -189,42 +317,68 @@ func (db *DB) DeleteBirthday(name string) error {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 	if rows == 0 {
-		return fmt.Errorf("no birthday found for %s", name)
+		return fmt.Errorf("%w for %s", ErrNotFound, name)
 	}
 
 	return nil
 }
 
-// GetPronoun returns the appropriate pronoun based on gender
-func (b *Birthday) GetPronoun(subjectForm bool) string {
-	// Default is they/them
-	if b.Gender == nil {
-		if subjectForm {
-			return "they"
-		}
-		return "their"
+// DeleteBirthdayByDiscordID removes the birthday linked to a Discord user ID
+func (db *DB) DeleteBirthdayByDiscordID(ctx context.Context, guildID, discordID string) error {
+	query := `DELETE FROM birthdays WHERE guild_id = ? AND discord_id = ?`
+	result, err := db.conn.ExecContext(ctx, query, guildID, discordID)
+	if err != nil {
+		return fmt.Errorf("failed to delete birthday: %w", err)
 	}
 
-	switch *b.Gender {
-	case "male":
-		if subjectForm {
-			return "he"
-		}
-		return "him"
-	case "female":
-		if subjectForm {
-			return "she"
-		}
-		return "her"
-	case "nonbinary", "other":
-		if subjectForm {
-			return "them"
-		}
-		return "their"
-	default:
-		if subjectForm {
-			return "they"
-		}
-		return "them"
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w for discord user %s", ErrNotFound, discordID)
+	}
+
+	return nil
+}
+
+// AssignUnscopedBirthdays moves birthdays that predate guild support (stored
+// with an empty guild ID) into the given guild, returning how many were moved
+func (db *DB) AssignUnscopedBirthdays(ctx context.Context, guildID string) (int64, error) {
+	result, err := db.conn.ExecContext(ctx, `UPDATE birthdays SET guild_id = ? WHERE guild_id = ''`, guildID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to assign birthdays to guild: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows, nil
+}
+
+// Mention returns a Discord mention for the person if they are linked to a
+// Discord user, or their name otherwise
+func (b *Birthday) Mention() string {
+	if b.DiscordID != nil && *b.DiscordID != "" {
+		return "<@" + *b.DiscordID + ">"
+	}
+	return b.Name
+}
+
+// AgeIn returns the age the person turns on their birthday in the given year,
+// or false if their year of birth isn't known
+func (b *Birthday) AgeIn(year int) (int, bool) {
+	if b.Year == nil {
+		return 0, false
+	}
+	return year - *b.Year, true
+}
+
+// PronounSet returns the person's pronouns, defaulting to they/them
+func (b *Birthday) PronounSet() Pronouns {
+	if b.Pronouns == nil {
+		return TheyThem
 	}
+	return *b.Pronouns
 }
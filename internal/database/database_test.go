@@ -1,11 +1,20 @@
 package database_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/nrzaman/baos-birthday-bot/internal/database"
 )
 
+// testGuildID is the Discord server used by tests that don't care about guilds
+const testGuildID = "guild-1"
+
+var ctx = context.Background()
+
 // Helper function to create an in-memory test database
 func setupTestDB(t *testing.T) *database.DB {
 	t.Helper()
@@ -26,14 +35,14 @@ func TestAddAndGetBirthday(t *testing.T) {
 	expected_day := 25
 
 	// Add a birthday
-	gender := "female"
-	err := db.AddBirthday("Alice", expected_month, expected_day, &gender, nil)
+	pronouns := database.SheHer
+	err := db.AddBirthday(ctx, testGuildID, "Alice", expected_month, expected_day, nil, false, &pronouns, nil)
 	if err != nil {
 		t.Fatalf("Failed to add birthday: %v", err)
 	}
 
 	// Get the birthday
-	birthday, err := db.GetBirthday("Alice")
+	birthday, err := db.GetBirthday(ctx, testGuildID, "Alice")
 	if err != nil {
 		t.Fatalf("Failed to get birthday: %v", err)
 	}
@@ -54,8 +63,8 @@ func TestAddAndGetBirthday(t *testing.T) {
 		t.Errorf("Expected day %d, got %d", expected_day, birthday.Day)
 	}
 
-	if birthday.Gender == nil || *birthday.Gender != "female" {
-		t.Error("Expected gender 'female'")
+	if birthday.Pronouns == nil || *birthday.Pronouns != database.SheHer {
+		t.Error("Expected pronouns she/her")
 	}
 }
 
@@ -68,12 +77,12 @@ func TestGetBirthdaysByMonth(t *testing.T) {
 	expected_day_cassidy := 2
 
 	// Add multiple birthdays
-	_ = db.AddBirthday("Alice", expected_month_alice_ben, expected_day_alice, nil, nil)
-	_ = db.AddBirthday("Bob", expected_month_alice_ben, expected_day_ben, nil, nil)
-	_ = db.AddBirthday("Cassidy", expected_month_cassidy, expected_day_cassidy, nil, nil)
+	_ = db.AddBirthday(ctx, testGuildID, "Alice", expected_month_alice_ben, expected_day_alice, nil, false, nil, nil)
+	_ = db.AddBirthday(ctx, testGuildID, "Bob", expected_month_alice_ben, expected_day_ben, nil, false, nil, nil)
+	_ = db.AddBirthday(ctx, testGuildID, "Cassidy", expected_month_cassidy, expected_day_cassidy, nil, false, nil, nil)
 
 	// Get March birthdays
-	birthdays, err := db.GetBirthdaysByMonth(expected_month_alice_ben)
+	birthdays, err := db.GetBirthdaysByMonth(ctx, testGuildID, expected_month_alice_ben)
 	if err != nil {
 		t.Fatalf("Failed to get birthdays: %v", err)
 	}
@@ -91,12 +100,12 @@ func TestGetBirthdaysByDate(t *testing.T) {
 	expected_day_bruce_cassidy := 2
 
 	// Add birthdays
-	_ = db.AddBirthday("Alice", expected_month_alice, expected_day_alice, nil, nil)
-	_ = db.AddBirthday("Bruce", expected_month_bruce_cassidy, expected_day_bruce_cassidy, nil, nil) // Same day!
-	_ = db.AddBirthday("Cassidy", expected_month_bruce_cassidy, expected_day_bruce_cassidy, nil, nil)
+	_ = db.AddBirthday(ctx, testGuildID, "Alice", expected_month_alice, expected_day_alice, nil, false, nil, nil)
+	_ = db.AddBirthday(ctx, testGuildID, "Bruce", expected_month_bruce_cassidy, expected_day_bruce_cassidy, nil, false, nil, nil) // Same day!
+	_ = db.AddBirthday(ctx, testGuildID, "Cassidy", expected_month_bruce_cassidy, expected_day_bruce_cassidy, nil, false, nil, nil)
 
 	// Get birthdays on March 15
-	birthdays, err := db.GetBirthdaysByDate(expected_month_bruce_cassidy, expected_day_bruce_cassidy)
+	birthdays, err := db.GetBirthdaysByDate(ctx, testGuildID, expected_month_bruce_cassidy, expected_day_bruce_cassidy)
 	if err != nil {
 		t.Fatalf("Failed to get birthdays: %v", err)
 	}
@@ -112,22 +121,102 @@ func TestUpdateBirthday(t *testing.T) {
 	expected_day_alice := 25
 
 	// Add a birthday
-	_ = db.AddBirthday("Alice", expected_month_alice, expected_day_alice, nil, nil)
+	_ = db.AddBirthday(ctx, testGuildID, "Alice", expected_month_alice, expected_day_alice, nil, false, nil, nil)
 
 	// Update it
-	gender := "female"
-	err := db.UpdateBirthday("Alice", expected_month_alice, (expected_day_alice + 1), &gender, nil)
+	pronouns := database.SheHer
+	err := db.UpdateBirthday(ctx, testGuildID, "Alice", expected_month_alice, (expected_day_alice + 1), nil, false, &pronouns, nil)
 	if err != nil {
 		t.Fatalf("Failed to update birthday: %v", err)
 	}
 
 	// Verify
-	birthday, _ := db.GetBirthday("Alice")
+	birthday, _ := db.GetBirthday(ctx, testGuildID, "Alice")
 	if birthday.Day != (expected_day_alice + 1) {
 		t.Errorf("Expected day %d, got %d", (expected_day_alice + 1), birthday.Day)
 	}
-	if birthday.Gender == nil || *birthday.Gender != "female" {
-		t.Error("Expected gender 'female'")
+	if birthday.Pronouns == nil || *birthday.Pronouns != database.SheHer {
+		t.Error("Expected pronouns she/her")
+	}
+}
+
+func TestValidateDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		month   int
+		day     int
+		wantErr bool
+	}{
+		{"January 31", 1, 31, false},
+		{"February 29", 2, 29, false},
+		{"February 30", 2, 30, true},
+		{"April 30", 4, 30, false},
+		{"April 31", 4, 31, true},
+		{"September 31", 9, 31, true},
+		{"December 31", 12, 31, false},
+		{"Day 0", 5, 0, true},
+		{"Month 0", 0, 1, true},
+		{"Month 13", 13, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := database.ValidateDate(tt.month, tt.day)
+			if tt.wantErr && !errors.Is(err, database.ErrInvalidDate) {
+				t.Errorf("ValidateDate(%d, %d) = %v; want ErrInvalidDate", tt.month, tt.day, err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("ValidateDate(%d, %d) = %v; want nil", tt.month, tt.day, err)
+			}
+		})
+	}
+}
+
+func TestWritesRejectImpossibleDates(t *testing.T) {
+	db := setupTestDB(t)
+	_ = db.AddBirthday(ctx, testGuildID, "Alice", 1, 25, nil, false, nil, nil)
+
+	if err := db.AddBirthday(ctx, testGuildID, "Bob", 4, 31, nil, false, nil, nil); !errors.Is(err, database.ErrInvalidDate) {
+		t.Errorf("AddBirthday on April 31 = %v; want ErrInvalidDate", err)
+	}
+	if err := db.UpdateBirthday(ctx, testGuildID, "Alice", 2, 30, nil, false, nil, nil); !errors.Is(err, database.ErrInvalidDate) {
+		t.Errorf("UpdateBirthday to February 30 = %v; want ErrInvalidDate", err)
+	}
+	if err := db.UpsertByDiscordID(ctx, testGuildID, "42", "Carol", 6, 31, nil, nil, nil); !errors.Is(err, database.ErrInvalidDate) {
+		t.Errorf("UpsertByDiscordID on June 31 = %v; want ErrInvalidDate", err)
+	}
+
+	all, _ := db.GetAllBirthdays(ctx, testGuildID)
+	if len(all) != 1 || all[0].Month != 1 || all[0].Day != 25 {
+		t.Errorf("Expected only Alice's original birthday to be stored, got %+v", all)
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	db := setupTestDB(t)
+	_ = db.AddBirthday(ctx, testGuildID, "Alice", 1, 25, nil, false, nil, nil)
+	_ = db.UpsertByDiscordID(ctx, testGuildID, "42", "Bob", 6, 10, nil, nil, nil)
+	zero := 0
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"Add duplicate name", db.AddBirthday(ctx, testGuildID, "Alice", 2, 2, nil, false, nil, nil), database.ErrDuplicateName},
+		{"Rename onto a taken name", db.UpsertByDiscordID(ctx, testGuildID, "42", "Alice", 6, 10, nil, nil, nil), database.ErrDuplicateName},
+		{"Check constraint", db.AddBirthday(ctx, testGuildID, "Carol", 3, 3, &zero, false, nil, nil), database.ErrInvalidValue},
+		{"Update missing", db.UpdateBirthday(ctx, testGuildID, "Nobody", 1, 1, nil, false, nil, nil), database.ErrNotFound},
+		{"Delete missing", db.DeleteBirthday(ctx, testGuildID, "Nobody"), database.ErrNotFound},
+		{"Delete missing Discord user", db.DeleteBirthdayByDiscordID(ctx, testGuildID, "99"), database.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !errors.Is(tt.err, tt.want) {
+				t.Errorf("Got error %v; want %v", tt.err, tt.want)
+			}
+		})
 	}
 }
 
@@ -135,55 +224,566 @@ func TestDeleteBirthday(t *testing.T) {
 	db := setupTestDB(t)
 
 	// Add a birthday
-	_ = db.AddBirthday("Alice", 1, 25, nil, nil)
+	_ = db.AddBirthday(ctx, testGuildID, "Alice", 1, 25, nil, false, nil, nil)
 
 	// Delete it
-	err := db.DeleteBirthday("Alice")
+	err := db.DeleteBirthday(ctx, testGuildID, "Alice")
 	if err != nil {
 		t.Fatalf("Failed to delete birthday: %v", err)
 	}
 
 	// Verify it's gone
-	birthday, _ := db.GetBirthday("Alice")
+	birthday, _ := db.GetBirthday(ctx, testGuildID, "Alice")
+	if birthday != nil {
+		t.Error("Birthday should have been deleted")
+	}
+}
+
+func TestUpsertByDiscordID(t *testing.T) {
+	db := setupTestDB(t)
+	female := database.SheHer
+
+	// First call inserts
+	if err := db.UpsertByDiscordID(ctx, testGuildID, "42", "Alice", 1, 25, nil, nil, &female); err != nil {
+		t.Fatalf("Failed to insert birthday: %v", err)
+	}
+
+	// Second call updates the same row and keeps the pronouns
+	if err := db.UpsertByDiscordID(ctx, testGuildID, "42", "Ali", 2, 3, nil, nil, nil); err != nil {
+		t.Fatalf("Failed to update birthday: %v", err)
+	}
+
+	all, _ := db.GetAllBirthdays(ctx, testGuildID)
+	if len(all) != 1 {
+		t.Fatalf("Expected 1 birthday, got %d", len(all))
+	}
+
+	birthday, err := db.GetBirthdayByDiscordID(ctx, testGuildID, "42")
+	if err != nil {
+		t.Fatalf("Failed to get birthday: %v", err)
+	}
+	if birthday == nil {
+		t.Fatal("Birthday not found")
+	}
+	if birthday.Name != "Ali" || birthday.Month != 2 || birthday.Day != 3 {
+		t.Errorf("Expected Ali on 2/3, got %s on %d/%d", birthday.Name, birthday.Month, birthday.Day)
+	}
+	if birthday.Pronouns == nil || *birthday.Pronouns != database.SheHer {
+		t.Error("Expected pronouns she/her to be kept")
+	}
+}
+
+func TestBirthYear(t *testing.T) {
+	db := setupTestDB(t)
+	year := 1990
+	private := true
+
+	if err := db.UpsertByDiscordID(ctx, testGuildID, "42", "Alice", 1, 25, &year, &private, nil); err != nil {
+		t.Fatalf("Failed to insert birthday: %v", err)
+	}
+	// A nil year and privacy flag keep the stored values
+	if err := db.UpsertByDiscordID(ctx, testGuildID, "42", "Alice", 1, 26, nil, nil, nil); err != nil {
+		t.Fatalf("Failed to update birthday: %v", err)
+	}
+
+	birthday, _ := db.GetBirthday(ctx, testGuildID, "Alice")
+	if birthday.Year == nil || *birthday.Year != 1990 || !birthday.YearPrivate {
+		t.Errorf("Expected private year 1990, got %v (private %v)", birthday.Year, birthday.YearPrivate)
+	}
+	if age, ok := birthday.AgeIn(2025); !ok || age != 35 {
+		t.Errorf("Expected age 35 in 2025, got %d (%v)", age, ok)
+	}
+
+	_ = db.AddBirthday(ctx, testGuildID, "Bob", 6, 10, nil, false, nil, nil)
+	bob, _ := db.GetBirthday(ctx, testGuildID, "Bob")
+	if _, ok := bob.AgeIn(2025); ok {
+		t.Error("Expected no age without a year of birth")
+	}
+}
+
+func TestDeleteBirthdayByDiscordID(t *testing.T) {
+	db := setupTestDB(t)
+	_ = db.UpsertByDiscordID(ctx, testGuildID, "42", "Alice", 1, 25, nil, nil, nil)
+
+	if err := db.DeleteBirthdayByDiscordID(ctx, testGuildID, "42"); err != nil {
+		t.Fatalf("Failed to delete birthday: %v", err)
+	}
+
+	birthday, _ := db.GetBirthdayByDiscordID(ctx, testGuildID, "42")
 	if birthday != nil {
 		t.Error("Birthday should have been deleted")
 	}
+
+	if err := db.DeleteBirthdayByDiscordID(ctx, testGuildID, "42"); err == nil {
+		t.Error("Expected error deleting a missing birthday")
+	}
+}
+
+func TestBirthdaysArePartitionedByGuild(t *testing.T) {
+	db := setupTestDB(t)
+
+	// The same name may exist once in each guild
+	if err := db.AddBirthday(ctx, "guild-1", "Alice", 1, 25, nil, false, nil, nil); err != nil {
+		t.Fatalf("Failed to add birthday: %v", err)
+	}
+	if err := db.AddBirthday(ctx, "guild-2", "Alice", 6, 10, nil, false, nil, nil); err != nil {
+		t.Fatalf("Failed to add same name in another guild: %v", err)
+	}
+	if err := db.AddBirthday(ctx, "guild-1", "Alice", 2, 2, nil, false, nil, nil); err == nil {
+		t.Error("Expected duplicate name in the same guild to fail")
+	}
+
+	birthdays, err := db.GetBirthdaysByDate(ctx, "guild-2", 1, 25)
+	if err != nil {
+		t.Fatalf("Failed to get birthdays: %v", err)
+	}
+	if len(birthdays) != 0 {
+		t.Errorf("Expected guild-1 birthday to be hidden from guild-2, got %d", len(birthdays))
+	}
+
+	all, _ := db.GetAllBirthdays(ctx, "guild-2")
+	if len(all) != 1 || all[0].Month != 6 || all[0].GuildID != "guild-2" {
+		t.Errorf("Expected only guild-2's Alice, got %+v", all)
+	}
+}
+
+func TestGuildSettings(t *testing.T) {
+	db := setupTestDB(t)
+
+	guild, err := db.GetGuild(ctx, "guild-1")
+	if err != nil {
+		t.Fatalf("Failed to get guild: %v", err)
+	}
+	if guild != nil {
+		t.Fatal("Expected unconfigured guild to be nil")
+	}
+
+	_ = db.UpsertGuild(ctx, database.Guild{GuildID: "guild-1", ChannelID: "100", Timezone: "UTC"})
+	_ = db.UpsertGuild(ctx, database.Guild{GuildID: "guild-2", ChannelID: "200", Timezone: "UTC"})
+	if err := db.UpsertGuild(ctx, database.Guild{GuildID: "guild-1", ChannelID: "101", Timezone: "Europe/London"}); err != nil {
+		t.Fatalf("Failed to update guild: %v", err)
+	}
+
+	guild, _ = db.GetGuild(ctx, "guild-1")
+	if guild == nil || guild.ChannelID != "101" || guild.Timezone != "Europe/London" {
+		t.Errorf("Expected updated guild settings, got %+v", guild)
+	}
+	if guild.Location().String() != "Europe/London" {
+		t.Errorf("Expected Europe/London location, got %s", guild.Location())
+	}
+
+	guilds, _ := db.GetGuilds(ctx)
+	if len(guilds) != 2 {
+		t.Errorf("Expected 2 guilds, got %d", len(guilds))
+	}
+
+	if err := db.SetGuildLastAnnounced(ctx, "guild-1", "2025-06-10"); err != nil {
+		t.Fatalf("Failed to set last announced date: %v", err)
+	}
+	guild, _ = db.GetGuild(ctx, "guild-1")
+	if guild.LastAnnouncedOn != "2025-06-10" {
+		t.Errorf("Expected last announced date 2025-06-10, got %q", guild.LastAnnouncedOn)
+	}
+	if err := db.SetGuildLastAnnounced(ctx, "guild-3", "2025-06-10"); err == nil {
+		t.Error("Expected error for an unconfigured guild")
+	}
+
+	if guild.LeapDayPolicy != database.LeapDayFeb28 {
+		t.Errorf("Expected default leap day policy %q, got %q", database.LeapDayFeb28, guild.LeapDayPolicy)
+	}
+	if err := db.SetGuildLeapDayPolicy(ctx, "guild-1", database.LeapDayMar1); err != nil {
+		t.Fatalf("Failed to set leap day policy: %v", err)
+	}
+	guild, _ = db.GetGuild(ctx, "guild-1")
+	if guild.LeapDayPolicy != database.LeapDayMar1 {
+		t.Errorf("Expected leap day policy %q, got %q", database.LeapDayMar1, guild.LeapDayPolicy)
+	}
+	if err := db.SetGuildLeapDayPolicy(ctx, "guild-1", "feb30"); err == nil {
+		t.Error("Expected error for an unknown leap day policy")
+	}
+}
+
+func TestAnnouncementLedger(t *testing.T) {
+	db := setupTestDB(t)
+
+	claimed, err := db.ClaimAnnouncement(ctx, testGuildID, "2025-06-01", database.AnnouncementMonthly)
+	if err != nil {
+		t.Fatalf("Failed to claim announcement: %v", err)
+	}
+	if !claimed {
+		t.Error("Expected first claim to succeed")
+	}
+
+	claimed, _ = db.ClaimAnnouncement(ctx, testGuildID, "2025-06-01", database.AnnouncementMonthly)
+	if claimed {
+		t.Error("Expected second claim for the same day and kind to fail")
+	}
+
+	// Other kinds, days and guilds are claimed separately
+	for _, a := range []database.Announcement{
+		{GuildID: testGuildID, Date: "2025-06-01", Kind: database.AnnouncementBirthday},
+		{GuildID: testGuildID, Date: "2025-06-02", Kind: database.AnnouncementMonthly},
+		{GuildID: "guild-2", Date: "2025-06-01", Kind: database.AnnouncementMonthly},
+	} {
+		if claimed, _ := db.ClaimAnnouncement(ctx, a.GuildID, a.Date, a.Kind); !claimed {
+			t.Errorf("Expected claim for %+v to succeed", a)
+		}
+	}
+
+	if err := db.ReleaseAnnouncement(ctx, testGuildID, "2025-06-02", database.AnnouncementMonthly); err != nil {
+		t.Fatalf("Failed to release announcement: %v", err)
+	}
+	if claimed, _ := db.ClaimAnnouncement(ctx, testGuildID, "2025-06-02", database.AnnouncementMonthly); !claimed {
+		t.Error("Expected a released announcement to be claimable again")
+	}
+
+	history, err := db.GetAnnouncements(ctx, testGuildID, 2)
+	if err != nil {
+		t.Fatalf("Failed to get announcements: %v", err)
+	}
+	if len(history) != 2 || history[0].Date != "2025-06-02" {
+		t.Errorf("Expected the 2 newest announcements, newest first, got %+v", history)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	db := setupTestDB(t)
+
+	templates, err := db.GetTemplates(ctx, testGuildID, database.AnnouncementBirthday)
+	if err != nil {
+		t.Fatalf("Failed to get templates: %v", err)
+	}
+	if templates != nil {
+		t.Errorf("Expected no templates before any are saved, got %v", templates)
+	}
+
+	variants := []string{"Happy birthday {{name}}!", "Cheers to {{name}}!"}
+	if err := db.SetTemplates(ctx, testGuildID, database.AnnouncementBirthday, variants); err != nil {
+		t.Fatalf("Failed to set templates: %v", err)
+	}
+	if err := db.SetTemplates(ctx, "guild-2", database.AnnouncementBirthday, []string{"Other server"}); err != nil {
+		t.Fatalf("Failed to set templates: %v", err)
+	}
+
+	templates, _ = db.GetTemplates(ctx, testGuildID, database.AnnouncementBirthday)
+	if fmt.Sprint(templates) != fmt.Sprint(variants) {
+		t.Errorf("Expected %v, got %v", variants, templates)
+	}
+	if monthly, _ := db.GetTemplates(ctx, testGuildID, database.AnnouncementMonthly); monthly != nil {
+		t.Errorf("Expected kinds to be stored separately, got %v", monthly)
+	}
+
+	// Saving replaces the whole pool, and an empty pool resets it
+	if err := db.SetTemplates(ctx, testGuildID, database.AnnouncementBirthday, variants[1:]); err != nil {
+		t.Fatalf("Failed to set templates: %v", err)
+	}
+	if templates, _ = db.GetTemplates(ctx, testGuildID, database.AnnouncementBirthday); fmt.Sprint(templates) != fmt.Sprint(variants[1:]) {
+		t.Errorf("Expected %v, got %v", variants[1:], templates)
+	}
+	if err := db.SetTemplates(ctx, testGuildID, database.AnnouncementBirthday, nil); err != nil {
+		t.Fatalf("Failed to reset templates: %v", err)
+	}
+	if templates, _ = db.GetTemplates(ctx, testGuildID, database.AnnouncementBirthday); templates != nil {
+		t.Errorf("Expected templates to be reset, got %v", templates)
+	}
+	if other, _ := db.GetTemplates(ctx, "guild-2", database.AnnouncementBirthday); len(other) != 1 {
+		t.Errorf("Expected other guilds' templates to be untouched, got %v", other)
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	db := setupTestDB(t)
+	caseyDiscordID := "7"
+	_ = db.AddBirthday(ctx, testGuildID, "Casey", 1, 6, nil, false, nil, &caseyDiscordID)
+	_ = db.AddBirthday(ctx, "guild-2", "Casey", 1, 6, nil, false, nil, nil)
+	casey, _ := db.GetBirthday(ctx, testGuildID, "Casey")
+	otherCasey, _ := db.GetBirthday(ctx, "guild-2", "Casey")
+
+	if err := db.SetOverride(ctx, testGuildID, 1, 6, &casey.ID, "First"); err != nil {
+		t.Fatalf("Failed to set override: %v", err)
+	}
+	if err := db.SetOverride(ctx, testGuildID, 1, 6, nil, "Everyone"); err != nil {
+		t.Fatalf("Failed to set override: %v", err)
+	}
+	if err := db.SetOverride(ctx, "guild-2", 1, 6, &otherCasey.ID, "Other server"); err != nil {
+		t.Fatalf("Failed to set override: %v", err)
+	}
+
+	// Setting either again replaces its message
+	if err := db.SetOverride(ctx, testGuildID, 1, 6, &casey.ID, "Second"); err != nil {
+		t.Fatalf("Failed to replace override: %v", err)
+	}
+	if err := db.SetOverride(ctx, testGuildID, 1, 6, nil, "Everyone again"); err != nil {
+		t.Fatalf("Failed to replace override: %v", err)
+	}
+	overrides, err := db.GetOverridesByDate(ctx, testGuildID, 1, 6)
+	if err != nil {
+		t.Fatalf("Failed to get overrides: %v", err)
+	}
+	var got []string
+	for _, o := range overrides {
+		got = append(got, o.Name+":"+o.Message)
+	}
+	if want := []string{":Everyone again", "Casey:Second"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected overrides %v, got %v", want, got)
+	}
+	if none, _ := db.GetOverridesByDate(ctx, testGuildID, 1, 7); len(none) != 0 {
+		t.Errorf("Expected no overrides on 1/7, got %+v", none)
+	}
+
+	if err := db.SetOverride(ctx, testGuildID, 2, 30, nil, "Never"); !errors.Is(err, database.ErrInvalidDate) {
+		t.Errorf("Expected ErrInvalidDate for 2/30, got %v", err)
+	}
+
+	// Overrides follow a rename, and are deleted with their birthday
+	if err := db.UpsertByDiscordID(ctx, testGuildID, caseyDiscordID, "Kasey", 1, 6, nil, nil, nil); err != nil {
+		t.Fatalf("Failed to rename birthday: %v", err)
+	}
+	if all, _ := db.GetOverrides(ctx, testGuildID); len(all) != 2 || all[1].Name != "Kasey" || *all[1].BirthdayID != casey.ID {
+		t.Errorf("Expected the override to follow the rename to Kasey, got %+v", all)
+	}
+	if err := db.DeleteBirthday(ctx, "guild-2", "Casey"); err != nil {
+		t.Fatalf("Failed to delete birthday: %v", err)
+	}
+	if other, _ := db.GetOverrides(ctx, "guild-2"); len(other) != 0 {
+		t.Errorf("Expected overrides for a deleted birthday to be deleted, got %+v", other)
+	}
+
+	if err := db.DeleteOverride(ctx, testGuildID, 1, 6, &casey.ID); err != nil {
+		t.Fatalf("Failed to delete override: %v", err)
+	}
+	if err := db.DeleteOverride(ctx, testGuildID, 1, 6, &casey.ID); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting a missing override, got %v", err)
+	}
+	if all, _ := db.GetOverrides(ctx, testGuildID); len(all) != 1 || all[0].BirthdayID != nil {
+		t.Errorf("Expected only the override for everyone to be left, got %+v", all)
+	}
+}
+
+func TestReminderSettings(t *testing.T) {
+	db := setupTestDB(t)
+
+	settings, err := db.GetReminderSettings(ctx, testGuildID)
+	if err != nil {
+		t.Fatalf("Failed to get reminder settings: %v", err)
+	}
+	if settings.GuildID != testGuildID || len(settings.Days) != 0 {
+		t.Errorf("Expected reminders to be off before they are set up, got %+v", settings)
+	}
+
+	want := database.ReminderSettings{GuildID: testGuildID, ChannelID: "organizers", RoleID: "888", Days: []int{7, 1}}
+	if err := db.SetReminderSettings(ctx, want); err != nil {
+		t.Fatalf("Failed to set reminder settings: %v", err)
+	}
+	if err := db.SetReminderSettings(ctx, database.ReminderSettings{GuildID: "guild-2", RoleID: "999", Days: []int{3}}); err != nil {
+		t.Fatalf("Failed to set reminder settings: %v", err)
+	}
+	if settings, _ = db.GetReminderSettings(ctx, testGuildID); fmt.Sprint(settings) != fmt.Sprint(want) {
+		t.Errorf("Expected %+v, got %+v", want, settings)
+	}
+
+	// Saving replaces everything, and no days turns reminders off
+	if err := db.SetReminderSettings(ctx, database.ReminderSettings{GuildID: testGuildID, RoleID: "888", Days: []int{14}}); err != nil {
+		t.Fatalf("Failed to set reminder settings: %v", err)
+	}
+	if settings, _ = db.GetReminderSettings(ctx, testGuildID); settings.ChannelID != "" || fmt.Sprint(settings.Days) != "[14]" {
+		t.Errorf("Expected the settings to be replaced, got %+v", settings)
+	}
+	if err := db.SetReminderSettings(ctx, database.ReminderSettings{GuildID: testGuildID}); err != nil {
+		t.Fatalf("Failed to turn reminders off: %v", err)
+	}
+	if settings, _ = db.GetReminderSettings(ctx, testGuildID); settings.RoleID != "" || len(settings.Days) != 0 {
+		t.Errorf("Expected reminders to be off, got %+v", settings)
+	}
+	if other, _ := db.GetReminderSettings(ctx, "guild-2"); len(other.Days) != 1 {
+		t.Errorf("Expected other guilds' reminders to be untouched, got %+v", other)
+	}
 }
 
-func TestGetPronoun(t *testing.T) {
+func TestSubscriptions(t *testing.T) {
+	db := setupTestDB(t)
+	aliceDiscordID := "7"
+	_ = db.AddBirthday(ctx, testGuildID, "Alice", 6, 4, nil, false, nil, &aliceDiscordID)
+	_ = db.AddBirthday(ctx, testGuildID, "Bob", 6, 10, nil, false, nil, nil)
+	alice, _ := db.GetBirthday(ctx, testGuildID, "Alice")
+	bob, _ := db.GetBirthday(ctx, testGuildID, "Bob")
+
+	// Subscribing twice, including to everyone, keeps one subscription
+	subscriptions := []struct {
+		guildID, userID string
+		birthdayID      *int
+	}{
+		{testGuildID, "5", nil}, {testGuildID, "5", nil}, {testGuildID, "5", &alice.ID}, {testGuildID, "5", &alice.ID},
+		{testGuildID, "3", &bob.ID}, {"guild-2", "5", nil},
+	}
+	for _, s := range subscriptions {
+		if err := db.AddSubscription(ctx, s.guildID, s.userID, s.birthdayID); err != nil {
+			t.Fatalf("Failed to add subscription: %v", err)
+		}
+	}
+	var got []string
+	all, err := db.GetGuildSubscriptions(ctx, testGuildID)
+	if err != nil {
+		t.Fatalf("Failed to get subscriptions: %v", err)
+	}
+	for _, s := range all {
+		got = append(got, s.UserID+":"+s.Name)
+	}
+	if want := []string{"3:Bob", "5:", "5:Alice"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected subscriptions %v, got %v", want, got)
+	}
+
+	// A failure is recorded on all of the user's subscriptions in the guild
+	failedAt := time.Date(2025, time.June, 3, 9, 0, 0, 0, time.UTC)
+	if err := db.SetSubscriptionFailure(ctx, testGuildID, "5", "DMs are off", failedAt); err != nil {
+		t.Fatalf("Failed to record failure: %v", err)
+	}
+	mine, _ := db.GetSubscriptions(ctx, testGuildID, "5")
+	for _, s := range mine {
+		if s.Failure != "DMs are off" || !s.FailedAt.Equal(failedAt) {
+			t.Errorf("Expected the failure on %+v", s)
+		}
+	}
+	if other, _ := db.GetSubscriptions(ctx, "guild-2", "5"); len(other) != 1 || other[0].Failure != "" {
+		t.Errorf("Expected other guilds' subscriptions to be untouched, got %+v", other)
+	}
+
+	// Subscriptions follow a rename, and are deleted with their birthday
+	if err := db.UpsertByDiscordID(ctx, testGuildID, aliceDiscordID, "Alicia", 6, 4, nil, nil, nil); err != nil {
+		t.Fatalf("Failed to rename birthday: %v", err)
+	}
+	if mine, _ := db.GetSubscriptions(ctx, testGuildID, "5"); len(mine) != 2 || mine[1].Name != "Alicia" || *mine[1].BirthdayID != alice.ID {
+		t.Errorf("Expected the subscription to follow the rename to Alicia, got %+v", mine)
+	}
+	if err := db.DeleteBirthday(ctx, testGuildID, "Bob"); err != nil {
+		t.Fatalf("Failed to delete birthday: %v", err)
+	}
+	if theirs, _ := db.GetSubscriptions(ctx, testGuildID, "3"); len(theirs) != 0 {
+		t.Errorf("Expected subscriptions to a deleted birthday to be deleted, got %+v", theirs)
+	}
+
+	if err := db.DeleteSubscription(ctx, testGuildID, "5", &alice.ID); err != nil {
+		t.Fatalf("Failed to delete subscription: %v", err)
+	}
+	if err := db.DeleteSubscription(ctx, testGuildID, "5", &alice.ID); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting a missing subscription, got %v", err)
+	}
+	if err := db.DeleteSubscription(ctx, testGuildID, "5", nil); err != nil {
+		t.Fatalf("Failed to delete the subscription to every birthday: %v", err)
+	}
+}
+
+func TestReminderKind(t *testing.T) {
+	kind := database.ReminderKind(7)
+	if days, ok := database.ReminderDays(kind); !ok || days != 7 {
+		t.Errorf("ReminderDays(%q) = %d, %v; want 7, true", kind, days, ok)
+	}
+	if _, ok := database.ReminderDays(database.AnnouncementBirthday); ok {
+		t.Errorf("Expected %q not to be a reminder", database.AnnouncementBirthday)
+	}
+}
+
+func TestPronounSet(t *testing.T) {
+	custom := database.Pronouns{Subject: "xe", Object: "xem", Possessive: "xyr", Reflexive: "xemself"}
 	tests := []struct {
-		name        string
-		gender      *string
-		subjectForm bool
-		expected    string
+		name     string
+		pronouns *database.Pronouns
+		form     string
+		expected string
 	}{
-		{"Male subject", stringPtr("male"), true, "he"},
-		{"Male possessive", stringPtr("male"), false, "his"},
-		{"Female subject", stringPtr("female"), true, "she"},
-		{"Female possessive", stringPtr("female"), false, "her"},
-		{"Nonbinary subject", stringPtr("nonbinary"), true, "they"},
-		{"Nonbinary possessive", stringPtr("nonbinary"), false, "their"},
-		{"Nil subject", nil, true, "they"},
-		{"Nil possessive", nil, false, "their"},
+		{"He subject", &database.HeHim, database.PronounSubject, "he"},
+		{"He object", &database.HeHim, database.PronounObject, "him"},
+		{"He possessive", &database.HeHim, database.PronounPossessive, "his"},
+		{"He reflexive", &database.HeHim, database.PronounReflexive, "himself"},
+		{"She object", &database.SheHer, database.PronounObject, "her"},
+		{"She possessive", &database.SheHer, database.PronounPossessive, "her"},
+		{"They subject", &database.TheyThem, database.PronounSubject, "they"},
+		{"They possessive", &database.TheyThem, database.PronounPossessive, "their"},
+		{"Custom reflexive", &custom, database.PronounReflexive, "xemself"},
+		{"Nil subject", nil, database.PronounSubject, "they"},
+		{"Nil object", nil, database.PronounObject, "them"},
+		{"Nil possessive", nil, database.PronounPossessive, "their"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			birthday := database.Birthday{
-				Name:   "Test",
-				Month:  1,
-				Day:    1,
-				Gender: tt.gender,
+				Name:     "Test",
+				Month:    1,
+				Day:      1,
+				Pronouns: tt.pronouns,
 			}
 
-			result := birthday.GetPronoun(tt.subjectForm)
+			result, ok := birthday.PronounSet().Form(tt.form)
+			if !ok {
+				t.Fatalf("Expected %q to be a pronoun form", tt.form)
+			}
 			if result != tt.expected {
 				t.Errorf("Expected '%s', got '%s'", tt.expected, result)
 			}
 		})
 	}
+
+	if _, ok := database.TheyThem.Form("sideways"); ok {
+		t.Error("Expected an unknown form to be rejected")
+	}
 }
 
-func stringPtr(s string) *string {
-	return &s
+func TestParsePronouns(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected database.Pronouns
+		valid    bool
+	}{
+		{"he", database.HeHim, true},
+		{"She/Her", database.SheHer, true},
+		{" they / them ", database.TheyThem, true},
+		{"he/him/his/himself", database.HeHim, true},
+		{"xe/xem/xyr/xemself", database.Pronouns{Subject: "xe", Object: "xem", Possessive: "xyr", Reflexive: "xemself"}, true},
+		{"he/her", database.Pronouns{}, false},
+		{"xe/xem", database.Pronouns{}, false},
+		{"xe/xem/xyr", database.Pronouns{}, false},
+		{"xe//xyr/xemself", database.Pronouns{}, false},
+		{"", database.Pronouns{}, false},
+		{"x/y/z/abcdefghijklmnopqrstuvwxyz", database.Pronouns{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			result, err := database.ParsePronouns(tt.text)
+			if !tt.valid {
+				if !errors.Is(err, database.ErrInvalidValue) {
+					t.Errorf("Expected ErrInvalidValue, got %v (%+v)", err, result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePronouns returned error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestPronounsAreStored(t *testing.T) {
+	db := setupTestDB(t)
+	custom := database.Pronouns{Subject: "xe", Object: "xem", Possessive: "xyr", Reflexive: "xemself"}
+
+	if err := db.AddBirthday(ctx, testGuildID, "Sam", 1, 1, nil, false, &custom, nil); err != nil {
+		t.Fatalf("Failed to add birthday: %v", err)
+	}
+	if err := db.AddBirthday(ctx, testGuildID, "Pat", 1, 2, nil, false, nil, nil); err != nil {
+		t.Fatalf("Failed to add birthday: %v", err)
+	}
+
+	sam, _ := db.GetBirthday(ctx, testGuildID, "Sam")
+	if sam == nil || sam.Pronouns == nil || *sam.Pronouns != custom {
+		t.Errorf("Expected custom pronouns to round trip, got %+v", sam)
+	}
+	pat, _ := db.GetBirthday(ctx, testGuildID, "Pat")
+	if pat == nil || pat.Pronouns != nil {
+		t.Errorf("Expected no pronouns, got %+v", pat)
+	}
 }
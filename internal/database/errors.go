@@ -0,0 +1,41 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Errors returned when a birthday can't be stored or found. Callers should
+// check for them with errors.Is, as they are wrapped with details. Any other
+// error means the database itself failed.
+var (
+	// ErrInvalidDate means a month and day don't form a real date, e.g. April 31
+	ErrInvalidDate = errors.New("invalid date")
+
+	// ErrDuplicateName means the guild already has a birthday with that name
+	ErrDuplicateName = errors.New("a birthday with this name already exists")
+
+	// ErrNotFound means there is no matching birthday
+	ErrNotFound = errors.New("no birthday found")
+
+	// ErrInvalidValue means a value other than the date is out of range, such as
+	// an empty name or a year of birth in the future
+	ErrInvalidValue = errors.New("invalid value")
+)
+
+// birthdayWriteError wraps an error from writing the birthday with the given
+// name, translating SQLite constraint violations into the errors above
+func birthdayWriteError(action, name string, err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique:
+			return fmt.Errorf("%w: %s", ErrDuplicateName, name)
+		case sqlite3.ErrConstraintCheck, sqlite3.ErrConstraintNotNull:
+			return fmt.Errorf("%w: %v", ErrInvalidValue, err)
+		}
+	}
+	return fmt.Errorf("failed to %s: %w", action, err)
+}
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// DateLayout is the format of calendar dates stored in the database
+const DateLayout = "2006-01-02"
+
+// Leap day policies: when birthdays on February 29 are celebrated in non-leap years
+const (
+	LeapDayFeb28 = "feb28"
+	LeapDayMar1  = "mar1"
+)
+
+// Guild holds the per-server settings for announcements
+type Guild struct {
+	GuildID   string
+	ChannelID string // Channel for announcements, empty if disabled
+	Timezone  string // IANA timezone name, e.g. "America/New_York"
+	Hour      int    // Announcement time of day in Timezone
+	Minute    int
+
+	// LeapDayPolicy is LeapDayFeb28 or LeapDayMar1
+	LeapDayPolicy string
+
+	// LastAnnouncedOn is the date (YYYY-MM-DD in Timezone) of the last successful
+	// announcement, or empty if there has been none
+	LastAnnouncedOn string
+}
+
+// DefaultGuild returns the settings a guild gets before anyone configures it
+func DefaultGuild(guildID string) Guild {
+	return Guild{GuildID: guildID, Timezone: "UTC", Hour: 9, Minute: 0, LeapDayPolicy: LeapDayFeb28}
+}
+
+// GetGuild gets the settings for a guild, or nil if it has not been configured
+func (db *DB) GetGuild(ctx context.Context, guildID string) (*Guild, error) {
+	query := `SELECT guild_id, channel_id, timezone, announce_hour, announce_minute, leap_day_policy, last_announced_on
+	          FROM guilds WHERE guild_id = ?`
+
+	var g Guild
+	err := db.conn.QueryRowContext(ctx, query, guildID).Scan(&g.GuildID, &g.ChannelID, &g.Timezone, &g.Hour, &g.Minute, &g.LeapDayPolicy, &g.LastAnnouncedOn)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guild: %w", err)
+	}
+	return &g, nil
+}
+
+// GetGuilds returns the settings for every configured guild
+func (db *DB) GetGuilds(ctx context.Context) ([]Guild, error) {
+	query := `SELECT guild_id, channel_id, timezone, announce_hour, announce_minute, leap_day_policy, last_announced_on
+	          FROM guilds ORDER BY guild_id`
+
+	rows, err := db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query guilds: %w", err)
+	}
+	defer func() {
+		_ = rows.Close() // Best effort close
+	}()
+
+	var guilds []Guild
+	for rows.Next() {
+		var g Guild
+		if err := rows.Scan(&g.GuildID, &g.ChannelID, &g.Timezone, &g.Hour, &g.Minute, &g.LeapDayPolicy, &g.LastAnnouncedOn); err != nil {
+			return nil, fmt.Errorf("failed to scan guild: %w", err)
+		}
+		guilds = append(guilds, g)
+	}
+
+	return guilds, nil
+}
+
+// UpsertGuild creates or replaces all settings for a guild
+func (db *DB) UpsertGuild(ctx context.Context, g Guild) error {
+	query := `INSERT INTO guilds (guild_id, channel_id, timezone, announce_hour, announce_minute) VALUES (?, ?, ?, ?, ?)
+	          ON CONFLICT(guild_id) DO UPDATE SET channel_id = excluded.channel_id, timezone = excluded.timezone,
+	          announce_hour = excluded.announce_hour, announce_minute = excluded.announce_minute`
+	if _, err := db.conn.ExecContext(ctx, query, g.GuildID, g.ChannelID, g.Timezone, g.Hour, g.Minute); err != nil {
+		return fmt.Errorf("failed to save guild: %w", err)
+	}
+	return nil
+}
+
+// SetGuildChannel sets the announcement channel for a guild, creating its
+// settings with defaults if needed
+func (db *DB) SetGuildChannel(ctx context.Context, guildID, channelID string) error {
+	query := `INSERT INTO guilds (guild_id, channel_id) VALUES (?, ?)
+	          ON CONFLICT(guild_id) DO UPDATE SET channel_id = excluded.channel_id`
+	if _, err := db.conn.ExecContext(ctx, query, guildID, channelID); err != nil {
+		return fmt.Errorf("failed to save guild channel: %w", err)
+	}
+	return nil
+}
+
+// SetGuildTimezone sets the timezone for a guild, creating its settings with
+// defaults if needed
+func (db *DB) SetGuildTimezone(ctx context.Context, guildID, timezone string) error {
+	query := `INSERT INTO guilds (guild_id, timezone) VALUES (?, ?)
+	          ON CONFLICT(guild_id) DO UPDATE SET timezone = excluded.timezone`
+	if _, err := db.conn.ExecContext(ctx, query, guildID, timezone); err != nil {
+		return fmt.Errorf("failed to save guild timezone: %w", err)
+	}
+	return nil
+}
+
+// SetGuildAnnouncementTime sets the time of day announcements are sent for a
+// guild, creating its settings with defaults if needed
+func (db *DB) SetGuildAnnouncementTime(ctx context.Context, guildID string, hour, minute int) error {
+	query := `INSERT INTO guilds (guild_id, announce_hour, announce_minute) VALUES (?, ?, ?)
+	          ON CONFLICT(guild_id) DO UPDATE SET announce_hour = excluded.announce_hour, announce_minute = excluded.announce_minute`
+	if _, err := db.conn.ExecContext(ctx, query, guildID, hour, minute); err != nil {
+		return fmt.Errorf("failed to save guild announcement time: %w", err)
+	}
+	return nil
+}
+
+// SetGuildLeapDayPolicy sets when a guild celebrates February 29 birthdays in
+// non-leap years, creating its settings with defaults if needed
+func (db *DB) SetGuildLeapDayPolicy(ctx context.Context, guildID, policy string) error {
+	query := `INSERT INTO guilds (guild_id, leap_day_policy) VALUES (?, ?)
+	          ON CONFLICT(guild_id) DO UPDATE SET leap_day_policy = excluded.leap_day_policy`
+	if _, err := db.conn.ExecContext(ctx, query, guildID, policy); err != nil {
+		return fmt.Errorf("failed to save guild leap day policy: %w", err)
+	}
+	return nil
+}
+
+// SetGuildLastAnnounced records the date of a guild's last successful announcement
+func (db *DB) SetGuildLastAnnounced(ctx context.Context, guildID, date string) error {
+	query := `UPDATE guilds SET last_announced_on = ? WHERE guild_id = ?`
+	result, err := db.conn.ExecContext(ctx, query, date, guildID)
+	if err != nil {
+		return fmt.Errorf("failed to save last announcement date: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("no settings found for guild %s", guildID)
+	}
+	return nil
+}
+
+// Location returns the guild's timezone, falling back to UTC if it is unset or unknown
+func (g *Guild) Location() *time.Location {
+	if g.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(g.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// AcquireLease takes or renews the named lease for holder until now+duration.
+// It returns false if another holder has a lease that hasn't expired. The check
+// and the write are a single statement, so two processes sharing the database
+// cannot both acquire the lease.
+func (db *DB) AcquireLease(name, holder string, now time.Time, duration time.Duration) (bool, error) {
+	query := `INSERT INTO leases (name, holder, expires_at) VALUES (?, ?, ?)
+	          ON CONFLICT(name) DO UPDATE SET holder = excluded.holder, expires_at = excluded.expires_at
+	          WHERE leases.holder = excluded.holder OR leases.expires_at <= ?`
+
+	nowMillis := now.UnixMilli()
+	result, err := db.conn.Exec(query, name, holder, now.Add(duration).UnixMilli(), nowMillis)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows == 1, nil
+}
+
+// ReleaseLease gives up the named lease if holder has it
+func (db *DB) ReleaseLease(name, holder string) error {
+	query := `DELETE FROM leases WHERE name = ? AND holder = ?`
+	if _, err := db.conn.Exec(query, name, holder); err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
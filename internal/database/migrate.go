@@ -0,0 +1,289 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is one ordered, embedded schema change
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// MigrationStatus describes a migration and when it was applied (nil if pending)
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Migrations returns the embedded migrations in version order. Files are named
+// NNNN_description.sql, where NNNN is the version.
+func Migrations() ([]Migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	migrations := make([]Migration, 0, len(entries))
+	for _, entry := range entries {
+		prefix, name, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s is not named NNNN_description.sql", entry.Name())
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has an invalid version: %w", entry.Name(), err)
+		}
+		body, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, SQL: string(body)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", migrations[i].Version)
+		}
+	}
+	return migrations, nil
+}
+
+// MigrationStatus returns every known migration and whether it has been applied
+func (db *DB) MigrationStatus() ([]MigrationStatus, error) {
+	if err := db.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		s := MigrationStatus{Version: m.Version, Name: m.Name}
+		if at, ok := applied[m.Version]; ok {
+			at := at
+			s.AppliedAt = &at
+		}
+		status = append(status, s)
+	}
+	return status, nil
+}
+
+// Migrate applies every pending migration in order, each in its own transaction,
+// and returns the migrations that were applied
+func (db *DB) Migrate() ([]Migration, error) {
+	if err := db.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	// Rebuilding a table must not cascade deletes into tables that reference it,
+	// so foreign keys are checked after each migration instead of enforced during it.
+	// The pragma is a no-op inside a transaction, so it is set around them.
+	if _, err := db.conn.Exec("PRAGMA foreign_keys = OFF"); err != nil {
+		return nil, fmt.Errorf("failed to disable foreign keys: %w", err)
+	}
+	defer func() {
+		_, _ = db.conn.Exec("PRAGMA foreign_keys = ON") // Best effort, Open enabled it
+	}()
+
+	var ran []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if err := db.applyMigration(m); err != nil {
+			return ran, err
+		}
+		ran = append(ran, m)
+	}
+	return ran, nil
+}
+
+// applyMigration runs one migration and records it atomically
+func (db *DB) applyMigration(m Migration) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", m.Version, err)
+	}
+	defer func() {
+		_ = tx.Rollback() // No-op after a successful commit
+	}()
+
+	if _, err := tx.Exec(m.SQL); err != nil {
+		return fmt.Errorf("failed to apply migration %d (%s): %w", m.Version, m.Name, err)
+	}
+	if err := checkForeignKeys(tx); err != nil {
+		return fmt.Errorf("migration %d (%s) broke a foreign key: %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.Version, m.Name); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", m.Version, err)
+	}
+	return nil
+}
+
+// checkForeignKeys fails if any row references a missing parent row
+func checkForeignKeys(tx *sql.Tx) error {
+	rows, err := tx.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rows.Close() // Best effort close
+	}()
+
+	if rows.Next() {
+		var table string
+		var rowID sql.NullInt64
+		var parent string
+		var fkID int
+		if err := rows.Scan(&table, &rowID, &parent, &fkID); err != nil {
+			return err
+		}
+		return fmt.Errorf("row %d in %s references a missing row in %s", rowID.Int64, table, parent)
+	}
+	return rows.Err()
+}
+
+// ensureMigrationsTable creates schema_migrations. Databases created before
+// versioned migrations existed are detected from their tables and marked as
+// already being at the matching version, so their data is kept.
+func (db *DB) ensureMigrationsTable() error {
+	_, err := db.conn.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+	    version INTEGER PRIMARY KEY,
+	    name TEXT NOT NULL,
+	    applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var count int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&count); err != nil {
+		return fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	baseline, err := db.detectUnversionedSchema()
+	if err != nil || baseline == 0 {
+		return err
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if m.Version > baseline {
+			break
+		}
+		if _, err := db.conn.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.Version, m.Name); err != nil {
+			return fmt.Errorf("failed to record baseline migration %d: %w", m.Version, err)
+		}
+	}
+	return nil
+}
+
+// detectUnversionedSchema returns the migration version matching the tables of a
+// database created from the old embedded schema, or 0 for an empty database
+func (db *DB) detectUnversionedSchema() (int, error) {
+	hasBirthdays, err := db.columnExists("birthdays", "id")
+	if err != nil || !hasBirthdays {
+		return 0, err
+	}
+	hasGuilds, err := db.columnExists("birthdays", "guild_id")
+	if err != nil {
+		return 0, err
+	}
+	if hasGuilds {
+		return 2, nil
+	}
+	return 1, nil
+}
+
+// appliedMigrations returns the applied versions and when they were applied
+func (db *DB) appliedMigrations() (map[int]time.Time, error) {
+	rows, err := db.conn.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer func() {
+		_ = rows.Close() // Best effort close
+	}()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// columnExists reports whether a table has a column. A missing table has no columns.
+func (db *DB) columnExists(table, column string) (bool, error) {
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	defer func() {
+		_ = rows.Close() // Best effort close
+	}()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   bool
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return false, fmt.Errorf("failed to scan table info: %w", err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
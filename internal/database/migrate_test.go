@@ -0,0 +1,383 @@
+package database_test
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+)
+
+// createFixtureDB creates a database file from a SQL fixture in testdata
+func createFixtureDB(t *testing.T, fixture string, extraSQL ...string) string {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "fixture.db")
+	conn, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open fixture database: %v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	for _, stmt := range append([]string{string(body)}, extraSQL...) {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatalf("Failed to load fixture: %v", err)
+		}
+	}
+	return dbPath
+}
+
+// createDBAtVersion creates a database file with the migrations up to and
+// including version applied, then runs extraSQL to add data for later
+// migrations to upgrade
+func createDBAtVersion(t *testing.T, version int, extraSQL ...string) string {
+	t.Helper()
+	migrations, err := database.Migrations()
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "versioned.db")
+	conn, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	statements := []string{`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at DATETIME DEFAULT CURRENT_TIMESTAMP)`}
+	for _, m := range migrations {
+		if m.Version > version {
+			break
+		}
+		statements = append(statements, m.SQL, fmt.Sprintf(`INSERT INTO schema_migrations (version, name) VALUES (%d, '%s')`, m.Version, m.Name))
+	}
+	for _, stmt := range append(statements, extraSQL...) {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set up database: %v", err)
+		}
+	}
+	return dbPath
+}
+
+// assertAtHead checks that every migration has been applied
+func assertAtHead(t *testing.T, db *database.DB) {
+	t.Helper()
+	status, err := db.MigrationStatus()
+	if err != nil {
+		t.Fatalf("Failed to get migration status: %v", err)
+	}
+	migrations, _ := database.Migrations()
+	if len(status) != len(migrations) {
+		t.Fatalf("Expected %d migrations in status, got %d", len(migrations), len(status))
+	}
+	for _, s := range status {
+		if s.AppliedAt == nil {
+			t.Errorf("Expected migration %d (%s) to be applied", s.Version, s.Name)
+		}
+	}
+}
+
+// assertV1DataKept checks that the rows from testdata/v1.sql survived an upgrade
+func assertV1DataKept(t *testing.T, db *database.DB) {
+	t.Helper()
+	all, err := db.GetAllBirthdays(ctx, "")
+	if err != nil {
+		t.Fatalf("Failed to get birthdays: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("Expected 3 birthdays after upgrade, got %d", len(all))
+	}
+
+	bob, _ := db.GetBirthday(ctx, "", "Bob")
+	if bob == nil || bob.Month != 6 || bob.Day != 10 {
+		t.Fatalf("Expected Bob on 6/10, got %+v", bob)
+	}
+	if bob.Pronouns == nil || *bob.Pronouns != database.HeHim || bob.DiscordID == nil || *bob.DiscordID != "1234" {
+		t.Errorf("Expected Bob's gender to become he/him and discord ID to be kept, got %+v", bob)
+	}
+}
+
+func TestMigrations_AreOrderedAndUnique(t *testing.T) {
+	migrations, err := database.Migrations()
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("Expected embedded migrations")
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("Expected migration %d at position %d, got version %d", i+1, i, m.Version)
+		}
+	}
+}
+
+func TestNew_MigratesEmptyDatabaseToHead(t *testing.T) {
+	db := setupTestDB(t)
+	assertAtHead(t, db)
+}
+
+func TestNew_UpgradesUnversionedV1Database(t *testing.T) {
+	dbPath := createFixtureDB(t, "v1.sql")
+
+	db, err := database.New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to upgrade v1 database: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	assertAtHead(t, db)
+	assertV1DataKept(t, db)
+}
+
+func TestNew_UpgradesVersionedV1Database(t *testing.T) {
+	dbPath := createFixtureDB(t, "v1.sql",
+		`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at DATETIME DEFAULT CURRENT_TIMESTAMP)`,
+		`INSERT INTO schema_migrations (version, name) VALUES (1, 'create_birthdays')`,
+	)
+
+	db, err := database.New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to upgrade v1 database: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	assertAtHead(t, db)
+	assertV1DataKept(t, db)
+}
+
+func TestNew_MovesCaseyMessageToOverride(t *testing.T) {
+	dbPath := createFixtureDB(t, "v1.sql",
+		`INSERT INTO birthdays (name, month, day) VALUES ('Casey', 1, 6)`,
+	)
+
+	db, err := database.New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to upgrade v1 database: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	overrides, err := db.GetOverrides(ctx, "")
+	if err != nil {
+		t.Fatalf("Failed to get overrides: %v", err)
+	}
+	if len(overrides) != 1 || overrides[0].Name != "Casey" || overrides[0].Month != 1 || overrides[0].Day != 6 {
+		t.Fatalf("Expected Casey's January 6 message to become an override, got %+v", overrides)
+	}
+	if !strings.Contains(overrides[0].Message, "Capitol Riots") {
+		t.Errorf("Expected the old message to be kept, got %q", overrides[0].Message)
+	}
+}
+
+func TestOpen_ReportsPendingMigrations(t *testing.T) {
+	dbPath := createFixtureDB(t, "v1.sql")
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	status, err := db.MigrationStatus()
+	if err != nil {
+		t.Fatalf("Failed to get migration status: %v", err)
+	}
+	if status[0].AppliedAt == nil {
+		t.Error("Expected the v1 schema to be detected as applied")
+	}
+	pending := 0
+	for _, s := range status {
+		if s.AppliedAt == nil {
+			pending++
+		}
+	}
+	if pending == 0 {
+		t.Fatal("Expected pending migrations on a v1 database")
+	}
+
+	ran, err := db.Migrate()
+	if err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if len(ran) != pending {
+		t.Errorf("Expected %d migrations to run, got %d", pending, len(ran))
+	}
+
+	// Running again is a no-op
+	ran, err = db.Migrate()
+	if err != nil || len(ran) != 0 {
+		t.Errorf("Expected second migrate to do nothing, got %d migrations and error %v", len(ran), err)
+	}
+}
+
+func TestNew_DetectsUnversionedGuildDatabase(t *testing.T) {
+	// A database created by the embedded schema that already had guild support
+	guilds, err := os.ReadFile(filepath.Join("migrations", "0002_guilds.sql"))
+	if err != nil {
+		t.Fatalf("Failed to read migration: %v", err)
+	}
+	dbPath := createFixtureDB(t, "v1.sql", string(guilds))
+
+	db, err := database.New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	assertAtHead(t, db)
+	assertV1DataKept(t, db)
+}
+
+func TestAssignUnscopedBirthdays(t *testing.T) {
+	db, err := database.New(createFixtureDB(t, "v1.sql"))
+	if err != nil {
+		t.Fatalf("Failed to upgrade v1 database: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	moved, err := db.AssignUnscopedBirthdays(ctx, testGuildID)
+	if err != nil {
+		t.Fatalf("Failed to assign birthdays: %v", err)
+	}
+	if moved != 3 {
+		t.Errorf("Expected 3 birthdays assigned, got %d", moved)
+	}
+
+	alice, _ := db.GetBirthday(ctx, testGuildID, "Alice")
+	if alice == nil || alice.Month != 1 || alice.Day != 25 {
+		t.Errorf("Expected Alice in %s, got %+v", testGuildID, alice)
+	}
+}
+
+func TestNew_MovesSubscriptionsToBirthdays(t *testing.T) {
+	// Birthdays are only unique by exact name, so a subscription made ignoring
+	// case can match several of them
+	dbPath := createDBAtVersion(t, 14,
+		`INSERT INTO birthdays (id, guild_id, name, month, day) VALUES
+		    (1, 'guild-1', 'alex', 1, 1), (2, 'guild-1', 'Alex', 2, 2), (3, 'guild-1', 'ALEX', 3, 3),
+		    (4, 'guild-1', 'sam', 4, 4), (5, 'guild-1', 'Sam', 5, 5)`,
+		`INSERT INTO subscriptions (guild_id, user_id, name) VALUES
+		    ('guild-1', '1', 'Alex'), ('guild-1', '2', 'SAM'), ('guild-1', '3', ''), ('guild-1', '4', 'Gone')`,
+	)
+
+	db, err := database.New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to upgrade database: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	subscriptions, err := db.GetGuildSubscriptions(ctx, testGuildID)
+	if err != nil {
+		t.Fatalf("Failed to get subscriptions: %v", err)
+	}
+	var got []string
+	for _, s := range subscriptions {
+		got = append(got, s.UserID+":"+s.Name)
+	}
+	// The exact spelling wins, then the oldest birthday; unknown names are dropped
+	if want := []string{"1:Alex", "2:sam", "3:"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected subscriptions %v, got %v", want, got)
+	}
+}
+
+func TestNew_MovesOverridesToBirthdays(t *testing.T) {
+	dbPath := createDBAtVersion(t, 15,
+		`INSERT INTO birthdays (id, guild_id, name, month, day) VALUES
+		    (1, 'guild-1', 'alex', 1, 1), (2, 'guild-1', 'Alex', 1, 1), (3, 'guild-1', 'sam', 4, 4), (4, 'guild-1', 'Sam', 4, 4)`,
+		`INSERT INTO overrides (guild_id, month, day, name, message) VALUES
+		    ('guild-1', 1, 1, 'Alex', 'exact'), ('guild-1', 4, 4, 'SAM', 'oldest'), ('guild-1', 4, 4, '', 'everyone'),
+		    ('guild-1', 5, 5, 'Gone', 'dropped')`,
+	)
+
+	db, err := database.New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to upgrade database: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	overrides, err := db.GetOverrides(ctx, testGuildID)
+	if err != nil {
+		t.Fatalf("Failed to get overrides: %v", err)
+	}
+	var got []string
+	for _, o := range overrides {
+		got = append(got, o.Name+":"+o.Message)
+	}
+	// The exact spelling wins, then the oldest birthday; unknown names are dropped
+	if want := []string{"Alex:exact", ":everyone", "sam:oldest"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected overrides %v, got %v", want, got)
+	}
+}
+
+func TestOpen_WaitsForOtherConnections(t *testing.T) {
+	// Arrange: another process sharing the file holds the write lock for a moment
+	dbPath := filepath.Join(t.TempDir(), "shared.db")
+	db, err := database.New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	other, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open second connection: %v", err)
+	}
+	defer func() {
+		_ = other.Close()
+	}()
+	tx, err := other.Begin()
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	if _, err := tx.Exec(`INSERT INTO leases (name, holder, expires_at) VALUES ('other', 'other', ?)`, time.Now().UnixMilli()); err != nil {
+		t.Fatalf("Failed to take the write lock: %v", err)
+	}
+	released := make(chan error, 1)
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		released <- tx.Commit()
+	}()
+
+	// Act
+	acquired, err := db.AcquireLease("announcer", "replica-1", time.Now(), time.Minute)
+
+	// Assert: the write waited for the lock instead of failing with SQLITE_BUSY
+	if err != nil || !acquired {
+		t.Errorf("AcquireLease() = %v, %v; want it to wait for the other writer and succeed", acquired, err)
+	}
+	if err := <-released; err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	if _, err := os.Stat(dbPath + "-wal"); err != nil {
+		t.Errorf("Expected the database to use write-ahead logging: %v", err)
+	}
+}
@@ -1,4 +1,4 @@
--- Birthday database schema
+-- Initial birthday schema
 
 CREATE TABLE IF NOT EXISTS birthdays (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
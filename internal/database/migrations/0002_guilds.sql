@@ -0,0 +1,50 @@
+-- Partition birthdays by Discord server and add per-server settings.
+-- SQLite cannot change a UNIQUE constraint in place, so the table is rebuilt.
+-- Existing rows get an empty guild_id until they are claimed by a server.
+
+ALTER TABLE birthdays RENAME TO birthdays_v1;
+DROP INDEX IF EXISTS idx_birthdays_date;
+DROP INDEX IF EXISTS idx_birthdays_discord_id;
+DROP TRIGGER IF EXISTS update_birthdays_timestamp;
+
+CREATE TABLE birthdays (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    guild_id TEXT NOT NULL DEFAULT '',  -- Discord server the birthday belongs to ('' = not yet assigned)
+    name TEXT NOT NULL,
+    month INTEGER NOT NULL CHECK(month >= 1 AND month <= 12),
+    day INTEGER NOT NULL CHECK(day >= 1 AND day <= 31),
+    gender TEXT CHECK(gender IN ('male', 'female', 'nonbinary', 'other', NULL)),  -- For pronoun reference
+    discord_id TEXT,  -- Optional: link to Discord user
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(guild_id, name)  -- One birthday per name in each server
+);
+
+INSERT INTO birthdays (id, guild_id, name, month, day, gender, discord_id, created_at, updated_at)
+SELECT id, '', name, month, day, gender, discord_id, created_at, updated_at FROM birthdays_v1;
+
+DROP TABLE birthdays_v1;
+
+CREATE INDEX idx_birthdays_date ON birthdays(guild_id, month, day);
+CREATE INDEX idx_birthdays_discord_id ON birthdays(guild_id, discord_id);
+
+CREATE TRIGGER update_birthdays_timestamp
+AFTER UPDATE ON birthdays
+BEGIN
+    UPDATE birthdays SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
+
+-- Per-server settings
+CREATE TABLE guilds (
+    guild_id TEXT PRIMARY KEY,
+    channel_id TEXT NOT NULL DEFAULT '',  -- Channel for announcements ('' = announcements disabled)
+    timezone TEXT NOT NULL DEFAULT 'UTC',  -- IANA timezone used to decide what "today" is
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_guilds_timestamp
+AFTER UPDATE ON guilds
+BEGIN
+    UPDATE guilds SET updated_at = CURRENT_TIMESTAMP WHERE guild_id = NEW.guild_id;
+END;
@@ -0,0 +1,4 @@
+-- Per-server announcement time, in the server's timezone (defaults to 9:00)
+
+ALTER TABLE guilds ADD COLUMN announce_hour INTEGER NOT NULL DEFAULT 9 CHECK(announce_hour >= 0 AND announce_hour <= 23);
+ALTER TABLE guilds ADD COLUMN announce_minute INTEGER NOT NULL DEFAULT 0 CHECK(announce_minute >= 0 AND announce_minute <= 59);
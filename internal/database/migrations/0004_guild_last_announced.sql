@@ -0,0 +1,4 @@
+-- Date of the last successful announcement per server (YYYY-MM-DD in the
+-- server's timezone), so missed announcements can be caught up after a restart
+
+ALTER TABLE guilds ADD COLUMN last_announced_on TEXT NOT NULL DEFAULT '';
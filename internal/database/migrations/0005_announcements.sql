@@ -0,0 +1,10 @@
+-- Ledger of announcements made per server, so each kind of announcement is
+-- posted at most once per day even across restarts or several replicas
+
+CREATE TABLE IF NOT EXISTS announcements (
+    guild_id TEXT NOT NULL,
+    date TEXT NOT NULL,     -- YYYY-MM-DD in the server's timezone
+    kind TEXT NOT NULL,     -- e.g. 'monthly' or 'birthday'
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (guild_id, date, kind)
+);
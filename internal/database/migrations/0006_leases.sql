@@ -0,0 +1,7 @@
+-- Leases used for leader election between replicas sharing this database
+
+CREATE TABLE IF NOT EXISTS leases (
+    name TEXT PRIMARY KEY,
+    holder TEXT NOT NULL,
+    expires_at INTEGER NOT NULL -- Unix time in milliseconds
+);
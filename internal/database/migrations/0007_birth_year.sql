@@ -0,0 +1,5 @@
+-- Optional year of birth, used to announce ages. year_private keeps the year
+-- stored for admins while leaving ages out of announcements.
+
+ALTER TABLE birthdays ADD COLUMN year INTEGER CHECK (year IS NULL OR year > 0);
+ALTER TABLE birthdays ADD COLUMN year_private INTEGER NOT NULL DEFAULT 0 CHECK (year_private IN (0, 1));
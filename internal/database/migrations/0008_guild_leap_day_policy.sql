@@ -0,0 +1,4 @@
+-- When birthdays on February 29 are celebrated in non-leap years: on February 28
+-- or on March 1
+
+ALTER TABLE guilds ADD COLUMN leap_day_policy TEXT NOT NULL DEFAULT 'feb28' CHECK(leap_day_policy IN ('feb28', 'mar1'));
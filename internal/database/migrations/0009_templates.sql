@@ -0,0 +1,12 @@
+-- Custom announcement templates per server. Each kind of announcement has a
+-- pool of variants, one of which is picked at random for every announcement.
+
+CREATE TABLE IF NOT EXISTS templates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    guild_id TEXT NOT NULL,
+    kind TEXT NOT NULL,     -- e.g. 'monthly' or 'birthday'
+    body TEXT NOT NULL,     -- Go text/template source
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_templates_guild_kind ON templates(guild_id, kind);
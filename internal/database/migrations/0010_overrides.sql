@@ -0,0 +1,18 @@
+-- Custom messages for special dates, for one person (by name) or for everyone
+-- celebrating on the date (empty name). The message is an announcement template.
+
+CREATE TABLE IF NOT EXISTS overrides (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    guild_id TEXT NOT NULL,
+    month INTEGER NOT NULL CHECK(month >= 1 AND month <= 12),
+    day INTEGER NOT NULL CHECK(day >= 1 AND day <= 31),
+    name TEXT NOT NULL DEFAULT '' COLLATE NOCASE,  -- '' for everyone
+    message TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(guild_id, month, day, name)
+);
+
+-- Keep the message that used to be compiled into the bot for Casey on January 6
+INSERT OR IGNORE INTO overrides (guild_id, month, day, name, message)
+SELECT DISTINCT guild_id, 1, 6, name, 'Today is the anniversary of the **Capitol Riots**. Nothing else special happened today.'
+FROM birthdays WHERE name = 'Casey' AND month = 1 AND day = 6;
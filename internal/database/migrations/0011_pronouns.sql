@@ -0,0 +1,14 @@
+-- Replace gender with a pronoun set, stored as subject/object/possessive/reflexive.
+-- Existing genders get the pronouns they were announced with; nonbinary and
+-- other become they/them.
+
+ALTER TABLE birthdays ADD COLUMN pronouns TEXT;
+
+UPDATE birthdays SET pronouns = CASE gender
+    WHEN 'male' THEN 'he/him/his/himself'
+    WHEN 'female' THEN 'she/her/her/herself'
+    ELSE 'they/them/their/themselves'
+END
+WHERE gender IS NOT NULL;
+
+ALTER TABLE birthdays DROP COLUMN gender;
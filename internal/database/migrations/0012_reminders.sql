@@ -0,0 +1,14 @@
+-- Advance reminders of upcoming birthdays for organizers, posted some days
+-- before a birthday to an organizers channel, mentioning a role, or both
+
+CREATE TABLE IF NOT EXISTS reminder_settings (
+    guild_id TEXT PRIMARY KEY,
+    channel_id TEXT NOT NULL DEFAULT '',  -- '' for the announcement channel
+    role_id TEXT NOT NULL DEFAULT ''      -- '' to mention nobody
+);
+
+CREATE TABLE IF NOT EXISTS reminder_days (
+    guild_id TEXT NOT NULL,
+    days INTEGER NOT NULL CHECK(days >= 1),  -- Days before the birthday
+    PRIMARY KEY (guild_id, days)
+);
@@ -0,0 +1,13 @@
+-- Members who get a DM the day before a birthday, for one person (by name) or
+-- for everyone in the guild (empty name)
+
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    guild_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,                          -- Discord user who gets the DMs
+    name TEXT NOT NULL DEFAULT '' COLLATE NOCASE,   -- '' for every birthday
+    failure TEXT NOT NULL DEFAULT '',               -- Why the last DM could not be sent, '' if it was
+    failed_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(guild_id, user_id, name)
+);
@@ -0,0 +1,11 @@
+-- How many of the messages making up an announcement were delivered, so that a
+-- retry after a failure resumes instead of posting them again. It outlives a
+-- released claim in the announcements ledger.
+
+CREATE TABLE IF NOT EXISTS announcement_progress (
+    guild_id TEXT NOT NULL,
+    date TEXT NOT NULL,     -- YYYY-MM-DD in the server's timezone
+    kind TEXT NOT NULL,
+    sent INTEGER NOT NULL DEFAULT 0 CHECK(sent >= 0),  -- Messages delivered, in order
+    PRIMARY KEY (guild_id, date, kind)
+);
@@ -0,0 +1,37 @@
+-- Subscriptions to one person reference their birthday instead of its name, so
+-- they follow a rename and are deleted with the birthday. Subscriptions whose
+-- name no longer matches a birthday are dropped. Names were matched ignoring
+-- case but birthdays are only unique by exact name, so a name matching several
+-- birthdays goes to the one spelled the same way, or else the oldest.
+-- SQLite cannot add a foreign key in place, so the table is rebuilt.
+
+ALTER TABLE subscriptions RENAME TO subscriptions_v1;
+
+CREATE TABLE subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    guild_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,                                           -- Discord user who gets the DMs
+    birthday_id INTEGER REFERENCES birthdays(id) ON DELETE CASCADE,  -- NULL for every birthday
+    failure TEXT NOT NULL DEFAULT '',                                -- Why the last DM could not be sent, '' if it was
+    failed_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(guild_id, user_id, birthday_id)
+);
+
+-- NULLs are distinct in a UNIQUE constraint, so subscriptions to every birthday
+-- need their own index to stay one per user
+CREATE UNIQUE INDEX idx_subscriptions_everyone ON subscriptions(guild_id, user_id) WHERE birthday_id IS NULL;
+CREATE INDEX idx_subscriptions_birthday ON subscriptions(birthday_id);
+
+INSERT INTO subscriptions (id, guild_id, user_id, birthday_id, failure, failed_at, created_at)
+SELECT id, guild_id, user_id, birthday_id, failure, failed_at, created_at
+FROM (
+    SELECT s.*, CASE WHEN s.name = '' THEN NULL ELSE COALESCE(
+        (SELECT b.id FROM birthdays b WHERE b.guild_id = s.guild_id AND b.name = s.name COLLATE BINARY),
+        (SELECT MIN(b.id) FROM birthdays b WHERE b.guild_id = s.guild_id AND b.name = s.name COLLATE NOCASE)
+    ) END AS birthday_id
+    FROM subscriptions_v1 s
+)
+WHERE name = '' OR birthday_id IS NOT NULL;
+
+DROP TABLE subscriptions_v1;
@@ -0,0 +1,37 @@
+-- Overrides for one person reference their birthday instead of its name, so
+-- they follow a rename and are deleted with the birthday rather than passing to
+-- the next person added with the same name. Overrides whose name no longer
+-- matches a birthday are dropped. A name matching several birthdays ignoring
+-- case goes to the one spelled the same way, or else the oldest.
+-- SQLite cannot add a foreign key in place, so the table is rebuilt.
+
+ALTER TABLE overrides RENAME TO overrides_v1;
+
+CREATE TABLE overrides (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    guild_id TEXT NOT NULL,
+    month INTEGER NOT NULL CHECK(month >= 1 AND month <= 12),
+    day INTEGER NOT NULL CHECK(day >= 1 AND day <= 31),
+    birthday_id INTEGER REFERENCES birthdays(id) ON DELETE CASCADE,  -- NULL for everyone
+    message TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(guild_id, month, day, birthday_id)
+);
+
+-- NULLs are distinct in a UNIQUE constraint, so overrides for everyone need
+-- their own index to stay one per date
+CREATE UNIQUE INDEX idx_overrides_everyone ON overrides(guild_id, month, day) WHERE birthday_id IS NULL;
+CREATE INDEX idx_overrides_birthday ON overrides(birthday_id);
+
+INSERT INTO overrides (id, guild_id, month, day, birthday_id, message, created_at)
+SELECT id, guild_id, month, day, birthday_id, message, created_at
+FROM (
+    SELECT o.*, CASE WHEN o.name = '' THEN NULL ELSE COALESCE(
+        (SELECT b.id FROM birthdays b WHERE b.guild_id = o.guild_id AND b.name = o.name COLLATE BINARY),
+        (SELECT MIN(b.id) FROM birthdays b WHERE b.guild_id = o.guild_id AND b.name = o.name COLLATE NOCASE)
+    ) END AS birthday_id
+    FROM overrides_v1 o
+)
+WHERE name = '' OR birthday_id IS NOT NULL;
+
+DROP TABLE overrides_v1;
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Override is a custom message for a date, for one person or for everyone
+// celebrating on it. The message is an announcement template.
+type Override struct {
+	GuildID    string
+	Month      int
+	Day        int
+	BirthdayID *int   // Nil for everyone celebrating on the date
+	Name       string // Name of the birthday, or empty for everyone
+	Message    string
+}
+
+// overrideQuery selects the columns scanned by queryOverrides. The name is read
+// from the birthday, so it follows renames.
+const overrideQuery = `SELECT o.guild_id, o.month, o.day, o.birthday_id, COALESCE(b.name, ''), o.message
+	FROM overrides o LEFT JOIN birthdays b ON b.id = o.birthday_id`
+
+// SetOverride saves the override for a date and birthday, or for everyone if
+// birthdayID is nil, replacing any existing one
+func (db *DB) SetOverride(ctx context.Context, guildID string, month, day int, birthdayID *int, message string) error {
+	if err := ValidateDate(month, day); err != nil {
+		return err
+	}
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // No-op after a successful commit
+	}()
+
+	// Overrides for everyone have a NULL birthday, which ON CONFLICT can't match
+	query := `UPDATE overrides SET message = ? WHERE guild_id = ? AND month = ? AND day = ? AND birthday_id IS ?`
+	result, err := tx.ExecContext(ctx, query, message, guildID, month, day, birthdayID)
+	if err != nil {
+		return fmt.Errorf("failed to save override: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		query = `INSERT INTO overrides (guild_id, month, day, birthday_id, message) VALUES (?, ?, ?, ?, ?)`
+		if _, err := tx.ExecContext(ctx, query, guildID, month, day, birthdayID, message); err != nil {
+			return fmt.Errorf("failed to save override: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit override: %w", err)
+	}
+	return nil
+}
+
+// DeleteOverride removes the override for a date and birthday, or for everyone
+// if birthdayID is nil
+func (db *DB) DeleteOverride(ctx context.Context, guildID string, month, day int, birthdayID *int) error {
+	query := `DELETE FROM overrides WHERE guild_id = ? AND month = ? AND day = ? AND birthday_id IS ?`
+	result, err := db.conn.ExecContext(ctx, query, guildID, month, day, birthdayID)
+	if err != nil {
+		return fmt.Errorf("failed to delete override: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: no override on %d/%d", ErrNotFound, month, day)
+	}
+	return nil
+}
+
+// GetOverrides returns all of the guild's overrides in date order, the one for
+// everyone first on each date and then by name
+func (db *DB) GetOverrides(ctx context.Context, guildID string) ([]Override, error) {
+	query := overrideQuery + ` WHERE o.guild_id = ? ORDER BY o.month, o.day, b.name`
+	return db.queryOverrides(ctx, query, guildID)
+}
+
+// GetOverridesByDate returns the guild's overrides for a date
+func (db *DB) GetOverridesByDate(ctx context.Context, guildID string, month, day int) ([]Override, error) {
+	query := overrideQuery + ` WHERE o.guild_id = ? AND o.month = ? AND o.day = ? ORDER BY b.name`
+	return db.queryOverrides(ctx, query, guildID, month, day)
+}
+
+// queryOverrides runs a query built on overrideQuery
+func (db *DB) queryOverrides(ctx context.Context, query string, args ...interface{}) ([]Override, error) {
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query overrides: %w", err)
+	}
+	defer func() {
+		_ = rows.Close() // Best effort close
+	}()
+
+	var overrides []Override
+	for rows.Next() {
+		var o Override
+		var birthdayID sql.NullInt64
+		if err := rows.Scan(&o.GuildID, &o.Month, &o.Day, &birthdayID, &o.Name, &o.Message); err != nil {
+			return nil, fmt.Errorf("failed to scan override: %w", err)
+		}
+		if birthdayID.Valid {
+			id := int(birthdayID.Int64)
+			o.BirthdayID = &id
+		}
+		overrides = append(overrides, o)
+	}
+
+	return overrides, nil
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// Pronouns is the set of pronouns used to refer to a person. Possessive is the
+// form used before a noun, as in "wish them a happy birthday on their day".
+type Pronouns struct {
+	Subject    string
+	Object     string
+	Possessive string
+	Reflexive  string
+}
+
+// Pronoun forms, as named in templates
+const (
+	PronounSubject    = "subject"
+	PronounObject     = "object"
+	PronounPossessive = "possessive"
+	PronounReflexive  = "reflexive"
+)
+
+// Preset pronoun sets
+var (
+	HeHim    = Pronouns{Subject: "he", Object: "him", Possessive: "his", Reflexive: "himself"}
+	SheHer   = Pronouns{Subject: "she", Object: "her", Possessive: "her", Reflexive: "herself"}
+	TheyThem = Pronouns{Subject: "they", Object: "them", Possessive: "their", Reflexive: "themselves"}
+)
+
+// pronounPresets are the sets that can be given by their first one or two forms
+var pronounPresets = []Pronouns{HeHim, SheHer, TheyThem}
+
+// maxPronounLength is the most characters a single pronoun may have
+const maxPronounLength = 20
+
+// pronounSeparator separates the forms of a set, e.g. "xe/xem/xyr/xemself"
+const pronounSeparator = "/"
+
+// ParsePronouns reads a pronoun set. A preset is given by its subject form or
+// its subject and object forms ("she" or "she/her"); any other set is given by
+// all four forms in order: subject/object/possessive/reflexive. Errors wrap
+// ErrInvalidValue.
+func ParsePronouns(text string) (Pronouns, error) {
+	forms := strings.Split(text, pronounSeparator)
+	for i := range forms {
+		forms[i] = strings.TrimSpace(forms[i])
+		if forms[i] == "" {
+			return Pronouns{}, fmt.Errorf("%w: pronouns %q have an empty form", ErrInvalidValue, text)
+		}
+		if n := len([]rune(forms[i])); n > maxPronounLength {
+			return Pronouns{}, fmt.Errorf("%w: pronoun %q is %d characters long; the most allowed is %d", ErrInvalidValue, forms[i], n, maxPronounLength)
+		}
+	}
+
+	switch len(forms) {
+	case 1, 2:
+		for _, preset := range pronounPresets {
+			if strings.EqualFold(forms[0], preset.Subject) && (len(forms) == 1 || strings.EqualFold(forms[1], preset.Object)) {
+				return preset, nil
+			}
+		}
+	case 4:
+		return Pronouns{Subject: forms[0], Object: forms[1], Possessive: forms[2], Reflexive: forms[3]}, nil
+	}
+	return Pronouns{}, fmt.Errorf("%w: unknown pronouns %q: use he/him, she/her, they/them or all four forms, like xe/xem/xyr/xemself", ErrInvalidValue, text)
+}
+
+// GenderPronouns returns the pronoun set for one of the gender values used
+// before pronoun sets were stored: male, female, nonbinary or other
+func GenderPronouns(gender string) Pronouns {
+	switch gender {
+	case "male":
+		return HeHim
+	case "female":
+		return SheHer
+	default:
+		return TheyThem
+	}
+}
+
+// Form returns the pronoun in the named form, or false if there is no such form
+func (p Pronouns) Form(form string) (string, bool) {
+	switch form {
+	case PronounSubject:
+		return p.Subject, true
+	case PronounObject:
+		return p.Object, true
+	case PronounPossessive:
+		return p.Possessive, true
+	case PronounReflexive:
+		return p.Reflexive, true
+	default:
+		return "", false
+	}
+}
+
+// String returns all four forms, e.g. "they/them/their/themselves"
+func (p Pronouns) String() string {
+	return strings.Join([]string{p.Subject, p.Object, p.Possessive, p.Reflexive}, pronounSeparator)
+}
+
+// Value stores a pronoun set as its four forms
+func (p Pronouns) Value() (driver.Value, error) {
+	return p.String(), nil
+}
+
+// Scan reads a pronoun set stored by Value
+func (p *Pronouns) Scan(src any) error {
+	var text string
+	switch v := src.(type) {
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into pronouns", src)
+	}
+
+	forms := strings.Split(text, pronounSeparator)
+	if len(forms) != 4 {
+		return fmt.Errorf("stored pronouns %q do not have four forms", text)
+	}
+	*p = Pronouns{Subject: forms[0], Object: forms[1], Possessive: forms[2], Reflexive: forms[3]}
+	return nil
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// ReminderSettings are a guild's advance reminders of upcoming birthdays
+type ReminderSettings struct {
+	GuildID   string
+	ChannelID string // Organizers channel reminders are posted in; nothing is sent without one
+	RoleID    string // Role mentioned in reminders, or empty for none
+	Days      []int  // Days before a birthday to remind, furthest first; empty if reminders are off
+}
+
+// GetReminderSettings returns a guild's reminder settings, which have no days
+// if reminders have not been set up
+func (db *DB) GetReminderSettings(ctx context.Context, guildID string) (ReminderSettings, error) {
+	settings := ReminderSettings{GuildID: guildID}
+	query := `SELECT channel_id, role_id FROM reminder_settings WHERE guild_id = ?`
+	err := db.conn.QueryRowContext(ctx, query, guildID).Scan(&settings.ChannelID, &settings.RoleID)
+	if err == sql.ErrNoRows {
+		return settings, nil
+	}
+	if err != nil {
+		return ReminderSettings{}, fmt.Errorf("failed to get reminder settings: %w", err)
+	}
+
+	rows, err := db.conn.QueryContext(ctx, `SELECT days FROM reminder_days WHERE guild_id = ? ORDER BY days DESC`, guildID)
+	if err != nil {
+		return ReminderSettings{}, fmt.Errorf("failed to query reminder days: %w", err)
+	}
+	defer func() {
+		_ = rows.Close() // Best effort close
+	}()
+
+	for rows.Next() {
+		var days int
+		if err := rows.Scan(&days); err != nil {
+			return ReminderSettings{}, fmt.Errorf("failed to scan reminder days: %w", err)
+		}
+		settings.Days = append(settings.Days, days)
+	}
+
+	return settings, nil
+}
+
+// SetReminderSettings replaces a guild's reminder settings. Settings without
+// days turn reminders off.
+func (db *DB) SetReminderSettings(ctx context.Context, settings ReminderSettings) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // No-op after a successful commit
+	}()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM reminder_days WHERE guild_id = ?`, settings.GuildID); err != nil {
+		return fmt.Errorf("failed to delete reminder days: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM reminder_settings WHERE guild_id = ?`, settings.GuildID); err != nil {
+		return fmt.Errorf("failed to delete reminder settings: %w", err)
+	}
+
+	if len(settings.Days) > 0 {
+		query := `INSERT INTO reminder_settings (guild_id, channel_id, role_id) VALUES (?, ?, ?)`
+		if _, err := tx.ExecContext(ctx, query, settings.GuildID, settings.ChannelID, settings.RoleID); err != nil {
+			return fmt.Errorf("failed to save reminder settings: %w", err)
+		}
+		for _, days := range settings.Days {
+			query := `INSERT OR IGNORE INTO reminder_days (guild_id, days) VALUES (?, ?)`
+			if _, err := tx.ExecContext(ctx, query, settings.GuildID, days); err != nil {
+				return fmt.Errorf("failed to save reminder days: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit reminder settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Subscription asks for a DM the day before a birthday, for one person or for
+// everyone in the guild
+type Subscription struct {
+	GuildID    string
+	UserID     string    // Discord user who gets the DMs
+	BirthdayID *int      // Nil for every birthday in the guild
+	Name       string    // Name of the birthday, or empty for every birthday
+	Failure    string    // Why the last DM could not be sent, or empty if it was
+	FailedAt   time.Time // When the last DM failed, or zero
+}
+
+// subscriptionQuery selects the columns scanned by querySubscriptions. The
+// name is read from the birthday, so it follows renames.
+const subscriptionQuery = `SELECT s.guild_id, s.user_id, s.birthday_id, COALESCE(b.name, ''), s.failure, s.failed_at
+	FROM subscriptions s LEFT JOIN birthdays b ON b.id = s.birthday_id`
+
+// AddSubscription subscribes a user to a birthday, or to every birthday if
+// birthdayID is nil. Subscribing again changes nothing.
+func (db *DB) AddSubscription(ctx context.Context, guildID, userID string, birthdayID *int) error {
+	query := `INSERT OR IGNORE INTO subscriptions (guild_id, user_id, birthday_id) VALUES (?, ?, ?)`
+	if _, err := db.conn.ExecContext(ctx, query, guildID, userID, birthdayID); err != nil {
+		return fmt.Errorf("failed to save subscription: %w", err)
+	}
+	return nil
+}
+
+// DeleteSubscription unsubscribes a user from a birthday, or from every
+// birthday if birthdayID is nil
+func (db *DB) DeleteSubscription(ctx context.Context, guildID, userID string, birthdayID *int) error {
+	query := `DELETE FROM subscriptions WHERE guild_id = ? AND user_id = ? AND birthday_id IS ?`
+	result, err := db.conn.ExecContext(ctx, query, guildID, userID, birthdayID)
+	if err != nil {
+		return fmt.Errorf("failed to delete subscription: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: no such subscription", ErrNotFound)
+	}
+	return nil
+}
+
+// GetSubscriptions returns a user's subscriptions in the guild, the one for
+// every birthday first and then by name
+func (db *DB) GetSubscriptions(ctx context.Context, guildID, userID string) ([]Subscription, error) {
+	query := subscriptionQuery + ` WHERE s.guild_id = ? AND s.user_id = ? ORDER BY b.name`
+	return db.querySubscriptions(ctx, query, guildID, userID)
+}
+
+// GetGuildSubscriptions returns every subscription in the guild, by user
+func (db *DB) GetGuildSubscriptions(ctx context.Context, guildID string) ([]Subscription, error) {
+	query := subscriptionQuery + ` WHERE s.guild_id = ? ORDER BY s.user_id, b.name`
+	return db.querySubscriptions(ctx, query, guildID)
+}
+
+// SetSubscriptionFailure records why a DM to a user could not be sent on all
+// of their subscriptions in the guild. An empty failure records that the last
+// DM was sent.
+func (db *DB) SetSubscriptionFailure(ctx context.Context, guildID, userID, failure string, at time.Time) error {
+	failedAt := sql.NullTime{Time: at.UTC(), Valid: failure != ""}
+	query := `UPDATE subscriptions SET failure = ?, failed_at = ? WHERE guild_id = ? AND user_id = ?`
+	if _, err := db.conn.ExecContext(ctx, query, failure, failedAt, guildID, userID); err != nil {
+		return fmt.Errorf("failed to record subscription failure: %w", err)
+	}
+	return nil
+}
+
+// querySubscriptions runs a query built on subscriptionQuery
+func (db *DB) querySubscriptions(ctx context.Context, query string, args ...interface{}) ([]Subscription, error) {
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subscriptions: %w", err)
+	}
+	defer func() {
+		_ = rows.Close() // Best effort close
+	}()
+
+	var subscriptions []Subscription
+	for rows.Next() {
+		var s Subscription
+		var birthdayID sql.NullInt64
+		var failedAt sql.NullTime
+		if err := rows.Scan(&s.GuildID, &s.UserID, &birthdayID, &s.Name, &s.Failure, &failedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+		if birthdayID.Valid {
+			id := int(birthdayID.Int64)
+			s.BirthdayID = &id
+		}
+		s.FailedAt = failedAt.Time
+		subscriptions = append(subscriptions, s)
+	}
+
+	return subscriptions, nil
+}
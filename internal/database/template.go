@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetTemplates returns the guild's custom templates for a kind of announcement
+// in the order they were saved, or nil if it uses the defaults
+func (db *DB) GetTemplates(ctx context.Context, guildID, kind string) ([]string, error) {
+	query := `SELECT body FROM templates WHERE guild_id = ? AND kind = ? ORDER BY id`
+
+	rows, err := db.conn.QueryContext(ctx, query, guildID, kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query templates: %w", err)
+	}
+	defer func() {
+		_ = rows.Close() // Best effort close
+	}()
+
+	var templates []string
+	for rows.Next() {
+		var body string
+		if err := rows.Scan(&body); err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		templates = append(templates, body)
+	}
+
+	return templates, nil
+}
+
+// SetTemplates replaces the guild's templates for a kind of announcement. An
+// empty list resets it to the defaults.
+func (db *DB) SetTemplates(ctx context.Context, guildID, kind string, templates []string) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // No-op after a successful commit
+	}()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM templates WHERE guild_id = ? AND kind = ?`, guildID, kind); err != nil {
+		return fmt.Errorf("failed to delete templates: %w", err)
+	}
+	for _, body := range templates {
+		query := `INSERT INTO templates (guild_id, kind, body) VALUES (?, ?, ?)`
+		if _, err := tx.ExecContext(ctx, query, guildID, kind, body); err != nil {
+			return fmt.Errorf("failed to save template: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit templates: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+-- A database as created by the original bot, before versioned migrations
+
+CREATE TABLE IF NOT EXISTS birthdays (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    month INTEGER NOT NULL CHECK(month >= 1 AND month <= 12),
+    day INTEGER NOT NULL CHECK(day >= 1 AND day <= 31),
+    gender TEXT CHECK(gender IN ('male', 'female', 'nonbinary', 'other', NULL)),  -- For pronoun reference
+    discord_id TEXT,  -- Optional: link to Discord user
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(name)  -- One birthday per name
+);
+
+CREATE INDEX IF NOT EXISTS idx_birthdays_date ON birthdays(month, day);
+CREATE INDEX IF NOT EXISTS idx_birthdays_discord_id ON birthdays(discord_id);
+
+CREATE TRIGGER IF NOT EXISTS update_birthdays_timestamp
+AFTER UPDATE ON birthdays
+BEGIN
+    UPDATE birthdays SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
+
+INSERT INTO birthdays (name, month, day, gender, discord_id) VALUES
+    ('Alice', 1, 25, 'female', NULL),
+    ('Bob', 6, 10, 'male', '1234'),
+    ('Cassidy', 12, 2, 'nonbinary', NULL);
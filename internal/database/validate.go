@@ -0,0 +1,54 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxNameLength is the most characters a birthday's name may have, which keeps
+// every line of a birthday listing well within Discord's embed limits
+const MaxNameLength = 64
+
+// daysInMonth is the number of days in each month, allowing February 29 since
+// a birthday isn't tied to a particular year
+var daysInMonth = [...]int{31, 29, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+// ValidateDate checks that a month and day form a date that exists in some
+// year, returning an error wrapping ErrInvalidDate if they don't
+func ValidateDate(month, day int) error {
+	if month < 1 || month > 12 {
+		return fmt.Errorf("%w: month %d must be between 1 and 12", ErrInvalidDate, month)
+	}
+	if days := daysInMonth[month-1]; day < 1 || day > days {
+		return fmt.Errorf("%w: %s has %d days, got %d", ErrInvalidDate, time.Month(month), days, day)
+	}
+	return nil
+}
+
+// MinBirthYear is the earliest year of birth accepted, to catch typos
+const MinBirthYear = 1900
+
+// ValidateYear checks that an optional year of birth is between MinBirthYear
+// and the current year, returning an error wrapping ErrInvalidValue if it isn't
+func ValidateYear(year *int, now time.Time) error {
+	if year == nil {
+		return nil
+	}
+	if *year < MinBirthYear || *year > now.Year() {
+		return fmt.Errorf("%w: year must be between %d and %d, got %d", ErrInvalidValue, MinBirthYear, now.Year(), *year)
+	}
+	return nil
+}
+
+// ValidateName checks that a birthday's name is not empty or longer than
+// MaxNameLength, returning an error wrapping ErrInvalidValue if it is
+func ValidateName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%w: name must not be empty", ErrInvalidValue)
+	}
+	if n := len([]rune(name)); n > MaxNameLength {
+		return fmt.Errorf("%w: name is %d characters long; the most allowed is %d", ErrInvalidValue, n, MaxNameLength)
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/nrzaman/baos-birthday-bot/internal/birthday"
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+	"github.com/nrzaman/baos-birthday-bot/internal/render"
+)
+
+// handleBirthdayCommand dispatches the /birthday add, edit, remove and lookup
+// subcommands. Anyone may look a birthday up, but only members with the Manage
+// Server permission may change the list; everyone else saves their own with
+// /setmybirthday. Validation failures are reported back to the caller as
+// ephemeral replies.
+func (h *Handler) handleBirthdayCommand(ctx context.Context, interaction *discordgo.Interaction) {
+	data := interaction.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		h.respond(interaction, "Unknown command", true)
+		return
+	}
+
+	subcommand := data.Options[0]
+	options := optionMap(subcommand.Options)
+	if subcommand.Name == "lookup" {
+		// The name is optional when looking someone up
+		h.handleLookup(ctx, interaction, options)
+		return
+	}
+	if !canManageServer(interaction) {
+		h.respond(interaction, "❌ You need the Manage Server permission to change other people's birthdays. Use /setmybirthday to save your own.", true)
+		return
+	}
+	name := options["name"].StringValue()
+
+	switch subcommand.Name {
+	case "add":
+		fmt.Printf("Slash command: Adding birthday for %s.\n", name)
+		month, day := dateValues(options)
+		year, yearPrivate := yearValues(options)
+		pronouns, discordID, err := personValues(options)
+		if err == nil {
+			err = h.birthdayService.AddBirthday(ctx, interaction.GuildID, name, month, day, year, yearPrivate != nil && *yearPrivate, pronouns, discordID)
+		}
+		if err != nil {
+			h.respond(interaction, birthdayErrorMessage("add birthday", name, err), true)
+			return
+		}
+		h.respond(interaction, fmt.Sprintf("✅ Added **%s** on %s %d", name, time.Month(month), day), false)
+
+	case "edit":
+		fmt.Printf("Slash command: Editing birthday for %s.\n", name)
+		month, day := dateValues(options)
+		year, yearPrivate := yearValues(options)
+		pronouns, discordID, err := personValues(options)
+		if err == nil {
+			err = h.birthdayService.UpdateBirthday(ctx, interaction.GuildID, name, month, day, year, yearPrivate, pronouns, discordID)
+		}
+		if err != nil {
+			h.respond(interaction, birthdayErrorMessage("edit birthday", name, err), true)
+			return
+		}
+		h.respond(interaction, fmt.Sprintf("✅ Updated **%s** to %s %d", name, time.Month(month), day), false)
+
+	case "remove":
+		fmt.Printf("Slash command: Removing birthday for %s.\n", name)
+		if err := h.birthdayService.RemoveBirthday(ctx, interaction.GuildID, name); err != nil {
+			h.respond(interaction, birthdayErrorMessage("remove birthday", name, err), true)
+			return
+		}
+		h.respond(interaction, fmt.Sprintf("✅ Removed **%s**", name), false)
+
+	default:
+		h.respond(interaction, "Unknown command", true)
+	}
+}
+
+// handleLookup finds a birthday by the person's Discord account or, failing
+// that, by name, ignoring case and small typos
+func (h *Handler) handleLookup(ctx context.Context, interaction *discordgo.Interaction, options map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	if opt, ok := options["user"]; ok {
+		user := opt.UserValue(nil)
+		fmt.Printf("Slash command: Looking up birthday for user %s.\n", user.ID)
+		match, err := h.birthdayService.FindBirthdayByDiscordID(ctx, interaction.GuildID, user.ID)
+		if err != nil {
+			h.respondError(interaction, "look up the birthday", err)
+			return
+		}
+		if match == nil {
+			h.respond(interaction, fmt.Sprintf("❌ <@%s> hasn't saved a birthday in this server", user.ID), true)
+			return
+		}
+		h.respond(interaction, render.Lookup("<@"+user.ID+">", []birthday.Upcoming{*match}), false)
+		return
+	}
+
+	opt, ok := options["name"]
+	if !ok {
+		h.respond(interaction, "❌ Give a name or a user to look up", true)
+		return
+	}
+	name := opt.StringValue()
+	fmt.Printf("Slash command: Looking up birthday for %s.\n", name)
+	matches, err := h.birthdayService.FindBirthdays(ctx, interaction.GuildID, name)
+	if err != nil {
+		h.respondError(interaction, "look up the birthday", err)
+		return
+	}
+	if len(matches) == 0 {
+		h.respond(interaction, fmt.Sprintf("❌ No birthday found for **%s**", name), true)
+		return
+	}
+	h.respond(interaction, render.Lookup(name, matches), false)
+}
+
+// handleSetMyBirthday registers or changes the caller's own birthday, keyed on their Discord user ID
+func (h *Handler) handleSetMyBirthday(ctx context.Context, interaction *discordgo.Interaction) {
+	user := interactionUser(interaction)
+	if user == nil {
+		h.respond(interaction, "❌ Could not determine who you are", true)
+		return
+	}
+
+	options := optionMap(interaction.ApplicationCommandData().Options)
+	month, day := dateValues(options)
+	year, yearPrivate := yearValues(options)
+	pronouns, _, err := personValues(options)
+	if err != nil {
+		h.respond(interaction, errorMessage("save your birthday", err), true)
+		return
+	}
+
+	name := displayName(interaction)
+	if opt, ok := options["name"]; ok {
+		name = opt.StringValue()
+	}
+
+	fmt.Printf("Slash command: Setting birthday for user %s.\n", user.ID)
+	if err := h.birthdayService.UpsertByDiscordID(ctx, interaction.GuildID, user.ID, name, month, day, year, yearPrivate, pronouns); err != nil {
+		h.respond(interaction, birthdayErrorMessage("save your birthday", name, err), true)
+		return
+	}
+	h.respond(interaction, fmt.Sprintf("✅ Saved your birthday as %s %d", time.Month(month), day), true)
+}
+
+// handleForgetMe deletes the caller's own birthday
+func (h *Handler) handleForgetMe(ctx context.Context, interaction *discordgo.Interaction) {
+	user := interactionUser(interaction)
+	if user == nil {
+		h.respond(interaction, "❌ Could not determine who you are", true)
+		return
+	}
+
+	fmt.Printf("Slash command: Forgetting birthday for user %s.\n", user.ID)
+	err := h.birthdayService.RemoveByDiscordID(ctx, interaction.GuildID, user.ID)
+	if errors.Is(err, database.ErrNotFound) {
+		h.respond(interaction, "❌ You don't have a birthday saved in this server", true)
+		return
+	}
+	if err != nil {
+		h.respond(interaction, birthdayErrorMessage("delete your birthday", "", err), true)
+		return
+	}
+	h.respond(interaction, "✅ Your birthday has been deleted", true)
+}
+
+// birthdayErrorMessage explains to the caller why a change to the named
+// birthday failed
+func birthdayErrorMessage(action, name string, err error) string {
+	switch {
+	case errors.Is(err, database.ErrDuplicateName):
+		return fmt.Sprintf("❌ Could not %s: there is already a birthday for **%s**", action, name)
+	case errors.Is(err, database.ErrNotFound):
+		return fmt.Sprintf("❌ Could not %s: there is no birthday for **%s**", action, name)
+	default:
+		return errorMessage(action, err)
+	}
+}
+
+// interactionUser returns the user who invoked an interaction, whether in a guild or a DM
+func interactionUser(interaction *discordgo.Interaction) *discordgo.User {
+	if interaction.Member != nil && interaction.Member.User != nil {
+		return interaction.Member.User
+	}
+	return interaction.User
+}
+
+// displayName returns the caller's server nickname, falling back to their username
+func displayName(interaction *discordgo.Interaction) string {
+	if interaction.Member != nil && interaction.Member.Nick != "" {
+		return interaction.Member.Nick
+	}
+	if user := interactionUser(interaction); user != nil {
+		return user.Username
+	}
+	return ""
+}
+
+// optionMap indexes command options by name
+func optionMap(options []*discordgo.ApplicationCommandInteractionDataOption) map[string]*discordgo.ApplicationCommandInteractionDataOption {
+	m := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		m[opt.Name] = opt
+	}
+	return m
+}
+
+// dateValues reads the month and day options
+func dateValues(options map[string]*discordgo.ApplicationCommandInteractionDataOption) (int, int) {
+	return int(options["month"].IntValue()), int(options["day"].IntValue())
+}
+
+// yearValues reads the optional year and hide_age options, returning nil for any that were not given
+func yearValues(options map[string]*discordgo.ApplicationCommandInteractionDataOption) (year *int, yearPrivate *bool) {
+	if opt, ok := options["year"]; ok {
+		value := int(opt.IntValue())
+		year = &value
+	}
+	if opt, ok := options["hide_age"]; ok {
+		value := opt.BoolValue()
+		yearPrivate = &value
+	}
+	return year, yearPrivate
+}
+
+// personValues reads the optional pronouns and user options, returning nil
+// for any that were not given and an error if the pronouns can't be read
+func personValues(options map[string]*discordgo.ApplicationCommandInteractionDataOption) (pronouns *database.Pronouns, discordID *string, err error) {
+	if opt, ok := options["pronouns"]; ok {
+		value, err := database.ParsePronouns(opt.StringValue())
+		if err != nil {
+			return nil, nil, err
+		}
+		pronouns = &value
+	}
+	if opt, ok := options["user"]; ok {
+		id := opt.UserValue(nil).ID
+		discordID = &id
+	}
+	return pronouns, discordID, nil
+}
@@ -1,6 +1,14 @@
 package bot
 
-import "github.com/bwmarrin/discordgo"
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/nrzaman/baos-birthday-bot/internal/birthday"
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+	"github.com/nrzaman/baos-birthday-bot/internal/render"
+)
 
 // SlashCommands defines all the slash commands for the bot
 var SlashCommands = []*discordgo.ApplicationCommand{
@@ -14,10 +22,495 @@ var SlashCommands = []*discordgo.ApplicationCommand{
 	},
 	{
 		Name:        "next",
-		Description: "Show the next upcoming birthday",
+		Description: "Show the next upcoming birthdays",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "count",
+				Description: "How many birthdays to show (default 1)",
+				Required:    false,
+				MinValue:    &minNextCount,
+				MaxValue:    maxNextCount,
+			},
+		},
+	},
+	{
+		Name:        "upcoming",
+		Description: "List the birthdays coming up in the next few days",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "days",
+				Description: fmt.Sprintf("How many days ahead to look (default %d)", defaultUpcomingDays),
+				Required:    false,
+				MinValue:    &minUpcomingDays,
+				MaxValue:    maxUpcomingDays,
+			},
+		},
+	},
+	{
+		Name:        "birthday",
+		Description: "Manage the birthday list",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Add a new birthday (Manage Server)",
+				Options:     birthdayOptions("Name of the person to add"),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "edit",
+				Description: "Change an existing birthday (Manage Server)",
+				Options:     birthdayOptions("Name of the person to edit"),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Remove a birthday (Manage Server)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Name of the person to remove",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "lookup",
+				Description: "Find someone's birthday",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Name of the person, or part of it",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "user",
+						Description: "Discord account of the person",
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:        "setmybirthday",
+		Description: "Register or change your own birthday",
+		Options: append(append(dateOptions(), yearOptions()...),
+			pronounsOption(),
+			&discordgo.ApplicationCommandOption{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "name",
+				Description: "Name to show in birthday messages (defaults to your server nickname)",
+				MaxLength:   database.MaxNameLength,
+			},
+		),
+	},
+	{
+		Name:        "forgetme",
+		Description: "Delete your own birthday",
+	},
+	{
+		Name:                     "config",
+		Description:              "Configure birthday announcements for this server",
+		DefaultMemberPermissions: &manageServerPermission,
+		DMPermission:             &dmPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "channel",
+				Description: "Set the channel announcements are posted in",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Announcement channel",
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "time",
+				Description: "Set the time of day announcements are posted",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "time",
+						Description: "24-hour time in the server's timezone, e.g. 08:30",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "timezone",
+				Description: "Set the server's timezone",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "timezone",
+						Description: "IANA timezone name, e.g. Europe/London",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "leapday",
+				Description: "Choose when February 29 birthdays are celebrated in non-leap years",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "celebrate",
+						Description: "Day to celebrate on",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "February 28", Value: database.LeapDayFeb28},
+							{Name: "March 1", Value: database.LeapDayMar1},
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "show",
+				Description: "Show the current announcement settings",
+			},
+		},
+	},
+	{
+		Name:                     "history",
+		Description:              "Show the announcements recently posted in this server",
+		DefaultMemberPermissions: &manageServerPermission,
+		DMPermission:             &dmPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "limit",
+				Description: fmt.Sprintf("How many announcements to show (default %d)", defaultHistoryLimit),
+				Required:    false,
+				MinValue:    &minHistoryLimit,
+				MaxValue:    maxHistoryLimit,
+			},
+		},
+	},
+	{
+		Name:                     "template",
+		Description:              "Change the wording of birthday announcements in this server",
+		DefaultMemberPermissions: &manageServerPermission,
+		DMPermission:             &dmPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			templateSubcommand("set", "Save a template, with up to 4 more variants, one used each day",
+				templateTextOption("text", "Template, e.g. Happy birthday {{mention}}! 🎉", true),
+				templateTextOption("text2", "Another variant", false),
+				templateTextOption("text3", "Another variant", false),
+				templateTextOption("text4", "Another variant", false),
+				templateTextOption("text5", "Another variant", false),
+			),
+			templateSubcommand("preview", "Preview a template, or the current ones, against a sample person",
+				templateTextOption("text", "Template to try (defaults to the current ones)", false),
+			),
+			templateSubcommand("reset", "Go back to the default template"),
+		},
+	},
+	{
+		Name:                     "override",
+		Description:              "Post a custom message instead of the usual one on special dates",
+		DefaultMemberPermissions: &manageServerPermission,
+		DMPermission:             &dmPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set",
+				Description: "Set the message for a date, for one person or for everyone celebrating on it",
+				Options: append(overrideDateOptions(),
+					templateTextOption("message", "Message, a template like /template uses", true),
+					overrideNameOption(),
+				),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Remove the message for a date",
+				Options:     append(overrideDateOptions(), overrideNameOption()),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List the custom messages",
+			},
+		},
+	},
+	{
+		Name:                     "reminders",
+		Description:              "Remind organizers of birthdays some days ahead",
+		DefaultMemberPermissions: &manageServerPermission,
+		DMPermission:             &dmPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set",
+				Description: "Choose when to remind, in which channel and which role",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "days",
+						Description: fmt.Sprintf("Days before each birthday, e.g. 7, 1 (up to %d, at most %d days)", birthday.MaxReminders, birthday.MaxReminderDays),
+						Required:    true,
+					},
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Organizers channel, which the people having a birthday shouldn't see",
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+						Required:     true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "role",
+						Description: "Organizers role to mention, except in reminders of its own members' birthdays",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "off",
+				Description: "Stop sending reminders",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "show",
+				Description: "Show the reminder settings",
+			},
+		},
+	},
+	{
+		Name:         "subscribe",
+		Description:  "Get a DM the day before a birthday",
+		DMPermission: &dmPermission,
+		Options:      subscriptionSubcommands("Get a DM the day before one person's birthday", "Get a DM the day before every birthday in this server"),
+	},
+	{
+		Name:         "unsubscribe",
+		Description:  "Stop getting birthday DMs",
+		DMPermission: &dmPermission,
+		Options:      subscriptionSubcommands("Stop getting DMs about one person's birthday", "Stop getting DMs about every birthday in this server"),
+	},
+	{
+		Name:         "subscriptions",
+		Description:  "Show the birthdays you get a DM about",
+		DMPermission: &dmPermission,
 	},
 }
 
+// subscriptionSubcommands returns the person and all subcommands of
+// /subscribe and /unsubscribe
+func subscriptionSubcommands(personDescription, allDescription string) []*discordgo.ApplicationCommandOption {
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "person",
+			Description: personDescription,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "name",
+					Description: "Name of the person",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "Discord account of the person",
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "all",
+			Description: allDescription,
+		},
+	}
+}
+
+// templateSubcommand returns a /template subcommand with a kind option
+// followed by the given options
+func templateSubcommand(name, description string, options ...*discordgo.ApplicationCommandOption) *discordgo.ApplicationCommandOption {
+	kind := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "kind",
+		Description: "Which announcement",
+		Required:    true,
+		Choices: []*discordgo.ApplicationCommandOptionChoice{
+			{Name: "Birthday message", Value: database.AnnouncementBirthday},
+			{Name: "Monthly roundup greeting", Value: database.AnnouncementMonthly},
+		},
+	}
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommand,
+		Name:        name,
+		Description: description,
+		Options:     append([]*discordgo.ApplicationCommandOption{kind}, options...),
+	}
+}
+
+// templateTextOption returns an option holding template text
+func templateTextOption(name, description string, required bool) *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        name,
+		Description: description,
+		Required:    required,
+		MaxLength:   render.MaxTemplateLength,
+	}
+}
+
+// overrideDateOptions returns the required month and day of a special date
+func overrideDateOptions() []*discordgo.ApplicationCommandOption {
+	minDay := float64(1)
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "month",
+			Description: "Month of the special date",
+			Required:    true,
+			Choices:     monthChoices(),
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "day",
+			Description: "Day of the special date",
+			Required:    true,
+			MinValue:    &minDay,
+			MaxValue:    31,
+		},
+	}
+}
+
+// overrideNameOption returns the option naming whose birthday an override is for
+func overrideNameOption() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "name",
+		Description: "Name of the person (defaults to everyone celebrating on the date)",
+	}
+}
+
+// Bounds for the number of announcements /history shows
+const (
+	defaultHistoryLimit = 10
+	maxHistoryLimit     = 50
+)
+
+var minHistoryLimit float64 = 1
+
+// Bounds for the number of birthdays /next shows
+const maxNextCount = 25
+
+var minNextCount float64 = 1
+
+// Bounds for how many days ahead /upcoming looks
+const (
+	defaultUpcomingDays = 7
+	maxUpcomingDays     = 366
+)
+
+var minUpcomingDays float64 = 1
+
+// manageServerPermission limits admin commands to members who can manage the server
+var manageServerPermission int64 = discordgo.PermissionManageServer
+
+// dmPermission hides server-only commands from DMs
+var dmPermission = false
+
+// birthdayOptions returns the options shared by the add and edit subcommands
+func birthdayOptions(nameDescription string) []*discordgo.ApplicationCommandOption {
+	options := []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "name",
+			Description: nameDescription,
+			Required:    true,
+			MaxLength:   database.MaxNameLength,
+		},
+	}
+	options = append(options, dateOptions()...)
+	options = append(options, yearOptions()...)
+	return append(options,
+		pronounsOption(),
+		&discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "user",
+			Description: "Discord account of the person",
+		},
+	)
+}
+
+// dateOptions returns the required month and day options
+func dateOptions() []*discordgo.ApplicationCommandOption {
+	minDay := float64(1)
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "month",
+			Description: "Birthday month",
+			Required:    true,
+			Choices:     monthChoices(),
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "day",
+			Description: "Birthday day of the month",
+			Required:    true,
+			MinValue:    &minDay,
+			MaxValue:    31,
+		},
+	}
+}
+
+// yearOptions returns the optional year of birth and the option to keep it private
+func yearOptions() []*discordgo.ApplicationCommandOption {
+	minYear := float64(1900)
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "year",
+			Description: "Year of birth, used to announce ages",
+			MinValue:    &minYear,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionBoolean,
+			Name:        "hide_age",
+			Description: "Keep the year private and leave the age out of birthday messages",
+		},
+	}
+}
+
+// pronounsOption returns the optional pronouns option used in birthday messages
+func pronounsOption() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "pronouns",
+		Description: "he/him, she/her, they/them, or all four forms like xe/xem/xyr/xemself",
+	}
+}
+
+// monthChoices returns one option choice per month, valued 1 through 12
+func monthChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, 12)
+	for m := time.January; m <= time.December; m++ {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  m.String(),
+			Value: int(m),
+		})
+	}
+	return choices
+}
+
 // RegisterCommands registers slash commands with Discord
 func RegisterCommands(session *discordgo.Session, guildID string) error {
 	for _, cmd := range SlashCommands {
@@ -0,0 +1,106 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+	"github.com/nrzaman/baos-birthday-bot/internal/render"
+)
+
+// Rescheduler is notified when a guild's announcement settings change so the
+// next announcement can be rescheduled without a restart
+type Rescheduler interface {
+	Reschedule()
+}
+
+// SetRescheduler sets who is notified when /config changes announcement settings
+func (h *Handler) SetRescheduler(rescheduler Rescheduler) {
+	h.rescheduler = rescheduler
+}
+
+// handleConfigCommand dispatches the /config subcommands. Only members with the
+// Manage Server permission may use them.
+func (h *Handler) handleConfigCommand(ctx context.Context, interaction *discordgo.Interaction) {
+	if !canManageServer(interaction) {
+		h.respond(interaction, "❌ You need the Manage Server permission to change birthday settings.", true)
+		return
+	}
+
+	data := interaction.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		h.respond(interaction, "Unknown command", true)
+		return
+	}
+
+	subcommand := data.Options[0]
+	options := optionMap(subcommand.Options)
+	guildID := interaction.GuildID
+
+	var err error
+	var response string
+	switch subcommand.Name {
+	case "channel":
+		channelID := options["channel"].ChannelValue(nil).ID
+		fmt.Printf("Slash command: Setting announcement channel for guild %s.\n", guildID)
+		err = h.birthdayService.SetAnnouncementChannel(ctx, guildID, channelID)
+		response = fmt.Sprintf("✅ Announcements will be posted in <#%s>", channelID)
+
+	case "time":
+		value := options["time"].StringValue()
+		fmt.Printf("Slash command: Setting announcement time for guild %s.\n", guildID)
+		parsed, parseErr := time.Parse("15:04", value)
+		if parseErr != nil {
+			err = fmt.Errorf("%w: %q is not a 24-hour HH:MM time, e.g. 08:30", database.ErrInvalidValue, value)
+			break
+		}
+		err = h.birthdayService.SetAnnouncementTime(ctx, guildID, parsed.Hour(), parsed.Minute())
+		response = fmt.Sprintf("✅ Announcements will be posted at %s", parsed.Format("15:04"))
+
+	case "timezone":
+		timezone := options["timezone"].StringValue()
+		fmt.Printf("Slash command: Setting timezone for guild %s.\n", guildID)
+		err = h.birthdayService.SetTimezone(ctx, guildID, timezone)
+		response = fmt.Sprintf("✅ Timezone set to %s", timezone)
+
+	case "leapday":
+		policy := options["celebrate"].StringValue()
+		fmt.Printf("Slash command: Setting leap day policy for guild %s.\n", guildID)
+		err = h.birthdayService.SetLeapDayPolicy(ctx, guildID, policy)
+		response = fmt.Sprintf("✅ February 29 birthdays will be celebrated on %s in non-leap years", render.LeapDayName(policy))
+
+	case "show":
+		settings, showErr := h.birthdayService.GetGuildSettings(ctx, guildID)
+		if showErr != nil {
+			h.respondError(interaction, "get the settings", showErr)
+			return
+		}
+		h.respond(interaction, render.Settings(settings), true)
+		return
+
+	default:
+		h.respond(interaction, "Unknown command", true)
+		return
+	}
+
+	if err != nil {
+		h.respondError(interaction, "update settings", err)
+		return
+	}
+
+	if h.rescheduler != nil {
+		h.rescheduler.Reschedule()
+	}
+	h.respond(interaction, response, true)
+}
+
+// canManageServer reports whether the caller has the Manage Server (or Administrator) permission
+func canManageServer(interaction *discordgo.Interaction) bool {
+	if interaction.Member == nil {
+		return false
+	}
+	permissions := interaction.Member.Permissions
+	return permissions&discordgo.PermissionManageServer != 0 || permissions&discordgo.PermissionAdministrator != 0
+}
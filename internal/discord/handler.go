@@ -1,142 +1,198 @@
 package bot
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"sort"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/nrzaman/baos-birthday-bot/internal/birthday"
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
 	"github.com/nrzaman/baos-birthday-bot/internal/interfaces"
+	"github.com/nrzaman/baos-birthday-bot/internal/render"
 )
 
 // Handler handles Discord message events with injected dependencies
 type Handler struct {
 	client          interfaces.DiscordClient
 	birthdayService birthday.BirthdayService
+	rescheduler     Rescheduler
+	renderer        render.Renderer
 }
 
-// NewHandler creates a new Handler with the given dependencies
+// NewHandler creates a new Handler with the given dependencies. Listings are
+// rendered as embeds.
 func NewHandler(client interfaces.DiscordClient, birthdayService birthday.BirthdayService) *Handler {
 	return &Handler{
 		client:          client,
 		birthdayService: birthdayService,
+		renderer:        render.Embeds{},
 	}
 }
 
+// SetRenderer changes how listings are rendered
+func (h *Handler) SetRenderer(renderer render.Renderer) {
+	h.renderer = renderer
+}
+
+// commandTimeout bounds the work done for a slash command, since Discord drops
+// replies that take longer than three seconds
+const commandTimeout = 2500 * time.Millisecond
+
 // HandleSlashCommand processes slash command interactions
 func (h *Handler) HandleSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
 	commandName := i.ApplicationCommandData().Name
 
-	var response string
+	// Birthdays are stored per server, so there is nothing to show in a DM
+	if i.GuildID == "" {
+		h.respond(i.Interaction, "Birthday commands only work inside a server.", true)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	var response render.Message
 	switch commandName {
 	case "month":
 		fmt.Println("Slash command: Listing the current month's birthdays.")
-		response = h.birthdayService.ListCurrentMonthBirthdays()
-		if response == "" {
-			response = "No birthdays this month!"
+		group, err := h.birthdayService.GetMonthBirthdays(ctx, i.GuildID)
+		if err != nil {
+			h.respondError(i.Interaction, "list this month's birthdays", err)
+			return
+		}
+		if len(group.Celebrations) == 0 {
+			response = render.Text("No birthdays this month!")
+		} else {
+			response = h.renderer.MonthBirthdays(group)
 		}
 
 	case "all":
 		fmt.Println("Slash command: Listing all birthdays.")
-		response = h.birthdayService.ListAllBirthdays()
-		if response == "" {
-			response = "No birthdays configured!"
+		message, err := h.allBirthdaysPage(ctx, i.GuildID, 0)
+		if err != nil {
+			h.respondError(i.Interaction, "list birthdays", err)
+			return
 		}
+		response = message
 
 	case "next":
-		fmt.Println("Slash command: Finding next birthday.")
-		response = h.getNextBirthday()
-		if response == "" {
-			response = "No upcoming birthdays found!"
-		}
+		h.handleNextCommand(ctx, i.Interaction)
+		return
 
-	default:
-		response = "Unknown command"
-	}
+	case "upcoming":
+		h.handleUpcomingCommand(ctx, i.Interaction)
+		return
 
-	// Respond to the interaction
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: response,
-		},
-	})
-	if err != nil {
-		fmt.Printf("Error responding to slash command: %v\n", err)
-	}
-}
+	case "birthday":
+		h.handleBirthdayCommand(ctx, i.Interaction)
+		return
 
-// getNextBirthday finds and returns the next upcoming birthday
-func (h *Handler) getNextBirthday() string {
-	birthdays := h.birthdayService.GetBirthdays()
-	if len(birthdays.People) == 0 {
-		return ""
-	}
+	case "setmybirthday":
+		h.handleSetMyBirthday(ctx, i.Interaction)
+		return
 
-	now := time.Now()
-	type bdayWithDate struct {
-		name      string
-		month     time.Month
-		day       int
-		daysUntil int
-	}
+	case "forgetme":
+		h.handleForgetMe(ctx, i.Interaction)
+		return
 
-	var upcoming []bdayWithDate
+	case "config":
+		h.handleConfigCommand(ctx, i.Interaction)
+		return
 
-	for _, person := range birthdays.People {
-		month := time.Month(person.Birthday.Month)
-		day := person.Birthday.Day
+	case "history":
+		h.handleHistoryCommand(ctx, i.Interaction)
+		return
 
-		// Calculate this year's birthday
-		thisYear := time.Date(now.Year(), month, day, 0, 0, 0, 0, now.Location())
+	case "template":
+		h.handleTemplateCommand(ctx, i.Interaction)
+		return
 
-		var daysUntil int
-		if thisYear.Before(now) {
-			// Birthday already passed this year, calculate for next year
-			nextYear := time.Date(now.Year()+1, month, day, 0, 0, 0, 0, now.Location())
-			daysUntil = int(nextYear.Sub(now).Hours() / 24)
-		} else {
-			daysUntil = int(thisYear.Sub(now).Hours() / 24)
-		}
+	case "override":
+		h.handleOverrideCommand(ctx, i.Interaction)
+		return
+
+	case "reminders":
+		h.handleRemindersCommand(ctx, i.Interaction)
+		return
+
+	case "subscribe":
+		h.handleSubscribeCommand(ctx, i.Interaction, true)
+		return
 
-		upcoming = append(upcoming, bdayWithDate{
-			name:      person.Name,
-			month:     month,
-			day:       day,
-			daysUntil: daysUntil,
-		})
+	case "unsubscribe":
+		h.handleSubscribeCommand(ctx, i.Interaction, false)
+		return
+
+	case "subscriptions":
+		h.handleSubscriptionsCommand(ctx, i.Interaction)
+		return
+
+	default:
+		response = render.Text("Unknown command")
 	}
 
-	// Sort by days until birthday
-	sort.Slice(upcoming, func(i, j int) bool {
-		return upcoming[i].daysUntil < upcoming[j].daysUntil
-	})
+	h.respondMessage(i.Interaction, response, false)
+}
 
-	// Get the next birthday (or multiple if on same day)
-	next := upcoming[0]
-	result := fmt.Sprintf("**Next birthday:** %s on %s %d", next.name, next.month.String(), next.day)
+// respond replies to an interaction with text, optionally only visible to the caller
+func (h *Handler) respond(interaction *discordgo.Interaction, content string, ephemeral bool) {
+	h.respondMessage(interaction, render.Text(content), ephemeral)
+}
 
-	if next.daysUntil == 0 {
-		result += " (Today! 🎉)"
-	} else if next.daysUntil == 1 {
-		result += " (Tomorrow!)"
-	} else {
-		result += fmt.Sprintf(" (in %d days!)", next.daysUntil)
+// respondMessage replies to an interaction with a rendered message, optionally only visible to the caller
+func (h *Handler) respondMessage(interaction *discordgo.Interaction, message render.Message, ephemeral bool) {
+	data := &discordgo.InteractionResponseData{
+		Content:    message.Content,
+		Embeds:     message.Embeds,
+		Components: message.Components,
+	}
+	if ephemeral {
+		data.Flags = discordgo.MessageFlagsEphemeral
 	}
 
-	// Check if there are multiple birthdays on the same day
-	for i := 1; i < len(upcoming) && upcoming[i].daysUntil == next.daysUntil; i++ {
-		result += fmt.Sprintf("\nAlso: %s", upcoming[i].name)
+	// Long replies are continued in follow-up messages
+	err := interfaces.RespondChunked(h.client, interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	})
+	if err != nil {
+		fmt.Printf("Error responding to slash command: %v\n", err)
 	}
+}
+
+// respondError tells only the caller that an action failed
+func (h *Handler) respondError(interaction *discordgo.Interaction, action string, err error) {
+	h.respond(interaction, errorMessage(action, err), true)
+}
 
-	return result
+// errorMessage explains why an action failed. Problems with the request are
+// described; storage failures are logged and reported without details.
+func errorMessage(action string, err error) string {
+	if errors.Is(err, database.ErrInvalidDate) || errors.Is(err, database.ErrInvalidValue) {
+		return fmt.Sprintf("❌ Could not %s: %v", action, err)
+	}
+	fmt.Printf("Error trying to %s: %v\n", action, err)
+	return fmt.Sprintf("❌ Could not %s because of a problem on our side. Please try again later.", action)
 }
 
-// SendBirthdayMessage sends a birthday message to the specified channel
-func (h *Handler) SendBirthdayMessage(channelID string, message string) error {
+// SendBirthdayMessage sends a birthday message to the specified channel. Only the
+// given users are notified by mentions in the message.
+func (h *Handler) SendBirthdayMessage(channelID string, message string, mentionIDs ...string) error {
 	if len(message) == 0 {
 		return nil
 	}
-	return h.client.SendMessage(channelID, message)
+	return sendWithMentions(h.client, channelID, message, mentionIDs)
+}
+
+// sendWithMentions sends a message whose mentions notify exactly the given users
+func sendWithMentions(client interfaces.DiscordClient, channelID string, message string, mentionIDs []string) error {
+	return interfaces.SendChunked(client, channelID, &discordgo.MessageSend{
+		Content:         message,
+		AllowedMentions: interfaces.UserMentions(mentionIDs),
+	})
 }
@@ -1,22 +1,41 @@
 package bot_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/bwmarrin/discordgo"
+	"github.com/nrzaman/baos-birthday-bot/internal/birthday"
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
 	bot "github.com/nrzaman/baos-birthday-bot/internal/discord"
-	"github.com/nrzaman/baos-birthday-bot/util"
+	"github.com/nrzaman/baos-birthday-bot/internal/interfaces"
+	"github.com/nrzaman/baos-birthday-bot/internal/render"
 )
 
 // MockDiscordClient is a mock implementation of DiscordClient for testing
 type MockDiscordClient struct {
 	SentMessages []SentMessage
+	Responses    []*discordgo.InteractionResponse
+	Followups    []*discordgo.WebhookParams
 	SendError    error
+
+	// FailAt makes the nth call to SendComplexMessage, counting from 1, fail once
+	FailAt       int
+	complexSends int
+
+	// MemberRoles are the roles of guild members by user ID
+	MemberRoles map[string][]string
 }
 
 type SentMessage struct {
 	ChannelID string
 	Message   string
+	Embeds    []*discordgo.MessageEmbed
+	Mentions  *discordgo.MessageAllowedMentions
 }
 
 func (m *MockDiscordClient) SendMessage(channelID string, message string) error {
@@ -30,6 +49,73 @@ func (m *MockDiscordClient) SendMessage(channelID string, message string) error
 	return nil
 }
 
+func (m *MockDiscordClient) SendComplexMessage(channelID string, data *discordgo.MessageSend) error {
+	m.complexSends++
+	if m.SendError != nil {
+		return m.SendError
+	}
+	if m.complexSends == m.FailAt {
+		return errors.New("discord unavailable")
+	}
+	m.SentMessages = append(m.SentMessages, SentMessage{
+		ChannelID: channelID,
+		Message:   data.Content,
+		Embeds:    data.Embeds,
+		Mentions:  data.AllowedMentions,
+	})
+	return nil
+}
+
+func (m *MockDiscordClient) SendEmbeds(channelID string, content string, embeds []*discordgo.MessageEmbed, mentionIDs []string) error {
+	if m.SendError != nil {
+		return m.SendError
+	}
+	m.SentMessages = append(m.SentMessages, SentMessage{
+		ChannelID: channelID,
+		Message:   content,
+		Embeds:    embeds,
+		Mentions:  interfaces.UserMentions(mentionIDs),
+	})
+	return nil
+}
+
+// User returns a user whose avatar hash is "avatar-" followed by their ID
+func (m *MockDiscordClient) User(userID string) (*discordgo.User, error) {
+	return &discordgo.User{ID: userID, Avatar: "avatar-" + userID}, nil
+}
+
+func (m *MockDiscordClient) GuildMember(guildID, userID string) (*discordgo.Member, error) {
+	return &discordgo.Member{GuildID: guildID, User: &discordgo.User{ID: userID}, Roles: m.MemberRoles[userID]}, nil
+}
+
+func (m *MockDiscordClient) InteractionRespond(interaction *discordgo.Interaction, response *discordgo.InteractionResponse) error {
+	if m.SendError != nil {
+		return m.SendError
+	}
+	m.Responses = append(m.Responses, response)
+	return nil
+}
+
+func (m *MockDiscordClient) FollowupMessage(interaction *discordgo.Interaction, params *discordgo.WebhookParams) error {
+	if m.SendError != nil {
+		return m.SendError
+	}
+	m.Followups = append(m.Followups, params)
+	return nil
+}
+
+// SendDirectMessage records a DM as a message sent to the channel "dm-" followed by the user ID
+func (m *MockDiscordClient) SendDirectMessage(userID string, message string) error {
+	if m.SendError != nil {
+		return m.SendError
+	}
+	m.SentMessages = append(m.SentMessages, SentMessage{
+		ChannelID: "dm-" + userID,
+		Message:   message,
+	})
+	return nil
+}
+
 func (m *MockDiscordClient) AddHandler(handler interface{}) {
 	// No-op for testing
 }
@@ -55,46 +141,561 @@ func (m *MockTimeProvider) Day() int {
 	return m.CurrentTime.Day()
 }
 
+func (m *MockTimeProvider) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
 // MockBirthdayService for testing
 type MockBirthdayService struct {
-	BirthdayMessage       string
-	CurrentMonthBirthdays string
-	AllBirthdays          string
-	Birthdays             []struct {
-		Name  string
-		Month int
-		Day   int
-	}
+	Birthdays     []database.Birthday
+	Announcements []database.Announcement
+	Calls         []string
+	CallError     error
+
+	// ReadError is returned by the methods that list birthdays
+	ReadError error
+
+	// CurrentTime and LeapDayPolicy override the defaults returned by Now and GetGuildSettings
+	CurrentTime   time.Time
+	LeapDayPolicy string
+
+	// Templates are the saved templates by kind of announcement
+	Templates map[string][]string
+
+	// Overrides are the guild's custom messages
+	Overrides []database.Override
+
+	// Reminders are the guild's reminder settings
+	Reminders database.ReminderSettings
+
+	// Subscriptions are the caller's subscriptions, and Notifications the DMs due today
+	Subscriptions []database.Subscription
+	Notifications []birthday.Notification
+
+	// Delivered records each RecordNotification as "user: failure"
+	Delivered []string
 }
 
 func (m *MockBirthdayService) IsBirthdayToday(month int, day int) bool {
 	return false
 }
 
-func (m *MockBirthdayService) GetBirthdayMessage() string {
-	return m.BirthdayMessage
+func (m *MockBirthdayService) Now(ctx context.Context, guildID string) (time.Time, error) {
+	if !m.CurrentTime.IsZero() {
+		return m.CurrentTime, m.ReadError
+	}
+	return time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC), m.ReadError
+}
+
+func (m *MockBirthdayService) GetBirthdaysToday(ctx context.Context, guildID string) ([]database.Birthday, error) {
+	return nil, m.ReadError
 }
 
-func (m *MockBirthdayService) ListCurrentMonthBirthdays() string {
-	return m.CurrentMonthBirthdays
+func (m *MockBirthdayService) GetMonthBirthdays(ctx context.Context, guildID string) (birthday.MonthGroup, error) {
+	groups, err := m.GetBirthdaysByMonth(ctx, guildID)
+	if err != nil {
+		return birthday.MonthGroup{}, err
+	}
+	now, _ := m.Now(ctx, guildID)
+	for _, group := range groups {
+		if group.Month == now.Month() {
+			return group, nil
+		}
+	}
+	return birthday.MonthGroup{Month: now.Month()}, nil
 }
 
-func (m *MockBirthdayService) ListAllBirthdays() string {
-	return m.AllBirthdays
+func (m *MockBirthdayService) GetBirthdaysByMonth(ctx context.Context, guildID string) ([]birthday.MonthGroup, error) {
+	if m.ReadError != nil {
+		return nil, m.ReadError
+	}
+	now, _ := m.Now(ctx, guildID)
+	return birthday.GroupByMonth(m.Birthdays, now, m.LeapDayPolicy), nil
 }
 
-func (m *MockBirthdayService) GetBirthdays() util.People {
-	people := make([]util.Person, len(m.Birthdays))
-	for i, b := range m.Birthdays {
-		people[i] = util.Person{
-			Name: b.Name,
-			Birthday: util.Birthday{
-				Month: b.Month,
-				Day:   b.Day,
-			},
+func (m *MockBirthdayService) GetAllBirthdays(ctx context.Context, guildID string) ([]database.Birthday, error) {
+	if m.ReadError != nil {
+		return nil, m.ReadError
+	}
+	return m.Birthdays, nil
+}
+
+func (m *MockBirthdayService) GetNextBirthdays(ctx context.Context, guildID string, count int) ([]birthday.Upcoming, error) {
+	upcoming, err := m.upcoming(ctx, guildID)
+	if err != nil || count >= len(upcoming) {
+		return upcoming, err
+	}
+	return upcoming[:count], nil
+}
+
+func (m *MockBirthdayService) GetUpcomingBirthdays(ctx context.Context, guildID string, days int) ([]birthday.Upcoming, error) {
+	upcoming, err := m.upcoming(ctx, guildID)
+	var within []birthday.Upcoming
+	for _, u := range upcoming {
+		if u.DaysUntil <= days {
+			within = append(within, u)
+		}
+	}
+	return within, err
+}
+
+// upcoming returns the next celebration of every birthday, soonest first
+func (m *MockBirthdayService) upcoming(ctx context.Context, guildID string) ([]birthday.Upcoming, error) {
+	if m.ReadError != nil {
+		return nil, m.ReadError
+	}
+	now, _ := m.Now(ctx, guildID)
+	return birthday.UpcomingBirthdays(m.Birthdays, now, m.LeapDayPolicy), nil
+}
+
+func (m *MockBirthdayService) FindBirthdays(ctx context.Context, guildID, name string) ([]birthday.Upcoming, error) {
+	upcoming, err := m.upcoming(ctx, guildID)
+	return birthday.MatchName(upcoming, name), err
+}
+
+func (m *MockBirthdayService) FindBirthdayByDiscordID(ctx context.Context, guildID, discordID string) (*birthday.Upcoming, error) {
+	upcoming, err := m.upcoming(ctx, guildID)
+	for i := range upcoming {
+		if id := upcoming[i].DiscordID; id != nil && *id == discordID {
+			return &upcoming[i], err
+		}
+	}
+	return nil, err
+}
+
+func (m *MockBirthdayService) AddBirthday(ctx context.Context, guildID, name string, month, day int, year *int, yearPrivate bool, pronouns *database.Pronouns, discordID *string) error {
+	m.Calls = append(m.Calls, "add "+name+yearCall(year, &yearPrivate)+pronounsCall(pronouns))
+	return m.CallError
+}
+
+func (m *MockBirthdayService) UpdateBirthday(ctx context.Context, guildID, name string, month, day int, year *int, yearPrivate *bool, pronouns *database.Pronouns, discordID *string) error {
+	m.Calls = append(m.Calls, "edit "+name+yearCall(year, yearPrivate)+pronounsCall(pronouns))
+	return m.CallError
+}
+
+// yearCall describes the year arguments of a call, or returns "" if no year was given
+func yearCall(year *int, yearPrivate *bool) string {
+	if year == nil {
+		return ""
+	}
+	return fmt.Sprintf(" %d private=%v", *year, yearPrivate != nil && *yearPrivate)
+}
+
+// pronounsCall describes the pronouns argument of a call, or returns "" if none were given
+func pronounsCall(pronouns *database.Pronouns) string {
+	if pronouns == nil {
+		return ""
+	}
+	return " pronouns=" + pronouns.String()
+}
+
+func (m *MockBirthdayService) RemoveBirthday(ctx context.Context, guildID, name string) error {
+	m.Calls = append(m.Calls, "remove "+name)
+	return m.CallError
+}
+
+func (m *MockBirthdayService) GetBirthdayByDiscordID(ctx context.Context, guildID, discordID string) (*database.Birthday, error) {
+	for i := range m.Birthdays {
+		if b := &m.Birthdays[i]; b.DiscordID != nil && *b.DiscordID == discordID {
+			return b, m.CallError
 		}
 	}
-	return util.People{People: people}
+	return nil, m.CallError
+}
+
+func (m *MockBirthdayService) UpsertByDiscordID(ctx context.Context, guildID, discordID, name string, month, day int, year *int, yearPrivate *bool, pronouns *database.Pronouns) error {
+	m.Calls = append(m.Calls, "upsert "+discordID+" "+name+yearCall(year, yearPrivate)+pronounsCall(pronouns))
+	return m.CallError
+}
+
+func (m *MockBirthdayService) RemoveByDiscordID(ctx context.Context, guildID, discordID string) error {
+	m.Calls = append(m.Calls, "forget "+discordID)
+	return m.CallError
+}
+
+func (m *MockBirthdayService) GetGuilds(ctx context.Context) ([]database.Guild, error) {
+	return nil, nil
+}
+
+func (m *MockBirthdayService) GetGuildSettings(ctx context.Context, guildID string) (database.Guild, error) {
+	guild := database.DefaultGuild(guildID)
+	if m.LeapDayPolicy != "" {
+		guild.LeapDayPolicy = m.LeapDayPolicy
+	}
+	return guild, m.CallError
+}
+
+func (m *MockBirthdayService) SetAnnouncementChannel(ctx context.Context, guildID, channelID string) error {
+	m.Calls = append(m.Calls, "channel "+channelID)
+	return m.CallError
+}
+
+func (m *MockBirthdayService) SetAnnouncementTime(ctx context.Context, guildID string, hour, minute int) error {
+	m.Calls = append(m.Calls, fmt.Sprintf("time %02d:%02d", hour, minute))
+	return m.CallError
+}
+
+func (m *MockBirthdayService) SetTimezone(ctx context.Context, guildID, timezone string) error {
+	m.Calls = append(m.Calls, "timezone "+timezone)
+	return m.CallError
+}
+
+func (m *MockBirthdayService) SetLeapDayPolicy(ctx context.Context, guildID, policy string) error {
+	m.Calls = append(m.Calls, "leapday "+policy)
+	return m.CallError
+}
+
+func (m *MockBirthdayService) MarkAnnounced(ctx context.Context, guildID string, date time.Time) error {
+	return nil
+}
+
+func (m *MockBirthdayService) ClaimAnnouncement(ctx context.Context, guildID string, date time.Time, kind string) (bool, error) {
+	return true, nil
+}
+
+func (m *MockBirthdayService) ReleaseAnnouncement(ctx context.Context, guildID string, date time.Time, kind string) error {
+	return nil
+}
+
+func (m *MockBirthdayService) GetAnnouncementHistory(ctx context.Context, guildID string, limit int) ([]database.Announcement, error) {
+	m.Calls = append(m.Calls, fmt.Sprintf("history %d", limit))
+	return m.Announcements, m.CallError
+}
+
+func (m *MockBirthdayService) GetTemplates(ctx context.Context, guildID, kind string) ([]string, error) {
+	return m.Templates[kind], m.ReadError
+}
+
+func (m *MockBirthdayService) SetTemplates(ctx context.Context, guildID, kind string, templates []string) error {
+	m.Calls = append(m.Calls, fmt.Sprintf("templates %s %d", kind, len(templates)))
+	return m.CallError
+}
+
+func (m *MockBirthdayService) ResetTemplates(ctx context.Context, guildID, kind string) error {
+	m.Calls = append(m.Calls, "reset templates "+kind)
+	return m.CallError
+}
+
+func (m *MockBirthdayService) PickTemplate(ctx context.Context, guildID, kind string) (string, error) {
+	if templates := m.Templates[kind]; len(templates) > 0 {
+		return templates[0], m.ReadError
+	}
+	return "", m.ReadError
+}
+
+func (m *MockBirthdayService) GetBirthdayTemplates(ctx context.Context, guildID string, birthdays []database.Birthday) ([]string, error) {
+	return make([]string, len(birthdays)), m.ReadError
+}
+
+func (m *MockBirthdayService) SetOverride(ctx context.Context, guildID string, month, day int, name, message string) error {
+	m.Calls = append(m.Calls, fmt.Sprintf("override %d/%d %q", month, day, name))
+	return m.CallError
+}
+
+func (m *MockBirthdayService) RemoveOverride(ctx context.Context, guildID string, month, day int, name string) error {
+	m.Calls = append(m.Calls, fmt.Sprintf("remove override %d/%d %q", month, day, name))
+	return m.CallError
+}
+
+func (m *MockBirthdayService) GetOverrides(ctx context.Context, guildID string) ([]database.Override, error) {
+	return m.Overrides, m.ReadError
+}
+
+func (m *MockBirthdayService) GetReminderSettings(ctx context.Context, guildID string) (database.ReminderSettings, error) {
+	return m.Reminders, m.ReadError
+}
+
+func (m *MockBirthdayService) SetReminderSettings(ctx context.Context, settings database.ReminderSettings) error {
+	m.Calls = append(m.Calls, fmt.Sprintf("reminders %v channel=%q role=%q", settings.Days, settings.ChannelID, settings.RoleID))
+	if m.CallError == nil {
+		m.Reminders = settings
+	}
+	return m.CallError
+}
+
+func (m *MockBirthdayService) GetDueReminders(ctx context.Context, guildID string) ([]birthday.Reminder, error) {
+	return nil, m.ReadError
+}
+
+func (m *MockBirthdayService) Subscribe(ctx context.Context, guildID, userID, name string) error {
+	m.Calls = append(m.Calls, fmt.Sprintf("subscribe %s %q", userID, name))
+	return m.CallError
+}
+
+func (m *MockBirthdayService) Unsubscribe(ctx context.Context, guildID, userID, name string) error {
+	m.Calls = append(m.Calls, fmt.Sprintf("unsubscribe %s %q", userID, name))
+	return m.CallError
+}
+
+func (m *MockBirthdayService) GetSubscriptions(ctx context.Context, guildID, userID string) ([]database.Subscription, error) {
+	return m.Subscriptions, m.ReadError
+}
+
+func (m *MockBirthdayService) GetDueNotifications(ctx context.Context, guildID string) ([]birthday.Notification, error) {
+	return m.Notifications, m.ReadError
+}
+
+func (m *MockBirthdayService) RecordNotification(ctx context.Context, guildID, userID, failure string) error {
+	m.Delivered = append(m.Delivered, userID+": "+failure)
+	return nil
+}
+
+func (m *MockBirthdayService) GetAnnouncementProgress(ctx context.Context, guildID string, date time.Time, kind string) (int, error) {
+	return 0, nil
+}
+
+func (m *MockBirthdayService) SetAnnouncementProgress(ctx context.Context, guildID string, date time.Time, kind string, sent int) error {
+	return nil
+}
+
+// MockRescheduler counts reschedule requests
+type MockRescheduler struct {
+	Count int
+}
+
+func (m *MockRescheduler) Reschedule() {
+	m.Count++
+}
+
+// birthdayCommand builds a /birthday interaction for the given subcommand and options
+func birthdayCommand(subcommand string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			Type:    discordgo.InteractionApplicationCommand,
+			GuildID: "guild-1",
+			Member:  &discordgo.Member{Permissions: discordgo.PermissionManageServer},
+			Data: discordgo.ApplicationCommandInteractionData{
+				Name: "birthday",
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{
+					{
+						Name:    subcommand,
+						Type:    discordgo.ApplicationCommandOptionSubCommand,
+						Options: options,
+					},
+				},
+			},
+		},
+	}
+}
+
+func stringOption(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionString, Value: value}
+}
+
+func intOption(name string, value int) *discordgo.ApplicationCommandInteractionDataOption {
+	// Discord sends integers as JSON numbers, which decode to float64
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionInteger, Value: float64(value)}
+}
+
+func userOption(name, userID string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionUser, Value: userID}
+}
+
+func boolOption(name string, value bool) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionBoolean, Value: value}
+}
+
+func TestHandleBirthdayCommand(t *testing.T) {
+	dateOptions := []*discordgo.ApplicationCommandInteractionDataOption{
+		stringOption("name", "Alice"), intOption("month", 1), intOption("day", 25),
+	}
+
+	tests := []struct {
+		name          string
+		interaction   *discordgo.InteractionCreate
+		callError     error
+		wantCall      string
+		wantContent   string
+		wantEphemeral bool
+	}{
+		{"Add birthday", birthdayCommand("add", dateOptions...), nil, "add Alice", "Added **Alice** on January 25", false},
+		{"Edit birthday", birthdayCommand("edit", dateOptions...), nil, "edit Alice", "Updated **Alice** to January 25", false},
+		{"Add birthday with year", birthdayCommand("add", append(dateOptions, intOption("year", 1990))...), nil, "add Alice 1990 private=false", "Added **Alice**", false},
+		{"Edit birthday with private year", birthdayCommand("edit", append(dateOptions, intOption("year", 1990), boolOption("hide_age", true))...), nil, "edit Alice 1990 private=true", "Updated **Alice**", false},
+		{"Add birthday with preset pronouns", birthdayCommand("add", append(dateOptions, stringOption("pronouns", "She/Her"))...), nil, "add Alice pronouns=she/her/her/herself", "Added **Alice**", false},
+		{"Edit birthday with custom pronouns", birthdayCommand("edit", append(dateOptions, stringOption("pronouns", "xe/xem/xyr/xemself"))...), nil, "edit Alice pronouns=xe/xem/xyr/xemself", "Updated **Alice**", false},
+		{"Remove birthday", birthdayCommand("remove", stringOption("name", "Alice")), nil, "remove Alice", "Removed **Alice**", false},
+		{"Add duplicate is ephemeral", birthdayCommand("add", dateOptions...), fmt.Errorf("%w: Alice", database.ErrDuplicateName), "add Alice", "already a birthday for **Alice**", true},
+		{"Remove missing is ephemeral", birthdayCommand("remove", stringOption("name", "Alice")), fmt.Errorf("%w for Alice", database.ErrNotFound), "remove Alice", "no birthday for **Alice**", true},
+		{"Invalid date is described", birthdayCommand("add", dateOptions...), fmt.Errorf("%w: April has 30 days, got 31", database.ErrInvalidDate), "add Alice", "April has 30 days", true},
+		{"Storage failure is not described", birthdayCommand("edit", dateOptions...), errors.New("disk I/O error"), "edit Alice", "problem on our side", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockClient := &MockDiscordClient{}
+			birthdayService := &MockBirthdayService{CallError: tt.callError}
+			handler := bot.NewHandler(mockClient, birthdayService)
+
+			// Act
+			handler.HandleSlashCommand(nil, tt.interaction)
+
+			// Assert
+			if len(birthdayService.Calls) != 1 || birthdayService.Calls[0] != tt.wantCall {
+				t.Errorf("Service calls = %v; want [%s]", birthdayService.Calls, tt.wantCall)
+			}
+			if len(mockClient.Responses) != 1 {
+				t.Fatalf("Expected 1 response, got %d", len(mockClient.Responses))
+			}
+			data := mockClient.Responses[0].Data
+			if !strings.Contains(data.Content, tt.wantContent) {
+				t.Errorf("Response content = %q; want it to contain %q", data.Content, tt.wantContent)
+			}
+			if ephemeral := data.Flags&discordgo.MessageFlagsEphemeral != 0; ephemeral != tt.wantEphemeral {
+				t.Errorf("Response ephemeral = %v; want %v", ephemeral, tt.wantEphemeral)
+			}
+		})
+	}
+}
+
+func TestHandleBirthdayCommand_RequiresManageServerToChange(t *testing.T) {
+	dateOptions := []*discordgo.ApplicationCommandInteractionDataOption{stringOption("name", "Alice"), intOption("month", 1), intOption("day", 25)}
+	for _, subcommand := range []string{"add", "edit", "remove"} {
+		t.Run(subcommand, func(t *testing.T) {
+			// Arrange: an ordinary member
+			mockClient := &MockDiscordClient{}
+			birthdayService := &MockBirthdayService{}
+			handler := bot.NewHandler(mockClient, birthdayService)
+			interaction := birthdayCommand(subcommand, dateOptions...)
+			interaction.Member.Permissions = 0
+
+			// Act
+			handler.HandleSlashCommand(nil, interaction)
+
+			// Assert
+			if len(birthdayService.Calls) != 0 {
+				t.Errorf("Service calls = %v; want none", birthdayService.Calls)
+			}
+			if len(mockClient.Responses) != 1 {
+				t.Fatalf("Expected 1 response, got %d", len(mockClient.Responses))
+			}
+			if data := mockClient.Responses[0].Data; !strings.Contains(data.Content, "You need the Manage Server permission") || data.Flags&discordgo.MessageFlagsEphemeral == 0 {
+				t.Errorf("Response = %q; want an ephemeral refusal", data.Content)
+			}
+		})
+	}
+}
+
+func TestHandleBirthdayCommand_RejectsUnknownPronouns(t *testing.T) {
+	// Arrange
+	mockClient := &MockDiscordClient{}
+	birthdayService := &MockBirthdayService{}
+	handler := bot.NewHandler(mockClient, birthdayService)
+	interaction := birthdayCommand("add", stringOption("name", "Alice"), intOption("month", 1), intOption("day", 25), stringOption("pronouns", "xe/xem"))
+
+	// Act
+	handler.HandleSlashCommand(nil, interaction)
+
+	// Assert
+	if len(birthdayService.Calls) != 0 {
+		t.Errorf("Expected no service calls, got %v", birthdayService.Calls)
+	}
+	if len(mockClient.Responses) != 1 {
+		t.Fatalf("Expected 1 response, got %d", len(mockClient.Responses))
+	}
+	data := mockClient.Responses[0].Data
+	if !strings.Contains(data.Content, "all four forms") || data.Flags&discordgo.MessageFlagsEphemeral == 0 {
+		t.Errorf("Expected an ephemeral reply explaining the pronouns, got %q", data.Content)
+	}
+}
+
+// memberCommand builds a top-level command interaction invoked by a guild member
+func memberCommand(command, userID, nick string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			Type:    discordgo.InteractionApplicationCommand,
+			GuildID: "guild-1",
+			Data: discordgo.ApplicationCommandInteractionData{
+				Name:    command,
+				Options: options,
+			},
+			Member: &discordgo.Member{
+				Nick: nick,
+				User: &discordgo.User{ID: userID, Username: "user" + userID},
+			},
+		},
+	}
+}
+
+func TestHandleSelfServiceCommands(t *testing.T) {
+	tests := []struct {
+		name        string
+		interaction *discordgo.InteractionCreate
+		callError   error
+		wantCall    string
+		wantContent string
+	}{
+		{
+			"Set birthday uses nickname",
+			memberCommand("setmybirthday", "42", "Ali", intOption("month", 1), intOption("day", 25)),
+			nil,
+			"upsert 42 Ali",
+			"Saved your birthday as January 25",
+		},
+		{
+			"Set birthday falls back to username",
+			memberCommand("setmybirthday", "42", "", intOption("month", 1), intOption("day", 25)),
+			nil,
+			"upsert 42 user42",
+			"Saved your birthday",
+		},
+		{
+			"Set birthday with explicit name",
+			memberCommand("setmybirthday", "42", "Ali", intOption("month", 1), intOption("day", 25), stringOption("name", "Alice")),
+			nil,
+			"upsert 42 Alice",
+			"Saved your birthday",
+		},
+		{
+			"Set birthday with a name someone else has",
+			memberCommand("setmybirthday", "42", "Ali", intOption("month", 1), intOption("day", 25)),
+			fmt.Errorf("%w: Ali", database.ErrDuplicateName),
+			"upsert 42 Ali",
+			"already a birthday for **Ali**",
+		},
+		{
+			"Forget me",
+			memberCommand("forgetme", "42", "Ali"),
+			nil,
+			"forget 42",
+			"deleted",
+		},
+		{
+			"Forget me without a birthday",
+			memberCommand("forgetme", "42", "Ali"),
+			fmt.Errorf("%w for discord user 42", database.ErrNotFound),
+			"forget 42",
+			"don't have a birthday saved",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockClient := &MockDiscordClient{}
+			birthdayService := &MockBirthdayService{CallError: tt.callError}
+			handler := bot.NewHandler(mockClient, birthdayService)
+
+			// Act
+			handler.HandleSlashCommand(nil, tt.interaction)
+
+			// Assert
+			if len(birthdayService.Calls) != 1 || birthdayService.Calls[0] != tt.wantCall {
+				t.Errorf("Service calls = %v; want [%s]", birthdayService.Calls, tt.wantCall)
+			}
+			if len(mockClient.Responses) != 1 {
+				t.Fatalf("Expected 1 response, got %d", len(mockClient.Responses))
+			}
+			data := mockClient.Responses[0].Data
+			if !strings.Contains(data.Content, tt.wantContent) {
+				t.Errorf("Response content = %q; want it to contain %q", data.Content, tt.wantContent)
+			}
+			if data.Flags&discordgo.MessageFlagsEphemeral == 0 {
+				t.Error("Expected self-service replies to be ephemeral")
+			}
+		})
+	}
 }
 
 func TestSendBirthdayMessage(t *testing.T) {
@@ -146,3 +747,1015 @@ func TestSendBirthdayMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestSendBirthdayMessage_RestrictsMentions(t *testing.T) {
+	// Arrange
+	mockClient := &MockDiscordClient{}
+	handler := bot.NewHandler(mockClient, &MockBirthdayService{})
+
+	// Act
+	err := handler.SendBirthdayMessage("123456", "Today is **<@42>'s birthday**! @everyone", "42")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("SendBirthdayMessage() returned error: %v", err)
+	}
+	if len(mockClient.SentMessages) != 1 {
+		t.Fatalf("Expected 1 message sent, got %d", len(mockClient.SentMessages))
+	}
+	mentions := mockClient.SentMessages[0].Mentions
+	if mentions == nil {
+		t.Fatal("Expected allowed mentions to be set")
+	}
+	if len(mentions.Parse) != 0 {
+		t.Errorf("Expected no parsed mention types (no @everyone), got %v", mentions.Parse)
+	}
+	if len(mentions.Users) != 1 || mentions.Users[0] != "42" {
+		t.Errorf("Allowed user mentions = %v; want [42]", mentions.Users)
+	}
+}
+
+func TestHandleSlashCommand_RejectsDirectMessages(t *testing.T) {
+	// Arrange
+	mockClient := &MockDiscordClient{}
+	handler := bot.NewHandler(mockClient, &MockBirthdayService{Birthdays: []database.Birthday{{Name: "Alice", Month: 1, Day: 25}}})
+	interaction := &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			Type: discordgo.InteractionApplicationCommand,
+			Data: discordgo.ApplicationCommandInteractionData{Name: "all"},
+			User: &discordgo.User{ID: "42"},
+		},
+	}
+
+	// Act
+	handler.HandleSlashCommand(nil, interaction)
+
+	// Assert
+	if len(mockClient.Responses) != 1 {
+		t.Fatalf("Expected 1 response, got %d", len(mockClient.Responses))
+	}
+	if content := mockClient.Responses[0].Data.Content; !strings.Contains(content, "only work inside a server") {
+		t.Errorf("Response content = %q; want a server-only notice", content)
+	}
+}
+
+// configCommand builds a /config interaction from a member with the given permissions
+func configCommand(permissions int64, subcommand string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	interaction := birthdayCommand(subcommand, options...)
+	interaction.Data = discordgo.ApplicationCommandInteractionData{
+		Name:    "config",
+		Options: interaction.ApplicationCommandData().Options,
+	}
+	interaction.Member = &discordgo.Member{
+		User:        &discordgo.User{ID: "42"},
+		Permissions: permissions,
+	}
+	return interaction
+}
+
+func TestHandleConfigCommand(t *testing.T) {
+	admin := int64(discordgo.PermissionManageServer)
+	channel := &discordgo.ApplicationCommandInteractionDataOption{Name: "channel", Type: discordgo.ApplicationCommandOptionChannel, Value: "777"}
+
+	tests := []struct {
+		name            string
+		interaction     *discordgo.InteractionCreate
+		wantCalls       []string
+		wantContent     string
+		wantRescheduled bool
+	}{
+		{"Set channel", configCommand(admin, "channel", channel), []string{"channel 777"}, "<#777>", true},
+		{"Set time", configCommand(admin, "time", stringOption("time", "08:30")), []string{"time 08:30"}, "08:30", true},
+		{"Set timezone", configCommand(admin, "timezone", stringOption("timezone", "Europe/London")), []string{"timezone Europe/London"}, "Europe/London", true},
+		{"Administrator may configure", configCommand(int64(discordgo.PermissionAdministrator), "time", stringOption("time", "07:00")), []string{"time 07:00"}, "07:00", true},
+		{"Invalid time", configCommand(admin, "time", stringOption("time", "25:99")), nil, "not a 24-hour HH:MM time", false},
+		{"Set leap day policy", configCommand(admin, "leapday", stringOption("celebrate", database.LeapDayMar1)), []string{"leapday mar1"}, "March 1", true},
+		{"Show settings", configCommand(admin, "show"), nil, "09:00", false},
+		{"Requires Manage Server", configCommand(0, "time", stringOption("time", "08:30")), nil, "Manage Server", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockClient := &MockDiscordClient{}
+			birthdayService := &MockBirthdayService{}
+			rescheduler := &MockRescheduler{}
+			handler := bot.NewHandler(mockClient, birthdayService)
+			handler.SetRescheduler(rescheduler)
+
+			// Act
+			handler.HandleSlashCommand(nil, tt.interaction)
+
+			// Assert
+			if fmt.Sprint(birthdayService.Calls) != fmt.Sprint(tt.wantCalls) {
+				t.Errorf("Service calls = %v; want %v", birthdayService.Calls, tt.wantCalls)
+			}
+			if len(mockClient.Responses) != 1 {
+				t.Fatalf("Expected 1 response, got %d", len(mockClient.Responses))
+			}
+			data := mockClient.Responses[0].Data
+			if !strings.Contains(data.Content, tt.wantContent) {
+				t.Errorf("Response content = %q; want it to contain %q", data.Content, tt.wantContent)
+			}
+			if data.Flags&discordgo.MessageFlagsEphemeral == 0 {
+				t.Error("Expected config replies to be ephemeral")
+			}
+			if rescheduled := rescheduler.Count > 0; rescheduled != tt.wantRescheduled {
+				t.Errorf("Rescheduled = %v; want %v", rescheduled, tt.wantRescheduled)
+			}
+		})
+	}
+}
+
+func TestHandleNextCommand_LeapDay(t *testing.T) {
+	tests := []struct {
+		name        string
+		now         time.Time
+		policy      string
+		wantContent string
+	}{
+		{"Non-leap year, February 28", time.Date(2025, 2, 20, 10, 0, 0, 0, time.UTC), database.LeapDayFeb28, "Leah on February 28 (in 8 days!)"},
+		{"Non-leap year, March 1", time.Date(2025, 2, 20, 10, 0, 0, 0, time.UTC), database.LeapDayMar1, "Leah on March 1 (in 9 days!)"},
+		{"Leap year", time.Date(2028, 2, 20, 10, 0, 0, 0, time.UTC), database.LeapDayFeb28, "Leah on February 29 (in 9 days!)"},
+		{"Leap year ignores March 1 policy", time.Date(2028, 2, 20, 10, 0, 0, 0, time.UTC), database.LeapDayMar1, "Leah on February 29 (in 9 days!)"},
+		{"Celebrated today", time.Date(2025, 2, 28, 10, 0, 0, 0, time.UTC), database.LeapDayFeb28, "Leah on February 28 (Today! 🎉)"},
+		{"Celebrated tomorrow", time.Date(2025, 2, 28, 10, 0, 0, 0, time.UTC), database.LeapDayMar1, "Leah on March 1 (Tomorrow!)"},
+		{"Passed, next year is not a leap year", time.Date(2025, 3, 2, 10, 0, 0, 0, time.UTC), database.LeapDayFeb28, "Leah on February 28 (in 363 days!)"},
+		{"Passed, next year is a leap year", time.Date(2027, 3, 2, 10, 0, 0, 0, time.UTC), database.LeapDayFeb28, "Leah on February 29 (in 364 days!)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockClient := &MockDiscordClient{}
+			birthdayService := &MockBirthdayService{
+				CurrentTime:   tt.now,
+				LeapDayPolicy: tt.policy,
+				Birthdays:     []database.Birthday{{Name: "Leah", Month: 2, Day: 29}},
+			}
+			handler := bot.NewHandler(mockClient, birthdayService)
+
+			// Act
+			handler.HandleSlashCommand(nil, memberCommand("next", "1", ""))
+
+			// Assert
+			if len(mockClient.Responses) != 1 {
+				t.Fatalf("Expected 1 response, got %d", len(mockClient.Responses))
+			}
+			if content := mockClient.Responses[0].Data.Content; !strings.Contains(content, tt.wantContent) {
+				t.Errorf("Response content = %q; want it to contain %q", content, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestHandleNextAndUpcomingCommands_AcrossYearEnd(t *testing.T) {
+	// Arrange: on December 30th, two birthdays are left this year
+	birthdays := []database.Birthday{
+		{Name: "Carol", Month: 1, Day: 2},
+		{Name: "Alice", Month: 12, Day: 30},
+		{Name: "Dave", Month: 2, Day: 1},
+		{Name: "Bob", Month: 12, Day: 31},
+	}
+
+	tests := []struct {
+		name        string
+		interaction *discordgo.InteractionCreate
+		wantContent string
+	}{
+		{"Next", memberCommand("next", "1", ""), "**Next birthday:** Alice on December 30 (Today! 🎉)"},
+		{"Next count", memberCommand("next", "1", "", intOption("count", 3)),
+			"**Next Birthdays:**\n\n• 🎉 **Alice, December 30** (today!)\n• Bob, December 31 (tomorrow)\n• Carol, January 2 (in 3 days)\n"},
+		{"Upcoming this week", memberCommand("upcoming", "1", ""),
+			"**Birthdays This Week:**\n\n• 🎉 **Alice, December 30** (today!)\n• Bob, December 31 (tomorrow)\n• Carol, January 2 (in 3 days)\n"},
+		{"Upcoming days", memberCommand("upcoming", "1", "", intOption("days", 40)), "• Dave, February 1 (in 33 days)"},
+		{"Upcoming tomorrow", memberCommand("upcoming", "1", "", intOption("days", 1)), "• Bob, December 31 (tomorrow)\n"},
+		{"Count out of range", memberCommand("next", "1", "", intOption("count", 26)), "The count must be between 1 and 25"},
+		{"Days out of range", memberCommand("upcoming", "1", "", intOption("days", 0)), "The number of days must be between 1 and 366"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockDiscordClient{}
+			birthdayService := &MockBirthdayService{
+				CurrentTime: time.Date(2025, 12, 30, 10, 0, 0, 0, time.UTC),
+				Birthdays:   birthdays,
+			}
+			handler := bot.NewHandler(mockClient, birthdayService)
+
+			// Act
+			handler.HandleSlashCommand(nil, tt.interaction)
+
+			// Assert
+			if len(mockClient.Responses) != 1 {
+				t.Fatalf("Expected 1 response, got %d", len(mockClient.Responses))
+			}
+			if content := mockClient.Responses[0].Data.Content; !strings.Contains(content, tt.wantContent) {
+				t.Errorf("Response content = %q; want it to contain %q", content, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestHandleUpcomingCommand_NoBirthdays(t *testing.T) {
+	// Arrange
+	mockClient := &MockDiscordClient{}
+	handler := bot.NewHandler(mockClient, &MockBirthdayService{Birthdays: []database.Birthday{{Name: "Alice", Month: 1, Day: 25}}})
+
+	// Act
+	handler.HandleSlashCommand(nil, memberCommand("upcoming", "1", "", intOption("days", 14)))
+
+	// Assert
+	if content := mockClient.Responses[0].Data.Content; content != "No birthdays in the next 14 days!" {
+		t.Errorf("Response content = %q; want the empty message", content)
+	}
+}
+
+func TestHandleListCommands(t *testing.T) {
+	birthdays := []database.Birthday{
+		{Name: "Alice", Month: 1, Day: 25},
+		{Name: "John", Month: 3, Day: 15},
+	}
+
+	tests := []struct {
+		name          string
+		command       string
+		birthdays     []database.Birthday
+		readError     error
+		wantContent   string
+		wantEphemeral bool
+	}{
+		{"Month", "month", birthdays, nil, "John, March 15", false},
+		{"Month without birthdays", "month", birthdays[:1], nil, "No birthdays this month!", false},
+		{"All", "all", birthdays, nil, "Alice, January 25", false},
+		{"All without birthdays", "all", nil, nil, "No birthdays configured!", false},
+		{"Next", "next", birthdays, nil, "John on March 15 (Today! 🎉)", false},
+		{"Month storage failure", "month", birthdays, errors.New("database is locked"), "Could not list this month's birthdays because of a problem on our side", true},
+		{"All storage failure", "all", birthdays, errors.New("database is locked"), "Could not list birthdays because of a problem on our side", true},
+		{"Next storage failure", "next", birthdays, errors.New("database is locked"), "Could not find the next birthday because of a problem on our side", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockClient := &MockDiscordClient{}
+			birthdayService := &MockBirthdayService{Birthdays: tt.birthdays, ReadError: tt.readError}
+			handler := bot.NewHandler(mockClient, birthdayService)
+			handler.SetRenderer(render.PlainText{})
+
+			// Act
+			handler.HandleSlashCommand(nil, memberCommand(tt.command, "1", ""))
+
+			// Assert
+			if len(mockClient.Responses) != 1 {
+				t.Fatalf("Expected 1 response, got %d", len(mockClient.Responses))
+			}
+			data := mockClient.Responses[0].Data
+			if !strings.Contains(data.Content, tt.wantContent) {
+				t.Errorf("Response content = %q; want it to contain %q", data.Content, tt.wantContent)
+			}
+			if ephemeral := data.Flags&discordgo.MessageFlagsEphemeral != 0; ephemeral != tt.wantEphemeral {
+				t.Errorf("Ephemeral = %v; want %v", ephemeral, tt.wantEphemeral)
+			}
+		})
+	}
+}
+
+func TestHandleListCommands_Embeds(t *testing.T) {
+	// Arrange
+	mockClient := &MockDiscordClient{}
+	birthdayService := &MockBirthdayService{Birthdays: []database.Birthday{
+		{Name: "Alice", Month: 1, Day: 25},
+		{Name: "Benjamin", Month: 1, Day: 31},
+		{Name: "John", Month: 3, Day: 20},
+	}}
+	handler := bot.NewHandler(mockClient, birthdayService)
+
+	// Act
+	handler.HandleSlashCommand(nil, memberCommand("all", "1", ""))
+
+	// Assert
+	if len(mockClient.Responses) != 1 {
+		t.Fatalf("Expected 1 response, got %d", len(mockClient.Responses))
+	}
+	embeds := mockClient.Responses[0].Data.Embeds
+	if len(embeds) != 1 {
+		t.Fatalf("Expected 1 embed, got %d", len(embeds))
+	}
+	fields := embeds[0].Fields
+	if len(fields) != 2 {
+		t.Fatalf("Expected a field for January and March, got %d fields", len(fields))
+	}
+	if fields[0].Name != "January" || fields[0].Value != "• Alice, January 25\n• Benjamin, January 31\n" {
+		t.Errorf("January field = %q: %q", fields[0].Name, fields[0].Value)
+	}
+	if fields[1].Name != "March" || fields[1].Value != "• John, March 20\n" {
+		t.Errorf("March field = %q: %q", fields[1].Name, fields[1].Value)
+	}
+}
+
+func TestHandleMonthCommand_LongListingUsesFollowups(t *testing.T) {
+	// Arrange: 100 birthdays in March are too long for one message
+	var birthdays []database.Birthday
+	for i := 0; i < 100; i++ {
+		birthdays = append(birthdays, database.Birthday{Name: fmt.Sprintf("Person with a long name %02d", i), Month: 3, Day: i%31 + 1})
+	}
+	mockClient := &MockDiscordClient{}
+	handler := bot.NewHandler(mockClient, &MockBirthdayService{Birthdays: birthdays})
+	handler.SetRenderer(render.PlainText{})
+
+	// Act
+	handler.HandleSlashCommand(nil, memberCommand("month", "1", ""))
+
+	// Assert
+	if len(mockClient.Responses) != 1 || len(mockClient.Followups) == 0 {
+		t.Fatalf("Expected a response and follow-ups, got %d responses and %d follow-ups", len(mockClient.Responses), len(mockClient.Followups))
+	}
+	parts := []string{mockClient.Responses[0].Data.Content}
+	for _, followup := range mockClient.Followups {
+		parts = append(parts, followup.Content)
+	}
+	for i, part := range parts {
+		if n := len([]rune(part)); n > interfaces.MessageLimit {
+			t.Errorf("Message %d has %d characters; want at most %d", i, n, interfaces.MessageLimit)
+		}
+		if strings.Count(part, "**")%2 != 0 {
+			t.Errorf("Message %d splits a bold span: %q", i, part)
+		}
+	}
+	if joined := strings.Join(parts, ""); strings.Count(joined, "• ") != len(birthdays) {
+		t.Errorf("Expected every birthday to be listed once, got %d", strings.Count(joined, "• "))
+	}
+}
+
+func TestHandleLookupCommand(t *testing.T) {
+	aliceID, aliceYear := "42", 1995
+	birthdays := []database.Birthday{
+		{Name: "Alice", Month: 3, Day: 20, Year: &aliceYear, DiscordID: &aliceID},
+		{Name: "Alicia", Month: 3, Day: 15},
+		{Name: "Bob", Month: 7, Day: 4},
+	}
+
+	tests := []struct {
+		name          string
+		options       []*discordgo.ApplicationCommandInteractionDataOption
+		readError     error
+		wantContent   string
+		wantEphemeral bool
+	}{
+		{"Exact name, any case", []*discordgo.ApplicationCommandInteractionDataOption{stringOption("name", "alice")}, nil, "🎂 Alice, March 20 (in 5 days), turning 30", false},
+		{"Partial name", []*discordgo.ApplicationCommandInteractionDataOption{stringOption("name", "ali")}, nil,
+			"**Birthdays matching \"ali\":**\n\n• 🎉 **Alicia, March 15** (today!)\n• Alice, March 20 (in 5 days), turning 30\n", false},
+		{"Typo", []*discordgo.ApplicationCommandInteractionDataOption{stringOption("name", "Bbo")}, nil, "🎂 Bob, July 4 (in 111 days)", false},
+		{"User", []*discordgo.ApplicationCommandInteractionDataOption{userOption("user", "42")}, nil, "🎂 Alice, March 20 (in 5 days), turning 30", false},
+		{"Unknown user", []*discordgo.ApplicationCommandInteractionDataOption{userOption("user", "7")}, nil, "<@7> hasn't saved a birthday in this server", true},
+		{"No match", []*discordgo.ApplicationCommandInteractionDataOption{stringOption("name", "Zed")}, nil, "No birthday found for **Zed**", true},
+		{"Nothing to look up", nil, nil, "Give a name or a user to look up", true},
+		{"Storage failure", []*discordgo.ApplicationCommandInteractionDataOption{stringOption("name", "Alice")}, errors.New("database is locked"), "Could not look up the birthday because of a problem on our side", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockClient := &MockDiscordClient{}
+			handler := bot.NewHandler(mockClient, &MockBirthdayService{Birthdays: birthdays, ReadError: tt.readError})
+
+			// Act
+			handler.HandleSlashCommand(nil, birthdayCommand("lookup", tt.options...))
+
+			// Assert
+			if len(mockClient.Responses) != 1 {
+				t.Fatalf("Expected 1 response, got %d", len(mockClient.Responses))
+			}
+			data := mockClient.Responses[0].Data
+			if !strings.Contains(data.Content, tt.wantContent) {
+				t.Errorf("Response content = %q; want it to contain %q", data.Content, tt.wantContent)
+			}
+			if ephemeral := data.Flags&discordgo.MessageFlagsEphemeral != 0; ephemeral != tt.wantEphemeral {
+				t.Errorf("Ephemeral = %v; want %v", ephemeral, tt.wantEphemeral)
+			}
+		})
+	}
+}
+
+// templateCommand builds a /template subcommand invoked by a member with the given permissions
+func templateCommand(permissions int64, subcommand, kind string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	interaction := configCommand(permissions, subcommand, append([]*discordgo.ApplicationCommandInteractionDataOption{stringOption("kind", kind)}, options...)...)
+	interaction.Data = discordgo.ApplicationCommandInteractionData{
+		Name:    "template",
+		Options: interaction.ApplicationCommandData().Options,
+	}
+	return interaction
+}
+
+func TestHandleTemplateCommand(t *testing.T) {
+	admin := int64(discordgo.PermissionManageServer)
+	saved := map[string][]string{database.AnnouncementBirthday: {"Happy birthday {{name}}!", "Cheers, {{name}}!"}}
+
+	tests := []struct {
+		name        string
+		interaction *discordgo.InteractionCreate
+		templates   map[string][]string
+		wantCalls   []string
+		wantContent []string
+	}{
+		{
+			"Set with variants",
+			templateCommand(admin, "set", database.AnnouncementBirthday, stringOption("text", "Happy birthday {{mention}}!"), stringOption("text2", "{{name}} turns {{age}} today!")),
+			nil,
+			[]string{"templates birthday 2"},
+			[]string{"✅ Template saved.", "_Variant 1:_\nHappy birthday Alex!", "_Variant 2:_\nAlex turns 30 today!"},
+		},
+		{
+			"Set a monthly greeting",
+			templateCommand(admin, "set", database.AnnouncementMonthly, stringOption("text", "Welcome to {{month}}!")),
+			nil,
+			[]string{"templates monthly 1"},
+			[]string{"Preview of the monthly roundup:", "Welcome to March! \nBelow are all of the birthdays this month:\n• 🎉 **Alex, March 15** (today!)"},
+		},
+		{
+			"Set rejects a broken template",
+			templateCommand(admin, "set", database.AnnouncementBirthday, stringOption("text", "Happy birthday {{name}")),
+			nil,
+			nil,
+			[]string{"❌ Could not save the template:", "bad character"},
+		},
+		{
+			"Set rejects a function the kind doesn't have",
+			templateCommand(admin, "set", database.AnnouncementMonthly, stringOption("text", "Happy {{month}}, {{name}}!")),
+			nil,
+			nil,
+			[]string{"❌ Could not save the template:", "name is only available in birthday templates"},
+		},
+		{
+			"Preview text",
+			templateCommand(admin, "preview", database.AnnouncementBirthday, stringOption("text", "{{if milestone}}Big one for {{name}}!{{end}}")),
+			saved,
+			nil,
+			[]string{"**Preview of the birthday message:**\n\nBig one for Alex!"},
+		},
+		{
+			"Preview saved templates",
+			templateCommand(admin, "preview", database.AnnouncementBirthday),
+			saved,
+			nil,
+			[]string{"Happy birthday Alex!", "Cheers, Alex!"},
+		},
+		{
+			"Preview the default",
+			templateCommand(admin, "preview", database.AnnouncementBirthday),
+			nil,
+			nil,
+			[]string{"Today is **Alex's birthday**! 🎉 Alex is turning **30**, a milestone birthday! 🥳"},
+		},
+		{
+			"Reset",
+			templateCommand(admin, "reset", database.AnnouncementMonthly),
+			saved,
+			[]string{"reset templates monthly"},
+			[]string{"The default template will be used"},
+		},
+		{
+			"Requires Manage Server",
+			templateCommand(0, "reset", database.AnnouncementMonthly),
+			saved,
+			nil,
+			[]string{"You need the Manage Server permission"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockClient := &MockDiscordClient{}
+			birthdayService := &MockBirthdayService{Templates: tt.templates}
+			handler := bot.NewHandler(mockClient, birthdayService)
+
+			// Act
+			handler.HandleSlashCommand(nil, tt.interaction)
+
+			// Assert
+			if fmt.Sprint(birthdayService.Calls) != fmt.Sprint(tt.wantCalls) {
+				t.Errorf("Calls = %v; want %v", birthdayService.Calls, tt.wantCalls)
+			}
+			if len(mockClient.Responses) != 1 {
+				t.Fatalf("Expected 1 response, got %d", len(mockClient.Responses))
+			}
+			data := mockClient.Responses[0].Data
+			for _, want := range tt.wantContent {
+				if !strings.Contains(data.Content, want) {
+					t.Errorf("Response content = %q; want it to contain %q", data.Content, want)
+				}
+			}
+			if data.Flags&discordgo.MessageFlagsEphemeral == 0 {
+				t.Error("Expected template replies to be ephemeral")
+			}
+		})
+	}
+}
+
+// overrideCommand builds an /override subcommand invoked by a member with the given permissions
+func overrideCommand(permissions int64, subcommand string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	interaction := configCommand(permissions, subcommand, options...)
+	interaction.Data = discordgo.ApplicationCommandInteractionData{
+		Name:    "override",
+		Options: interaction.ApplicationCommandData().Options,
+	}
+	return interaction
+}
+
+func TestHandleOverrideCommand(t *testing.T) {
+	admin := int64(discordgo.PermissionManageServer)
+	jan6 := []*discordgo.ApplicationCommandInteractionDataOption{intOption("month", 1), intOption("day", 6)}
+	overrides := []database.Override{
+		{Month: 1, Day: 6, Name: "Casey", Message: "Nothing special today."},
+		{Month: 3, Day: 14, Message: "Happy Pi Day, {{name}}!"},
+	}
+
+	tests := []struct {
+		name        string
+		interaction *discordgo.InteractionCreate
+		callError   error
+		wantCalls   []string
+		wantContent []string
+	}{
+		{
+			"Set for a person",
+			overrideCommand(admin, "set", append(jan6, stringOption("message", "Nothing special today, {{name}}."), stringOption("name", "Casey"))...),
+			nil,
+			[]string{`override 1/6 "Casey"`},
+			[]string{"On January 6, **Casey** will get this message", "Nothing special today, Alex."},
+		},
+		{
+			"Set for everyone",
+			overrideCommand(admin, "set", append(jan6, stringOption("message", "Happy Epiphany!"))...),
+			nil,
+			[]string{`override 1/6 ""`},
+			[]string{"On January 6, everyone will get this message"},
+		},
+		{
+			"Set rejects a broken message",
+			overrideCommand(admin, "set", append(jan6, stringOption("message", "{{nmae}}"))...),
+			nil,
+			nil,
+			[]string{"❌ Could not save the message:", `function "nmae" not defined`},
+		},
+		{
+			"Set for a name without a birthday",
+			overrideCommand(admin, "set", append(jan6, stringOption("message", "Hi"), stringOption("name", "Cassie"))...),
+			fmt.Errorf("%w: no birthday found for Cassie", database.ErrNotFound),
+			[]string{`override 1/6 "Cassie"`},
+			[]string{"❌ Could not save the message: there is no birthday for **Cassie**"},
+		},
+		{
+			"Set rejects an impossible date",
+			overrideCommand(admin, "set", intOption("month", 2), intOption("day", 30), stringOption("message", "Hi")),
+			fmt.Errorf("%w: February has 29 days, got 30", database.ErrInvalidDate),
+			[]string{`override 2/30 ""`},
+			[]string{"February has 29 days"},
+		},
+		{
+			"Remove",
+			overrideCommand(admin, "remove", append(jan6, stringOption("name", "Casey"))...),
+			nil,
+			[]string{`remove override 1/6 "Casey"`},
+			[]string{"Removed the message for **Casey** on January 6"},
+		},
+		{
+			"Remove a missing override",
+			overrideCommand(admin, "remove", jan6...),
+			database.ErrNotFound,
+			[]string{`remove override 1/6 ""`},
+			[]string{"There is no message for everyone on January 6"},
+		},
+		{
+			"List",
+			overrideCommand(admin, "list"),
+			nil,
+			nil,
+			[]string{"• January 6, **Casey**: Nothing special today.", "• March 14, everyone: Happy Pi Day, {{name}}!"},
+		},
+		{
+			"Requires Manage Server",
+			overrideCommand(0, "list"),
+			nil,
+			nil,
+			[]string{"You need the Manage Server permission"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockClient := &MockDiscordClient{}
+			birthdayService := &MockBirthdayService{Overrides: overrides, CallError: tt.callError}
+			handler := bot.NewHandler(mockClient, birthdayService)
+
+			// Act
+			handler.HandleSlashCommand(nil, tt.interaction)
+
+			// Assert
+			if fmt.Sprint(birthdayService.Calls) != fmt.Sprint(tt.wantCalls) {
+				t.Errorf("Calls = %v; want %v", birthdayService.Calls, tt.wantCalls)
+			}
+			if len(mockClient.Responses) != 1 {
+				t.Fatalf("Expected 1 response, got %d", len(mockClient.Responses))
+			}
+			data := mockClient.Responses[0].Data
+			for _, want := range tt.wantContent {
+				if !strings.Contains(data.Content, want) {
+					t.Errorf("Response content = %q; want it to contain %q", data.Content, want)
+				}
+			}
+			if data.Flags&discordgo.MessageFlagsEphemeral == 0 {
+				t.Error("Expected override replies to be ephemeral")
+			}
+		})
+	}
+}
+
+// remindersCommand builds a /reminders interaction from a member with the given permissions
+func remindersCommand(permissions int64, subcommand string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	interaction := configCommand(permissions, subcommand, options...)
+	interaction.Data = discordgo.ApplicationCommandInteractionData{
+		Name:    "reminders",
+		Options: interaction.ApplicationCommandData().Options,
+	}
+	return interaction
+}
+
+func TestHandleRemindersCommand(t *testing.T) {
+	admin := int64(discordgo.PermissionManageServer)
+	channel := &discordgo.ApplicationCommandInteractionDataOption{Name: "channel", Type: discordgo.ApplicationCommandOptionChannel, Value: "777"}
+	role := &discordgo.ApplicationCommandInteractionDataOption{Name: "role", Type: discordgo.ApplicationCommandOptionRole, Value: "888"}
+
+	tests := []struct {
+		name        string
+		interaction *discordgo.InteractionCreate
+		callError   error
+		wantCalls   []string
+		wantContent []string
+	}{
+		{
+			"Set with a channel and role",
+			remindersCommand(admin, "set", stringOption("days", "7, 1"), channel, role),
+			nil,
+			[]string{`reminders [7 1] channel="777" role="888"`},
+			[]string{"✅ Reminders saved.", "7 days, 1 day", "<#777>", "<@&888>"},
+		},
+		{
+			"Set rejects a role without a channel",
+			remindersCommand(admin, "set", stringOption("days", "3"), role),
+			fmt.Errorf("%w: give an organizers channel to post reminders in", database.ErrInvalidValue),
+			[]string{`reminders [3] channel="" role="888"`},
+			[]string{"❌ Could not set up reminders: invalid value: give an organizers channel"},
+		},
+		{
+			"Set rejects days that aren't numbers",
+			remindersCommand(admin, "set", stringOption("days", "a week"), channel),
+			nil,
+			nil,
+			[]string{`"a" is not a number of days`},
+		},
+		{
+			"Set reports invalid settings",
+			remindersCommand(admin, "set", stringOption("days", "7")),
+			fmt.Errorf("%w: give a channel or a role to remind", database.ErrInvalidValue),
+			[]string{`reminders [7] channel="" role=""`},
+			[]string{"give a channel or a role to remind"},
+		},
+		{
+			"Off",
+			remindersCommand(admin, "off"),
+			nil,
+			[]string{`reminders [] channel="" role=""`},
+			[]string{"Birthday reminders are off"},
+		},
+		{
+			"Show without reminders",
+			remindersCommand(admin, "show"),
+			nil,
+			nil,
+			[]string{"Birthday reminders are off"},
+		},
+		{
+			"Requires Manage Server",
+			remindersCommand(0, "show"),
+			nil,
+			nil,
+			[]string{"You need the Manage Server permission"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockClient := &MockDiscordClient{}
+			birthdayService := &MockBirthdayService{CallError: tt.callError}
+			handler := bot.NewHandler(mockClient, birthdayService)
+
+			// Act
+			handler.HandleSlashCommand(nil, tt.interaction)
+
+			// Assert
+			if fmt.Sprint(birthdayService.Calls) != fmt.Sprint(tt.wantCalls) {
+				t.Errorf("Calls = %v; want %v", birthdayService.Calls, tt.wantCalls)
+			}
+			if len(mockClient.Responses) != 1 {
+				t.Fatalf("Expected 1 response, got %d", len(mockClient.Responses))
+			}
+			data := mockClient.Responses[0].Data
+			for _, want := range tt.wantContent {
+				if !strings.Contains(data.Content, want) {
+					t.Errorf("Response content = %q; want it to contain %q", data.Content, want)
+				}
+			}
+			if data.Flags&discordgo.MessageFlagsEphemeral == 0 {
+				t.Error("Expected reminder replies to be ephemeral")
+			}
+		})
+	}
+}
+
+// subscribeCommand builds a /subscribe or /unsubscribe interaction from member 5
+func subscribeCommand(command, subcommand string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	return memberCommand(command, "5", "", &discordgo.ApplicationCommandInteractionDataOption{
+		Name:    subcommand,
+		Type:    discordgo.ApplicationCommandOptionSubCommand,
+		Options: options,
+	})
+}
+
+func TestHandleSubscribeCommand(t *testing.T) {
+	aliceID := "7"
+	birthdays := []database.Birthday{{Name: "Alice", Month: 6, Day: 4, DiscordID: &aliceID}}
+
+	tests := []struct {
+		name        string
+		interaction *discordgo.InteractionCreate
+		callError   error
+		wantCalls   []string
+		wantContent string
+	}{
+		{
+			"Subscribe by name",
+			subscribeCommand("subscribe", "person", stringOption("name", "Alice")),
+			nil,
+			[]string{`subscribe 5 "Alice"`},
+			"You'll get a DM the day before **Alice**'s birthday",
+		},
+		{
+			"Subscribe by user",
+			subscribeCommand("subscribe", "person", userOption("user", aliceID)),
+			nil,
+			[]string{`subscribe 5 "Alice"`},
+			"**Alice**'s birthday",
+		},
+		{
+			"Subscribe to a user without a birthday",
+			subscribeCommand("subscribe", "person", userOption("user", "8")),
+			nil,
+			nil,
+			"<@8> hasn't saved a birthday",
+		},
+		{
+			"Subscribe to an unknown name",
+			subscribeCommand("subscribe", "person", stringOption("name", "Zed")),
+			fmt.Errorf("%w: no birthday found for Zed", database.ErrNotFound),
+			[]string{`subscribe 5 "Zed"`},
+			"there is no birthday for **Zed**",
+		},
+		{
+			"Subscribe without a person",
+			subscribeCommand("subscribe", "person"),
+			nil,
+			nil,
+			"Give a name or a user",
+		},
+		{
+			"Subscribe to everyone",
+			subscribeCommand("subscribe", "all"),
+			nil,
+			[]string{`subscribe 5 ""`},
+			"every birthday in this server",
+		},
+		{
+			"Unsubscribe",
+			subscribeCommand("unsubscribe", "person", stringOption("name", "Alice")),
+			nil,
+			[]string{`unsubscribe 5 "Alice"`},
+			"You'll no longer get a DM about **Alice**'s birthday",
+		},
+		{
+			"Unsubscribe without a subscription",
+			subscribeCommand("unsubscribe", "all"),
+			database.ErrNotFound,
+			[]string{`unsubscribe 5 ""`},
+			"You aren't subscribed to every birthday in this server",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockClient := &MockDiscordClient{}
+			birthdayService := &MockBirthdayService{Birthdays: birthdays, CallError: tt.callError}
+			handler := bot.NewHandler(mockClient, birthdayService)
+
+			// Act
+			handler.HandleSlashCommand(nil, tt.interaction)
+
+			// Assert
+			if fmt.Sprint(birthdayService.Calls) != fmt.Sprint(tt.wantCalls) {
+				t.Errorf("Calls = %v; want %v", birthdayService.Calls, tt.wantCalls)
+			}
+			if len(mockClient.Responses) != 1 {
+				t.Fatalf("Expected 1 response, got %d", len(mockClient.Responses))
+			}
+			data := mockClient.Responses[0].Data
+			if !strings.Contains(data.Content, tt.wantContent) {
+				t.Errorf("Response content = %q; want it to contain %q", data.Content, tt.wantContent)
+			}
+			if data.Flags&discordgo.MessageFlagsEphemeral == 0 {
+				t.Error("Expected subscription replies to be ephemeral")
+			}
+		})
+	}
+}
+
+func TestHandleSubscriptionsCommand(t *testing.T) {
+	// Arrange: the last DM to the member failed
+	failedAt := time.Date(2025, time.June, 3, 9, 0, 0, 0, time.UTC)
+	mockClient := &MockDiscordClient{}
+	birthdayService := &MockBirthdayService{Subscriptions: []database.Subscription{
+		{Name: "", Failure: "DMs are off", FailedAt: failedAt},
+		{Name: "Alice", Failure: "DMs are off", FailedAt: failedAt},
+	}}
+	handler := bot.NewHandler(mockClient, birthdayService)
+
+	// Act
+	handler.HandleSlashCommand(nil, memberCommand("subscriptions", "5", ""))
+
+	// Assert
+	if len(mockClient.Responses) != 1 {
+		t.Fatalf("Expected 1 response, got %d", len(mockClient.Responses))
+	}
+	content := mockClient.Responses[0].Data.Content
+	for _, want := range []string{"• every birthday in this server\n• **Alice**'s birthday", "could not be sent (2025-06-03 09:00 UTC): DMs are off"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Response content = %q; want it to contain %q", content, want)
+		}
+	}
+	if strings.Count(content, "DMs are off") != 1 {
+		t.Errorf("Expected the failure to be shown once, got %q", content)
+	}
+}
+
+// buttonPress builds a button interaction for the given custom ID
+func buttonPress(customID string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			Type:    discordgo.InteractionMessageComponent,
+			GuildID: "guild-1",
+			Data:    discordgo.MessageComponentInteractionData{CustomID: customID, ComponentType: discordgo.ButtonComponent},
+		},
+	}
+}
+
+// pageButtonStates returns the custom IDs and disabled states of the /all page buttons in a response
+func pageButtonStates(t *testing.T, data *discordgo.InteractionResponseData) []string {
+	t.Helper()
+	if len(data.Components) != 1 {
+		t.Fatalf("Expected a row of buttons, got %d components", len(data.Components))
+	}
+	var states []string
+	for _, component := range data.Components[0].(discordgo.ActionsRow).Components {
+		button := component.(discordgo.Button)
+		states = append(states, fmt.Sprintf("%s disabled=%v", button.CustomID, button.Disabled))
+	}
+	return states
+}
+
+func TestHandleAllCommand_Pages(t *testing.T) {
+	// Arrange: 60 birthdays make three pages
+	var birthdays []database.Birthday
+	for i := 0; i < 60; i++ {
+		birthdays = append(birthdays, database.Birthday{Name: fmt.Sprintf("Person %02d", i), Month: i/5 + 1, Day: i%5 + 1})
+	}
+	mockClient := &MockDiscordClient{}
+	handler := bot.NewHandler(mockClient, &MockBirthdayService{Birthdays: birthdays})
+
+	// Act
+	handler.HandleSlashCommand(nil, memberCommand("all", "1", ""))
+	handler.HandleMessageComponent(nil, buttonPress("all:page:1"))
+	handler.HandleMessageComponent(nil, buttonPress("all:page:2"))
+
+	// Assert
+	if len(mockClient.Responses) != 3 {
+		t.Fatalf("Expected 3 responses, got %d", len(mockClient.Responses))
+	}
+	tests := []struct {
+		wantType    discordgo.InteractionResponseType
+		wantFooter  string
+		wantFirst   string
+		wantButtons []string
+	}{
+		{discordgo.InteractionResponseChannelMessageWithSource, "Page 1 of 3", "• Person 00, January 1\n", []string{"all:page:-1 disabled=true", "all:page:1 disabled=false"}},
+		{discordgo.InteractionResponseUpdateMessage, "Page 2 of 3", "• Person 25, June 1\n", []string{"all:page:0 disabled=false", "all:page:2 disabled=false"}},
+		{discordgo.InteractionResponseUpdateMessage, "Page 3 of 3", "• Person 50, November 1\n", []string{"all:page:1 disabled=false", "all:page:3 disabled=true"}},
+	}
+	for i, tt := range tests {
+		response := mockClient.Responses[i]
+		if response.Type != tt.wantType {
+			t.Errorf("Response %d type = %v; want %v", i, response.Type, tt.wantType)
+		}
+		embed := response.Data.Embeds[0]
+		if embed.Footer == nil || embed.Footer.Text != tt.wantFooter {
+			t.Errorf("Response %d footer = %+v; want %q", i, embed.Footer, tt.wantFooter)
+		}
+		if !strings.HasPrefix(embed.Fields[0].Value, tt.wantFirst) {
+			t.Errorf("Response %d starts with %q; want %q", i, embed.Fields[0].Value, tt.wantFirst)
+		}
+		if states := pageButtonStates(t, response.Data); fmt.Sprint(states) != fmt.Sprint(tt.wantButtons) {
+			t.Errorf("Response %d buttons = %v; want %v", i, states, tt.wantButtons)
+		}
+	}
+}
+
+func TestHandleAllCommand_SinglePageHasNoButtons(t *testing.T) {
+	// Arrange
+	mockClient := &MockDiscordClient{}
+	handler := bot.NewHandler(mockClient, &MockBirthdayService{Birthdays: []database.Birthday{{Name: "Alice", Month: 1, Day: 25}}})
+
+	// Act
+	handler.HandleSlashCommand(nil, memberCommand("all", "1", ""))
+
+	// Assert
+	data := mockClient.Responses[0].Data
+	if len(data.Components) != 0 {
+		t.Errorf("Expected no buttons for a single page, got %+v", data.Components)
+	}
+	if data.Embeds[0].Footer != nil {
+		t.Errorf("Expected no page footer for a single page, got %+v", data.Embeds[0].Footer)
+	}
+}
+
+func historyCommand(permissions int64, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	interaction := configCommand(permissions, "")
+	interaction.Data = discordgo.ApplicationCommandInteractionData{
+		Name:    "history",
+		Options: options,
+	}
+	return interaction
+}
+
+func TestHandleHistoryCommand(t *testing.T) {
+	admin := int64(discordgo.PermissionManageServer)
+	ledger := []database.Announcement{
+		{GuildID: "guild-1", Date: "2025-06-10", Kind: database.AnnouncementBirthday, CreatedAt: time.Date(2025, 6, 10, 9, 0, 0, 0, time.UTC)},
+		{GuildID: "guild-1", Date: "2025-06-01", Kind: database.AnnouncementMonthly, CreatedAt: time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		name          string
+		interaction   *discordgo.InteractionCreate
+		announcements []database.Announcement
+		wantCalls     []string
+		wantContent   []string
+	}{
+		{"Default limit", historyCommand(admin), ledger, []string{"history 10"}, []string{"2025-06-10: birthday message", "2025-06-01: monthly roundup"}},
+		{"Custom limit", historyCommand(admin, intOption("limit", 1)), ledger[:1], []string{"history 1"}, []string{"2025-06-10"}},
+		{"Empty ledger", historyCommand(admin), nil, []string{"history 10"}, []string{"No announcements"}},
+		{"Limit too large", historyCommand(admin, intOption("limit", 500)), nil, nil, []string{"between 1 and 50"}},
+		{"Requires Manage Server", historyCommand(0), ledger, nil, []string{"Manage Server"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockClient := &MockDiscordClient{}
+			birthdayService := &MockBirthdayService{Announcements: tt.announcements}
+			handler := bot.NewHandler(mockClient, birthdayService)
+
+			// Act
+			handler.HandleSlashCommand(nil, tt.interaction)
+
+			// Assert
+			if fmt.Sprint(birthdayService.Calls) != fmt.Sprint(tt.wantCalls) {
+				t.Errorf("Service calls = %v; want %v", birthdayService.Calls, tt.wantCalls)
+			}
+			if len(mockClient.Responses) != 1 {
+				t.Fatalf("Expected 1 response, got %d", len(mockClient.Responses))
+			}
+			data := mockClient.Responses[0].Data
+			for _, want := range tt.wantContent {
+				if !strings.Contains(data.Content, want) {
+					t.Errorf("Response content = %q; want it to contain %q", data.Content, want)
+				}
+			}
+			if data.Flags&discordgo.MessageFlagsEphemeral == 0 {
+				t.Error("Expected history replies to be ephemeral")
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/nrzaman/baos-birthday-bot/internal/render"
+)
+
+// handleHistoryCommand lists the guild's most recent announcements from the
+// ledger. Only members with the Manage Server permission may use it.
+func (h *Handler) handleHistoryCommand(ctx context.Context, interaction *discordgo.Interaction) {
+	if !canManageServer(interaction) {
+		h.respond(interaction, "❌ You need the Manage Server permission to see the announcement history.", true)
+		return
+	}
+
+	limit := defaultHistoryLimit
+	options := optionMap(interaction.ApplicationCommandData().Options)
+	if opt, ok := options["limit"]; ok {
+		limit = int(opt.IntValue())
+	}
+	if limit < 1 || limit > maxHistoryLimit {
+		h.respond(interaction, fmt.Sprintf("❌ The limit must be between 1 and %d.", maxHistoryLimit), true)
+		return
+	}
+
+	fmt.Printf("Slash command: Listing announcement history for guild %s.\n", interaction.GuildID)
+	announcements, err := h.birthdayService.GetAnnouncementHistory(ctx, interaction.GuildID, limit)
+	if err != nil {
+		h.respondError(interaction, "get the announcement history", err)
+		return
+	}
+
+	h.respond(interaction, render.History(announcements), true)
+}
@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+	"github.com/nrzaman/baos-birthday-bot/internal/render"
+)
+
+// handleOverrideCommand dispatches the /override set, remove and list
+// subcommands. Only members with the Manage Server permission may use them.
+// Messages are previewed against a sample person before they are saved, like
+// templates.
+func (h *Handler) handleOverrideCommand(ctx context.Context, interaction *discordgo.Interaction) {
+	if !canManageServer(interaction) {
+		h.respond(interaction, "❌ You need the Manage Server permission to change custom messages.", true)
+		return
+	}
+
+	data := interaction.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		h.respond(interaction, "Unknown command", true)
+		return
+	}
+
+	subcommand := data.Options[0]
+	options := optionMap(subcommand.Options)
+	guildID := interaction.GuildID
+
+	var name string
+	if opt, ok := options["name"]; ok {
+		name = opt.StringValue()
+	}
+
+	switch subcommand.Name {
+	case "set":
+		month, day := dateValues(options)
+		message := options["message"].StringValue()
+		fmt.Printf("Slash command: Setting override on %d/%d for guild %s.\n", month, day, guildID)
+		preview, err := h.previewTemplates(ctx, guildID, database.AnnouncementBirthday, []string{message})
+		if err != nil {
+			h.respondError(interaction, "save the message", err)
+			return
+		}
+		if err := h.birthdayService.SetOverride(ctx, guildID, month, day, name, message); err != nil {
+			h.respond(interaction, birthdayErrorMessage("save the message", name, err), true)
+			return
+		}
+		h.respond(interaction, fmt.Sprintf("✅ On %s %d, %s will get this message instead of the usual one.\n\n%s",
+			time.Month(month), day, render.OverrideTarget(name), preview), true)
+
+	case "remove":
+		month, day := dateValues(options)
+		fmt.Printf("Slash command: Removing override on %d/%d for guild %s.\n", month, day, guildID)
+		err := h.birthdayService.RemoveOverride(ctx, guildID, month, day, name)
+		if errors.Is(err, database.ErrNotFound) {
+			h.respond(interaction, fmt.Sprintf("❌ There is no message for %s on %s %d", render.OverrideTarget(name), time.Month(month), day), true)
+			return
+		}
+		if err != nil {
+			h.respondError(interaction, "remove the message", err)
+			return
+		}
+		h.respond(interaction, fmt.Sprintf("✅ Removed the message for %s on %s %d", render.OverrideTarget(name), time.Month(month), day), true)
+
+	case "list":
+		overrides, err := h.birthdayService.GetOverrides(ctx, guildID)
+		if err != nil {
+			h.respondError(interaction, "list the custom messages", err)
+			return
+		}
+		h.respond(interaction, render.Overrides(overrides), true)
+
+	default:
+		h.respond(interaction, "Unknown command", true)
+	}
+}
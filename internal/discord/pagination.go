@@ -0,0 +1,111 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/nrzaman/baos-birthday-bot/internal/interfaces"
+	"github.com/nrzaman/baos-birthday-bot/internal/render"
+)
+
+// allPageSize is the most birthdays /all shows per page
+const allPageSize = 25
+
+// allPageLength is the most characters of birthday lines /all shows per page,
+// which keeps each page well under Discord's 6000 character limit for embeds
+// even with month headers, however long the names are
+const allPageLength = 3000
+
+// allPagePrefix starts the custom ID of the /all page buttons. It is followed
+// by the index of the page the button shows.
+const allPagePrefix = "all:page:"
+
+// allBirthdaysPage renders one page of /all, counting from 0, with buttons to
+// move between pages if there is more than one. Pages past the end show the last page.
+func (h *Handler) allBirthdaysPage(ctx context.Context, guildID string, page int) (render.Message, error) {
+	groups, err := h.birthdayService.GetBirthdaysByMonth(ctx, guildID)
+	if err != nil {
+		return render.Message{}, err
+	}
+	if len(groups) == 0 {
+		return render.Text("No birthdays configured!"), nil
+	}
+
+	pages := render.Paginate(groups, allPageSize, allPageLength)
+	if page >= len(pages) {
+		page = len(pages) - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+
+	message := h.renderer.AllBirthdays(pages[page], page+1, len(pages))
+	if len(pages) > 1 {
+		message.Components = pageButtons(page, len(pages))
+	}
+	return message, nil
+}
+
+// pageButtons returns Previous and Next buttons for a page of /all, disabled at either end
+func pageButtons(page, pages int) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "◀ Previous",
+					Style:    discordgo.SecondaryButton,
+					CustomID: allPagePrefix + strconv.Itoa(page-1),
+					Disabled: page == 0,
+				},
+				discordgo.Button{
+					Label:    "Next ▶",
+					Style:    discordgo.SecondaryButton,
+					CustomID: allPagePrefix + strconv.Itoa(page+1),
+					Disabled: page == pages-1,
+				},
+			},
+		},
+	}
+}
+
+// HandleMessageComponent processes button presses. The /all page buttons
+// replace the listing with the page they point to.
+func (h *Handler) HandleMessageComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionMessageComponent || i.GuildID == "" {
+		return
+	}
+	customID := i.MessageComponentData().CustomID
+	if !strings.HasPrefix(customID, allPagePrefix) {
+		return
+	}
+	page, err := strconv.Atoi(strings.TrimPrefix(customID, allPagePrefix))
+	if err != nil {
+		fmt.Printf("Error reading page button %q: %v\n", customID, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	fmt.Printf("Button: Showing page %d of all birthdays.\n", page+1)
+	message, err := h.allBirthdaysPage(ctx, i.GuildID, page)
+	if err != nil {
+		h.respondError(i.Interaction, "list birthdays", err)
+		return
+	}
+
+	err = interfaces.RespondChunked(h.client, i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    message.Content,
+			Embeds:     message.Embeds,
+			Components: message.Components,
+		},
+	})
+	if err != nil {
+		fmt.Printf("Error updating message: %v\n", err)
+	}
+}
@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+	"github.com/nrzaman/baos-birthday-bot/internal/render"
+)
+
+// handleRemindersCommand dispatches the /reminders set, off and show
+// subcommands. Only members with the Manage Server permission may use them.
+func (h *Handler) handleRemindersCommand(ctx context.Context, interaction *discordgo.Interaction) {
+	if !canManageServer(interaction) {
+		h.respond(interaction, "❌ You need the Manage Server permission to change birthday reminders.", true)
+		return
+	}
+
+	data := interaction.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		h.respond(interaction, "Unknown command", true)
+		return
+	}
+
+	subcommand := data.Options[0]
+	options := optionMap(subcommand.Options)
+	guildID := interaction.GuildID
+
+	switch subcommand.Name {
+	case "set":
+		days, err := parseReminderDays(options["days"].StringValue())
+		if err != nil {
+			h.respondError(interaction, "set up reminders", err)
+			return
+		}
+		settings := database.ReminderSettings{GuildID: guildID, Days: days}
+		if opt, ok := options["channel"]; ok {
+			settings.ChannelID = opt.ChannelValue(nil).ID
+		}
+		if opt, ok := options["role"]; ok {
+			settings.RoleID = opt.RoleValue(nil, "").ID
+		}
+
+		fmt.Printf("Slash command: Setting birthday reminders for guild %s.\n", guildID)
+		if err := h.birthdayService.SetReminderSettings(ctx, settings); err != nil {
+			h.respondError(interaction, "set up reminders", err)
+			return
+		}
+		h.showReminders(ctx, interaction, "✅ Reminders saved.\n\n")
+
+	case "off":
+		fmt.Printf("Slash command: Turning off birthday reminders for guild %s.\n", guildID)
+		if err := h.birthdayService.SetReminderSettings(ctx, database.ReminderSettings{GuildID: guildID}); err != nil {
+			h.respondError(interaction, "turn off reminders", err)
+			return
+		}
+		h.respond(interaction, "✅ Birthday reminders are off.", true)
+
+	case "show":
+		h.showReminders(ctx, interaction, "")
+
+	default:
+		h.respond(interaction, "Unknown command", true)
+	}
+}
+
+// showReminders replies with the guild's reminder settings after a prefix
+func (h *Handler) showReminders(ctx context.Context, interaction *discordgo.Interaction, prefix string) {
+	settings, err := h.birthdayService.GetReminderSettings(ctx, interaction.GuildID)
+	if err != nil {
+		h.respondError(interaction, "get the reminder settings", err)
+		return
+	}
+	h.respond(interaction, prefix+render.ReminderSettings(settings), true)
+}
+
+// parseReminderDays reads a list of numbers of days separated by commas or
+// spaces, e.g. "7, 1"
+func parseReminderDays(text string) ([]int, error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: give the days before a birthday to remind, e.g. 7, 1", database.ErrInvalidValue)
+	}
+
+	days := make([]int, len(fields))
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not a number of days", database.ErrInvalidValue, field)
+		}
+		days[i] = n
+	}
+	return days, nil
+}
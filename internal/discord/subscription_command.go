@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+	"github.com/nrzaman/baos-birthday-bot/internal/render"
+)
+
+// handleSubscribeCommand dispatches the person and all subcommands of
+// /subscribe, or of /unsubscribe if subscribe is false. They change the
+// caller's own birthday DMs, so anyone may use them.
+func (h *Handler) handleSubscribeCommand(ctx context.Context, interaction *discordgo.Interaction, subscribe bool) {
+	user := interactionUser(interaction)
+	if user == nil {
+		h.respond(interaction, "❌ Could not determine who you are", true)
+		return
+	}
+
+	data := interaction.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		h.respond(interaction, "Unknown command", true)
+		return
+	}
+
+	subcommand := data.Options[0]
+	var name string
+	switch subcommand.Name {
+	case "person":
+		var ok bool
+		if name, ok = h.subscriptionName(ctx, interaction, optionMap(subcommand.Options)); !ok {
+			return
+		}
+	case "all":
+		// An empty name is every birthday
+	default:
+		h.respond(interaction, "Unknown command", true)
+		return
+	}
+	target := render.SubscriptionTarget(name)
+
+	if !subscribe {
+		fmt.Printf("Slash command: Unsubscribing from birthday DMs for guild %s.\n", interaction.GuildID)
+		err := h.birthdayService.Unsubscribe(ctx, interaction.GuildID, user.ID, name)
+		if errors.Is(err, database.ErrNotFound) {
+			h.respond(interaction, "❌ You aren't subscribed to "+target, true)
+			return
+		}
+		if err != nil {
+			h.respondError(interaction, "unsubscribe", err)
+			return
+		}
+		h.respond(interaction, "✅ You'll no longer get a DM about "+target, true)
+		return
+	}
+
+	fmt.Printf("Slash command: Subscribing to birthday DMs for guild %s.\n", interaction.GuildID)
+	if err := h.birthdayService.Subscribe(ctx, interaction.GuildID, user.ID, name); err != nil {
+		h.respond(interaction, birthdayErrorMessage("subscribe", name, err), true)
+		return
+	}
+	h.respond(interaction, "✅ You'll get a DM the day before "+target+
+		". Make sure this server is allowed to send you direct messages; /subscriptions shows any that couldn't be sent.", true)
+}
+
+// subscriptionName returns the name of the birthday a person subcommand is
+// about, given by name or by the person's Discord account. If there is none it
+// tells the caller and returns false.
+func (h *Handler) subscriptionName(ctx context.Context, interaction *discordgo.Interaction, options map[string]*discordgo.ApplicationCommandInteractionDataOption) (string, bool) {
+	if opt, ok := options["user"]; ok {
+		user := opt.UserValue(nil)
+		b, err := h.birthdayService.GetBirthdayByDiscordID(ctx, interaction.GuildID, user.ID)
+		if err != nil {
+			h.respondError(interaction, "look up the birthday", err)
+			return "", false
+		}
+		if b == nil {
+			h.respond(interaction, fmt.Sprintf("❌ <@%s> hasn't saved a birthday in this server", user.ID), true)
+			return "", false
+		}
+		return b.Name, true
+	}
+
+	if opt, ok := options["name"]; ok && opt.StringValue() != "" {
+		return opt.StringValue(), true
+	}
+	h.respond(interaction, "❌ Give a name or a user", true)
+	return "", false
+}
+
+// handleSubscriptionsCommand shows the caller the birthdays they get a DM
+// about, and why the last DM could not be sent if it wasn't
+func (h *Handler) handleSubscriptionsCommand(ctx context.Context, interaction *discordgo.Interaction) {
+	user := interactionUser(interaction)
+	if user == nil {
+		h.respond(interaction, "❌ Could not determine who you are", true)
+		return
+	}
+
+	subscriptions, err := h.birthdayService.GetSubscriptions(ctx, interaction.GuildID, user.ID)
+	if err != nil {
+		h.respondError(interaction, "get your subscriptions", err)
+		return
+	}
+	h.respond(interaction, render.Subscriptions(subscriptions), true)
+}
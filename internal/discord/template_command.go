@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/nrzaman/baos-birthday-bot/internal/render"
+)
+
+// templateOptions are the options of /template set holding template variants, in order
+var templateOptions = []string{"text", "text2", "text3", "text4", "text5"}
+
+// handleTemplateCommand dispatches the /template set, preview and reset
+// subcommands. Only members with the Manage Server permission may use them.
+// Templates are previewed against a sample person before they are saved, so a
+// broken template is never used for an announcement.
+func (h *Handler) handleTemplateCommand(ctx context.Context, interaction *discordgo.Interaction) {
+	if !canManageServer(interaction) {
+		h.respond(interaction, "❌ You need the Manage Server permission to change announcement templates.", true)
+		return
+	}
+
+	data := interaction.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		h.respond(interaction, "Unknown command", true)
+		return
+	}
+
+	subcommand := data.Options[0]
+	options := optionMap(subcommand.Options)
+	guildID := interaction.GuildID
+	kind := options["kind"].StringValue()
+
+	switch subcommand.Name {
+	case "set":
+		var templates []string
+		for _, name := range templateOptions {
+			if opt, ok := options[name]; ok {
+				templates = append(templates, opt.StringValue())
+			}
+		}
+
+		fmt.Printf("Slash command: Setting %s templates for guild %s.\n", kind, guildID)
+		preview, err := h.previewTemplates(ctx, guildID, kind, templates)
+		if err != nil {
+			h.respondError(interaction, "save the template", err)
+			return
+		}
+		if err := h.birthdayService.SetTemplates(ctx, guildID, kind, templates); err != nil {
+			h.respondError(interaction, "save the template", err)
+			return
+		}
+		h.respond(interaction, "✅ Template saved.\n\n"+preview, true)
+
+	case "preview":
+		var templates []string
+		if opt, ok := options["text"]; ok {
+			templates = []string{opt.StringValue()}
+		} else {
+			saved, err := h.birthdayService.GetTemplates(ctx, guildID, kind)
+			if err != nil {
+				h.respondError(interaction, "preview the template", err)
+				return
+			}
+			templates = saved
+		}
+		if len(templates) == 0 {
+			templates = []string{render.DefaultTemplate(kind)}
+		}
+
+		preview, err := h.previewTemplates(ctx, guildID, kind, templates)
+		if err != nil {
+			h.respondError(interaction, "preview the template", err)
+			return
+		}
+		h.respond(interaction, preview, true)
+
+	case "reset":
+		fmt.Printf("Slash command: Resetting %s templates for guild %s.\n", kind, guildID)
+		if err := h.birthdayService.ResetTemplates(ctx, guildID, kind); err != nil {
+			h.respondError(interaction, "reset the template", err)
+			return
+		}
+		h.respond(interaction, "✅ The default template will be used.", true)
+
+	default:
+		h.respond(interaction, "Unknown command", true)
+	}
+}
+
+// previewTemplates renders templates against a sample person celebrating today in the guild
+func (h *Handler) previewTemplates(ctx context.Context, guildID, kind string, templates []string) (string, error) {
+	now, err := h.birthdayService.Now(ctx, guildID)
+	if err != nil {
+		return "", err
+	}
+	return render.TemplatePreviews(kind, templates, now)
+}
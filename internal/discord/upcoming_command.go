@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/nrzaman/baos-birthday-bot/internal/render"
+)
+
+// handleNextCommand shows the next birthdays in the guild, one by default
+func (h *Handler) handleNextCommand(ctx context.Context, interaction *discordgo.Interaction) {
+	count := 1
+	options := optionMap(interaction.ApplicationCommandData().Options)
+	if opt, ok := options["count"]; ok {
+		count = int(opt.IntValue())
+	}
+	if count < 1 || count > maxNextCount {
+		h.respond(interaction, fmt.Sprintf("❌ The count must be between 1 and %d.", maxNextCount), true)
+		return
+	}
+
+	fmt.Println("Slash command: Finding next birthday.")
+	upcoming, err := h.birthdayService.GetNextBirthdays(ctx, interaction.GuildID, count)
+	if err != nil {
+		h.respondError(interaction, "find the next birthday", err)
+		return
+	}
+
+	var message string
+	if count == 1 {
+		message = render.NextBirthday(upcoming)
+	} else {
+		message = render.UpcomingBirthdays("Next Birthdays", upcoming)
+	}
+	if message == "" {
+		message = "No upcoming birthdays found!"
+	}
+	h.respond(interaction, message, false)
+}
+
+// handleUpcomingCommand lists the birthdays in the guild from today until a
+// number of days from now, a week by default
+func (h *Handler) handleUpcomingCommand(ctx context.Context, interaction *discordgo.Interaction) {
+	days := defaultUpcomingDays
+	options := optionMap(interaction.ApplicationCommandData().Options)
+	if opt, ok := options["days"]; ok {
+		days = int(opt.IntValue())
+	}
+	if days < 1 || days > maxUpcomingDays {
+		h.respond(interaction, fmt.Sprintf("❌ The number of days must be between 1 and %d.", maxUpcomingDays), true)
+		return
+	}
+
+	fmt.Printf("Slash command: Listing birthdays in the next %d days.\n", days)
+	upcoming, err := h.birthdayService.GetUpcomingBirthdays(ctx, interaction.GuildID, days)
+	if err != nil {
+		h.respondError(interaction, "list upcoming birthdays", err)
+		return
+	}
+
+	message := render.UpcomingBirthdays(upcomingTitle(days), upcoming)
+	if message == "" {
+		message = fmt.Sprintf("No birthdays in the next %d days!", days)
+	}
+	h.respond(interaction, message, false)
+}
+
+// upcomingTitle heads the /upcoming listing
+func upcomingTitle(days int) string {
+	if days == 7 {
+		return "Birthdays This Week"
+	}
+	return fmt.Sprintf("Birthdays in the Next %d Days", days)
+}
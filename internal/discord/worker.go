@@ -1,12 +1,17 @@
 package bot
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/bwmarrin/discordgo"
 	"github.com/nrzaman/baos-birthday-bot/internal/birthday"
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
 	"github.com/nrzaman/baos-birthday-bot/internal/interfaces"
+	"github.com/nrzaman/baos-birthday-bot/internal/leader"
+	"github.com/nrzaman/baos-birthday-bot/internal/render"
 )
 
 // Worker handles scheduled birthday checks
@@ -14,87 +19,435 @@ type Worker struct {
 	client          interfaces.DiscordClient
 	birthdayService birthday.BirthdayService
 	timeProvider    interfaces.TimeProvider
-	channelID       string
+	elector         leader.Elector
+	renderer        render.Renderer
+	leading         bool
 	stopChan        chan struct{}
+	rescheduleChan  chan struct{}
+	done            chan struct{}
 }
 
-// NewWorker creates a new Worker with the given dependencies
-func NewWorker(client interfaces.DiscordClient, birthdayService birthday.BirthdayService, timeProvider interfaces.TimeProvider, channelID string) *Worker {
+// NewWorker creates a new Worker with the given dependencies. The announcement
+// channel, time and timezone are read from each guild's settings, and
+// announcements are rendered as embeds.
+func NewWorker(client interfaces.DiscordClient, birthdayService birthday.BirthdayService, timeProvider interfaces.TimeProvider) *Worker {
 	return &Worker{
 		client:          client,
 		birthdayService: birthdayService,
 		timeProvider:    timeProvider,
-		channelID:       channelID,
+		renderer:        render.Embeds{},
 		stopChan:        make(chan struct{}),
+		rescheduleChan:  make(chan struct{}, 1),
+		done:            make(chan struct{}),
 	}
 }
 
-// Start begins the worker's scheduled tasks
-func (w *Worker) Start() {
-	// Get duration until next day at 9am
-	currentTime := w.timeProvider.Now()
-	fmt.Println("Current time is: " + currentTime.String())
+// SetElector makes the worker announce only while this replica is the leader,
+// so several replicas can run while announcements are still posted once. All
+// replicas keep answering slash commands.
+func (w *Worker) SetElector(elector leader.Elector) {
+	w.elector = elector
+}
 
-	newTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), 9, 0, 0, 0, currentTime.Location())
-	fmt.Println("New time is: " + newTime.String())
+// SetRenderer changes how announcements are rendered
+func (w *Worker) SetRenderer(renderer render.Renderer) {
+	w.renderer = renderer
+}
 
-	duration := newTime.Sub(currentTime)
-	fmt.Println("Duration is: " + duration.String())
+// stopTimeout is how long Stop waits for the worker to give up leadership
+const stopTimeout = 5 * time.Second
 
-	// If the new time happened before the current time, wait until tomorrow at the same time
-	if duration < 0 {
-		newTime = newTime.Add(24 * time.Hour)
-		duration = newTime.Sub(currentTime)
-		fmt.Println("New duration is: " + duration.String())
-	}
+// maxSleep caps how long the worker sleeps between checks, so a suspended host
+// or a wall clock change is noticed within the hour
+const maxSleep = time.Hour
+
+// retryDelay is how long the worker waits before retrying a failed announcement
+const retryDelay = 5 * time.Minute
+
+// Start begins the worker's scheduled tasks. Each guild is announced once a day
+// at its configured wall-clock time in its own timezone. The next run is
+// recomputed from the current time on every iteration, so DST changes don't
+// shift it, and a guild whose announcement was missed today (e.g. because the
+// bot was down) is announced as soon as the worker starts.
+func (w *Worker) Start() {
+	defer close(w.done)
+	defer w.releaseLeadership()
+
+	// Stopping cancels any announcement in progress
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-w.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
 
 	for {
+		wait := maxSleep
+		if w.isLeader() {
+			wait = w.runDue(ctx)
+		}
+		if w.elector != nil && wait > w.elector.RenewInterval() {
+			// Keep the lease, or keep trying to take it over
+			wait = w.elector.RenewInterval()
+		} else {
+			fmt.Println("Next announcement check is at: " + w.timeProvider.Now().Add(wait).String())
+		}
+
 		select {
 		case <-w.stopChan:
 			return
-		case <-time.After(duration):
-			// Reset until the same time tomorrow
-			duration = 24 * time.Hour
-			w.performDailyCheck()
+		case <-w.rescheduleChan:
+			// Settings changed, so recompute the next run
+		case <-w.timeProvider.After(wait):
 		}
 	}
 }
 
-// Stop stops the worker
+// Stop stops the worker, giving up leadership if it holds it
 func (w *Worker) Stop() {
 	close(w.stopChan)
+	select {
+	case <-w.done:
+	case <-time.After(stopTimeout):
+		// The worker was never started or is stuck; its lease will expire
+	}
+}
+
+// isLeader reports whether this replica should run announcements. Without an
+// elector it always should.
+func (w *Worker) isLeader() bool {
+	if w.elector == nil {
+		return true
+	}
+
+	leading, err := w.elector.Acquire()
+	if err != nil {
+		fmt.Printf("Error acquiring leadership: %v\n", err)
+		leading = false
+	}
+	if leading != w.leading {
+		if leading {
+			fmt.Println("This replica is now the leader and will post announcements.")
+		} else {
+			fmt.Println("This replica is no longer the leader.")
+		}
+		w.leading = leading
+	}
+	return leading
+}
+
+// releaseLeadership lets another replica take over right away when this one stops
+func (w *Worker) releaseLeadership() {
+	if w.elector == nil || !w.leading {
+		return
+	}
+	if err := w.elector.Release(); err != nil {
+		fmt.Printf("Error releasing leadership: %v\n", err)
+	}
+	w.leading = false
 }
 
-// performDailyCheck performs the daily birthday check and sends messages
-func (w *Worker) performDailyCheck() {
+// Reschedule makes the worker re-read guild settings and recompute its next run
+func (w *Worker) Reschedule() {
+	select {
+	case w.rescheduleChan <- struct{}{}:
+	default:
+		// A reschedule is already pending
+	}
+}
+
+// runDue announces every guild that is due and returns how long to wait before
+// the next check
+func (w *Worker) runDue(ctx context.Context) time.Duration {
 	now := w.timeProvider.Now()
+	guilds, err := w.birthdayService.GetGuilds(ctx)
+	if err != nil {
+		fmt.Printf("Error getting guilds: %v\n", err)
+		return retryDelay
+	}
 
+	wait := maxSleep
+	for _, guild := range guilds {
+		if guild.ChannelID == "" {
+			continue
+		}
+
+		local := now.In(guild.Location())
+		if isDue(guild, local) {
+			if err := w.announce(ctx, guild.GuildID, guild.ChannelID, local); err != nil {
+				fmt.Printf("Error announcing for guild %s, retrying in %s: %v\n", guild.GuildID, retryDelay, err)
+				if retryDelay < wait {
+					wait = retryDelay
+				}
+				continue
+			}
+			if err := w.birthdayService.MarkAnnounced(ctx, guild.GuildID, local); err != nil {
+				fmt.Printf("Error recording announcement for guild %s: %v\n", guild.GuildID, err)
+			}
+		}
+
+		if until := nextAnnouncement(guild, local).Sub(now); until < wait {
+			wait = until
+		}
+	}
+
+	return wait
+}
+
+// isDue reports whether a guild's announcement time has passed today (in its
+// timezone) without a successful announcement having been recorded for today
+func isDue(guild database.Guild, local time.Time) bool {
+	today := local.Format(database.DateLayout)
+	if guild.LastAnnouncedOn >= today {
+		return false
+	}
+	scheduled := time.Date(local.Year(), local.Month(), local.Day(), guild.Hour, guild.Minute, 0, 0, local.Location())
+	return !local.Before(scheduled)
+}
+
+// nextAnnouncement returns the first time after now at which the guild's
+// announcement time of day occurs in its timezone. time.Date works in wall-clock
+// terms, so the result stays at the same local time across DST changes.
+func nextAnnouncement(guild database.Guild, now time.Time) time.Time {
+	local := now.In(guild.Location())
+	at := time.Date(local.Year(), local.Month(), local.Day(), guild.Hour, guild.Minute, 0, 0, local.Location())
+	if !at.After(local) {
+		at = time.Date(local.Year(), local.Month(), local.Day()+1, guild.Hour, guild.Minute, 0, 0, local.Location())
+	}
+	return at
+}
+
+// announce sends the monthly and birthday messages for one guild to its channel.
+// now is the current time in the guild's timezone.
+func (w *Worker) announce(ctx context.Context, guildID, channelID string, now time.Time) error {
 	// List the monthly birthdays if it is the first of the month
 	if now.Day() == 1 {
-		var buffer bytes.Buffer
-		response := w.birthdayService.ListCurrentMonthBirthdays()
-		buffer.WriteString("Happy ")
-		// Special handling for January to account for New Year's messaging
-		if now.Month() == 1 {
-			buffer.WriteString("New Year and January! 🎊 ")
-		} else {
-			buffer.WriteString(now.Month().String() + "! 🙌 ")
+		group, err := w.birthdayService.GetMonthBirthdays(ctx, guildID)
+		if err != nil {
+			return fmt.Errorf("failed to get monthly birthdays: %w", err)
 		}
-		if response != "" {
-			buffer.WriteString("\nBelow are all of the birthdays this month:\n" + response)
-		} else {
-			buffer.WriteString("\nThere are no birthdays this month. See you next month! 🫡")
+		template, err := w.birthdayService.PickTemplate(ctx, guildID, database.AnnouncementMonthly)
+		if err != nil {
+			return fmt.Errorf("failed to get monthly template: %w", err)
 		}
-		if err := w.client.SendMessage(w.channelID, buffer.String()); err != nil {
-			fmt.Printf("Error sending monthly birthday message: %v\n", err)
+		message := w.renderer.MonthlyRoundup(group, template)
+		err = w.sendChunkedOnce(ctx, guildID, now, database.AnnouncementMonthly, channelID, &discordgo.MessageSend{
+			Content:         message.Content,
+			Embeds:          message.Embeds,
+			AllowedMentions: interfaces.UserMentions(nil),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to send monthly birthday message: %w", err)
 		}
 	}
 
 	// Posts a birthday message if today is a birthday
-	birthdayMessage := w.birthdayService.GetBirthdayMessage()
-	if len(birthdayMessage) > 0 {
-		if err := w.client.SendMessage(w.channelID, birthdayMessage); err != nil {
-			fmt.Printf("Error sending birthday message: %v\n", err)
+	birthdays, err := w.birthdayService.GetBirthdaysToday(ctx, guildID)
+	if err != nil {
+		return fmt.Errorf("failed to get today's birthdays: %w", err)
+	}
+	if len(birthdays) > 0 {
+		templates, err := w.birthdayService.GetBirthdayTemplates(ctx, guildID, birthdays)
+		if err != nil {
+			return fmt.Errorf("failed to get birthday templates: %w", err)
+		}
+		message := w.renderer.BirthdayAnnouncement(birthdays, now, w.avatars(birthdays), templates)
+		err = w.sendChunkedOnce(ctx, guildID, now, database.AnnouncementBirthday, channelID, &discordgo.MessageSend{
+			Content:         message.Content,
+			Embeds:          message.Embeds,
+			AllowedMentions: interfaces.UserMentions(birthdayMentions(birthdays)),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to send birthday message: %w", err)
+		}
+	}
+
+	// Reminds organizers of the birthdays coming up
+	if err := w.remind(ctx, guildID, now); err != nil {
+		return fmt.Errorf("failed to send birthday reminder: %w", err)
+	}
+
+	// Tells subscribers about the birthdays tomorrow
+	if err := w.notify(ctx, guildID, now); err != nil {
+		return fmt.Errorf("failed to send birthday DMs: %w", err)
+	}
+	return nil
+}
+
+// remind sends the guild's advance reminders that are due today to its
+// organizers channel. They are never posted in the announcement channel, where
+// the people having a birthday would see them, so settings saved without a
+// channel send nothing. Only the reminder role is notified; the people having a
+// birthday are named but never mentioned. A reminder leaves the role out if one
+// of the people having a birthday holds it, so they aren't pinged about their
+// own surprise.
+func (w *Worker) remind(ctx context.Context, guildID string, now time.Time) error {
+	reminders, err := w.birthdayService.GetDueReminders(ctx, guildID)
+	if err != nil || len(reminders) == 0 {
+		return err
+	}
+	settings, err := w.birthdayService.GetReminderSettings(ctx, guildID)
+	if err != nil {
+		return err
+	}
+	if settings.ChannelID == "" {
+		fmt.Printf("Skipping birthday reminders for guild %s: no organizers channel is set.\n", guildID)
+		return nil
+	}
+
+	for _, reminder := range reminders {
+		roleID := settings.RoleID
+		if roleID != "" && w.celebrantHoldsRole(guildID, reminder, roleID) {
+			roleID = ""
+		}
+		var roleIDs []string
+		if roleID != "" {
+			roleIDs = []string{roleID}
+		}
+		message := w.renderer.Reminder(reminder, roleID)
+		err := w.sendChunkedOnce(ctx, guildID, now, database.ReminderKind(reminder.DaysBefore), settings.ChannelID, &discordgo.MessageSend{
+			Content:         message.Content,
+			Embeds:          message.Embeds,
+			AllowedMentions: interfaces.RoleMentions(roleIDs),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// celebrantHoldsRole reports whether any of the people in a reminder is a
+// member of the guild with the role. People who can't be looked up (e.g.
+// because they left the guild) are taken not to hold it.
+func (w *Worker) celebrantHoldsRole(guildID string, reminder birthday.Reminder, roleID string) bool {
+	for _, b := range reminder.Birthdays {
+		if b.DiscordID == nil || *b.DiscordID == "" {
+			continue
+		}
+		member, err := w.client.GuildMember(guildID, *b.DiscordID)
+		if err != nil {
+			fmt.Printf("Error looking up Discord member %s in guild %s: %v\n", *b.DiscordID, guildID, err)
+			continue
+		}
+		for _, id := range member.Roles {
+			if id == roleID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// notify DMs each subscriber about the birthdays tomorrow they subscribed to.
+// The DMs are claimed together, so a DM that can't be sent (e.g. because the
+// subscriber doesn't accept DMs) is not retried; the failure is recorded for
+// the subscriber to see in /subscriptions instead.
+func (w *Worker) notify(ctx context.Context, guildID string, now time.Time) error {
+	notifications, err := w.birthdayService.GetDueNotifications(ctx, guildID)
+	if err != nil || len(notifications) == 0 {
+		return err
+	}
+
+	return w.sendOnce(ctx, guildID, now, database.AnnouncementNotifications, func() error {
+		for _, notification := range notifications {
+			failure := ""
+			if err := w.client.SendDirectMessage(notification.UserID, render.Notification(notification)); err != nil {
+				fmt.Printf("Error sending birthday DM to user %s in guild %s: %v\n", notification.UserID, guildID, err)
+				failure = deliveryFailure(err)
+			}
+			if err := w.birthdayService.RecordNotification(ctx, guildID, notification.UserID, failure); err != nil {
+				fmt.Printf("Error recording birthday DM to user %s in guild %s: %v\n", notification.UserID, guildID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// deliveryFailure explains to a subscriber why their DM could not be sent
+func deliveryFailure(err error) string {
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeCannotSendMessagesToThisUser {
+		return "Discord didn't let the bot message you. Allow direct messages from server members in this server's privacy settings."
+	}
+	return err.Error()
+}
+
+// sendOnce claims an announcement in the ledger and then sends it, so it is
+// posted at most once per day even if the bot restarts or several replicas run.
+// If sending fails the claim is released so the next attempt can retry it.
+func (w *Worker) sendOnce(ctx context.Context, guildID string, now time.Time, kind string, send func() error) error {
+	claimed, err := w.birthdayService.ClaimAnnouncement(ctx, guildID, now, kind)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		fmt.Printf("Skipping %s announcement for guild %s: already sent today\n", kind, guildID)
+		return nil
+	}
+
+	if err := send(); err != nil {
+		if releaseErr := w.birthdayService.ReleaseAnnouncement(ctx, guildID, now, kind); releaseErr != nil {
+			fmt.Printf("Error releasing %s announcement for guild %s: %v\n", kind, guildID, releaseErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// sendChunkedOnce sends an announcement to a channel with sendOnce, split into
+// as many messages as Discord's limits need. Each message delivered is
+// recorded, so if one fails the retry resumes with it instead of posting the
+// earlier ones again. That relies on the retry rendering the same text, which
+// is why PickTemplate picks the same variant all day; avatars only change
+// thumbnails, which don't count towards the limits.
+func (w *Worker) sendChunkedOnce(ctx context.Context, guildID string, now time.Time, kind, channelID string, data *discordgo.MessageSend) error {
+	return w.sendOnce(ctx, guildID, now, kind, func() error {
+		sent, err := w.birthdayService.GetAnnouncementProgress(ctx, guildID, now, kind)
+		if err != nil {
+			return err
+		}
+		messages := interfaces.ChunkMessage(data)
+		for i := sent; i < len(messages); i++ {
+			if err := w.client.SendComplexMessage(channelID, messages[i]); err != nil {
+				return err
+			}
+			if err := w.birthdayService.SetAnnouncementProgress(ctx, guildID, now, kind, i+1); err != nil {
+				fmt.Printf("Error recording %s announcement progress for guild %s: %v\n", kind, guildID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// avatars looks up the avatar of everyone with a linked Discord account. People
+// whose account can't be looked up are announced without one.
+func (w *Worker) avatars(birthdays []database.Birthday) map[string]string {
+	avatars := make(map[string]string)
+	for _, b := range birthdays {
+		if b.DiscordID == nil || *b.DiscordID == "" {
+			continue
+		}
+		user, err := w.client.User(*b.DiscordID)
+		if err != nil {
+			fmt.Printf("Error looking up Discord user %s: %v\n", *b.DiscordID, err)
+			continue
+		}
+		avatars[*b.DiscordID] = user.AvatarURL("128")
+	}
+	return avatars
+}
+
+// birthdayMentions returns the Discord user IDs of the people having a birthday
+func birthdayMentions(birthdays []database.Birthday) []string {
+	var mentionIDs []string
+	for _, b := range birthdays {
+		if b.DiscordID != nil && *b.DiscordID != "" {
+			mentionIDs = append(mentionIDs, *b.DiscordID)
 		}
 	}
+	return mentionIDs
 }
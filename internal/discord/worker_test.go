@@ -0,0 +1,677 @@
+package bot_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/nrzaman/baos-birthday-bot/internal/birthday"
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+	bot "github.com/nrzaman/baos-birthday-bot/internal/discord"
+	"github.com/nrzaman/baos-birthday-bot/internal/render"
+)
+
+// FakeClock is a TimeProvider that only moves when the test advances it. Each
+// call to After reports the requested wait on Waits and blocks until the test
+// fires it.
+type FakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	Waits chan time.Duration
+	fire  chan time.Time
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{
+		now:   now,
+		Waits: make(chan time.Duration),
+		fire:  make(chan time.Time),
+	}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) Month() time.Month {
+	return c.Now().Month()
+}
+
+func (c *FakeClock) Day() int {
+	return c.Now().Day()
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.Waits <- d
+	return c.fire
+}
+
+// Advance moves the clock forward by d and wakes the waiting worker
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+	c.mu.Unlock()
+	c.fire <- now
+}
+
+// Guild and channel the worker tests announce to
+const (
+	testGuildID     = "guild-1"
+	workerChannelID = "announce-channel"
+)
+
+var ctx = context.Background()
+
+// startWorker runs a worker against an in-memory database for a guild in
+// timezone tz announcing at 09:00, and returns the first wait it requested
+func startWorker(t *testing.T, clock *FakeClock, client *MockDiscordClient, tz, lastAnnounced string, birthdays map[string][2]int) (*bot.Worker, time.Duration) {
+	t.Helper()
+	db := setupWorkerDB(t, tz, lastAnnounced, birthdays)
+	return startWorkerWithDB(t, clock, client, db)
+}
+
+// setupWorkerDB creates an in-memory database for a guild in timezone tz
+// announcing at 09:00
+func setupWorkerDB(t *testing.T, tz, lastAnnounced string, birthdays map[string][2]int) *database.DB {
+	t.Helper()
+	db, err := database.New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close() // Best effort close in tests
+	})
+
+	guild := database.Guild{GuildID: testGuildID, ChannelID: workerChannelID, Timezone: tz, Hour: 9}
+	if err := db.UpsertGuild(ctx, guild); err != nil {
+		t.Fatalf("Failed to save guild: %v", err)
+	}
+	if lastAnnounced != "" {
+		if err := db.SetGuildLastAnnounced(ctx, testGuildID, lastAnnounced); err != nil {
+			t.Fatalf("Failed to set last announced date: %v", err)
+		}
+	}
+	for name, date := range birthdays {
+		if err := db.AddBirthday(ctx, testGuildID, name, date[0], date[1], nil, false, nil, nil); err != nil {
+			t.Fatalf("Failed to add birthday: %v", err)
+		}
+	}
+
+	return db
+}
+
+// addSubscription subscribes a user to the named birthday in the test guild,
+// or to every birthday if name is empty
+func addSubscription(t *testing.T, db *database.DB, userID, name string) {
+	t.Helper()
+	var birthdayID *int
+	if name != "" {
+		b, err := db.GetBirthday(ctx, testGuildID, name)
+		if err != nil || b == nil {
+			t.Fatalf("Failed to get birthday for %s: %v", name, err)
+		}
+		birthdayID = &b.ID
+	}
+	if err := db.AddSubscription(ctx, testGuildID, userID, birthdayID); err != nil {
+		t.Fatalf("Failed to add subscription: %v", err)
+	}
+}
+
+// startWorkerWithDB runs a worker against db and returns the first wait it requested
+func startWorkerWithDB(t *testing.T, clock *FakeClock, client *MockDiscordClient, db *database.DB) (*bot.Worker, time.Duration) {
+	t.Helper()
+	worker := bot.NewWorker(client, birthday.NewServiceDB(clock, db), clock)
+	worker.SetRenderer(render.PlainText{})
+	go worker.Start()
+	wait := <-clock.Waits
+	t.Cleanup(worker.Stop)
+	return worker, wait
+}
+
+func TestWorkerAnnouncesAtLocalTimeAcrossDST(t *testing.T) {
+	// Arrange
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	tests := []struct {
+		name  string
+		start time.Time
+		end   time.Time
+		days  map[string][2]int
+	}{
+		{
+			name:  "spring forward",
+			start: time.Date(2025, time.March, 7, 12, 0, 0, 0, ny),
+			end:   time.Date(2025, time.March, 11, 12, 0, 0, 0, ny),
+			days:  map[string][2]int{"Before": {3, 8}, "During": {3, 9}, "After": {3, 10}},
+		},
+		{
+			name:  "fall back",
+			start: time.Date(2025, time.October, 31, 12, 0, 0, 0, ny),
+			end:   time.Date(2025, time.November, 4, 12, 0, 0, 0, ny),
+			days:  map[string][2]int{"Before": {11, 1}, "During": {11, 2}, "After": {11, 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewFakeClock(tt.start)
+			client := &MockDiscordClient{}
+			_, wait := startWorker(t, clock, client, "America/New_York", tt.start.Format(database.DateLayout), tt.days)
+
+			// Act
+			sentAt := map[string][]time.Time{}
+			for clock.Now().Before(tt.end) {
+				sent := len(client.SentMessages)
+				clock.Advance(wait)
+				wait = <-clock.Waits
+				for _, msg := range client.SentMessages[sent:] {
+					for name := range tt.days {
+						if strings.Contains(msg.Message, "**"+name+"'s birthday**") {
+							sentAt[name] = append(sentAt[name], clock.Now().In(ny))
+						}
+					}
+				}
+			}
+
+			// Assert
+			for name := range tt.days {
+				times := sentAt[name]
+				if len(times) != 1 {
+					t.Errorf("Expected %s to be announced once, got %d", name, len(times))
+					continue
+				}
+				if times[0].Hour() != 9 || times[0].Minute() != 0 {
+					t.Errorf("Expected %s to be announced at 09:00 local, got %s", name, times[0].Format("15:04 MST"))
+				}
+			}
+		})
+	}
+}
+
+func TestWorkerCatchesUpMissedAnnouncement(t *testing.T) {
+	// Arrange: the bot starts at 11:00 and yesterday was the last announcement
+	clock := NewFakeClock(time.Date(2025, time.June, 10, 11, 0, 0, 0, time.UTC))
+	client := &MockDiscordClient{}
+
+	// Act
+	startWorker(t, clock, client, "UTC", "2025-06-09", map[string][2]int{"Bob": {6, 10}})
+
+	// Assert
+	if len(client.SentMessages) != 1 || !strings.Contains(client.SentMessages[0].Message, "Bob") {
+		t.Errorf("Expected Bob's birthday to be announced on startup, got %+v", client.SentMessages)
+	}
+}
+
+func TestWorkerAnnouncesWithEmbeds(t *testing.T) {
+	// Arrange: Bob's Discord account is linked, Alice's is not
+	clock := NewFakeClock(time.Date(2025, time.June, 10, 11, 0, 0, 0, time.UTC))
+	client := &MockDiscordClient{}
+	db := setupWorkerDB(t, "UTC", "2025-06-09", map[string][2]int{"Alice": {6, 10}})
+	bobID := "42"
+	if err := db.AddBirthday(ctx, testGuildID, "Bob", 6, 10, nil, false, nil, &bobID); err != nil {
+		t.Fatalf("Failed to add birthday: %v", err)
+	}
+	worker := bot.NewWorker(client, birthday.NewServiceDB(clock, db), clock)
+
+	// Act
+	go worker.Start()
+	<-clock.Waits
+	t.Cleanup(worker.Stop)
+
+	// Assert
+	if len(client.SentMessages) != 1 {
+		t.Fatalf("Expected 1 message, got %+v", client.SentMessages)
+	}
+	sent := client.SentMessages[0]
+	if sent.Message != "<@42>" {
+		t.Errorf("Expected the content to mention Bob, got %q", sent.Message)
+	}
+	if len(sent.Embeds) != 2 {
+		t.Fatalf("Expected an embed per person, got %d", len(sent.Embeds))
+	}
+	for _, embed := range sent.Embeds {
+		linked := strings.Contains(embed.Description, "<@42>")
+		if linked && (embed.Thumbnail == nil || !strings.Contains(embed.Thumbnail.URL, "avatar-42")) {
+			t.Errorf("Expected Bob's embed to show his avatar, got %+v", embed.Thumbnail)
+		}
+		if !linked && embed.Thumbnail != nil {
+			t.Errorf("Expected no avatar for Alice, got %+v", embed.Thumbnail)
+		}
+	}
+}
+
+func TestWorkerUsesGuildTemplates(t *testing.T) {
+	// Arrange: the first of the month is also Bob's birthday
+	clock := NewFakeClock(time.Date(2025, time.June, 1, 11, 0, 0, 0, time.UTC))
+	client := &MockDiscordClient{}
+	db := setupWorkerDB(t, "UTC", "2025-05-31", map[string][2]int{"Bob": {6, 1}})
+	if err := db.SetTemplates(ctx, testGuildID, database.AnnouncementMonthly, []string{"Welcome to {{month}}!"}); err != nil {
+		t.Fatalf("Failed to set templates: %v", err)
+	}
+	variants := []string{"Cheers to {{name}}!", "Hooray for {{name}}!"}
+	if err := db.SetTemplates(ctx, testGuildID, database.AnnouncementBirthday, variants); err != nil {
+		t.Fatalf("Failed to set templates: %v", err)
+	}
+
+	// Act
+	startWorkerWithDB(t, clock, client, db)
+
+	// Assert
+	if len(client.SentMessages) != 2 {
+		t.Fatalf("Expected the roundup and the birthday message, got %+v", client.SentMessages)
+	}
+	if roundup := client.SentMessages[0].Message; !strings.HasPrefix(roundup, "Welcome to June!") {
+		t.Errorf("Expected the roundup to use the guild's greeting, got %q", roundup)
+	}
+	if message := client.SentMessages[1].Message; message != "Cheers to Bob!\n" && message != "Hooray for Bob!\n" {
+		t.Errorf("Expected one of the guild's birthday templates, got %q", message)
+	}
+}
+
+func TestWorkerSendsReminders(t *testing.T) {
+	// Arrange: Bob's birthday is a week away and Alice's is tomorrow; reminders
+	// go to the organizers channel and mention the organizers role
+	clock := NewFakeClock(time.Date(2025, time.June, 3, 11, 0, 0, 0, time.UTC))
+	client := &MockDiscordClient{}
+	db := setupWorkerDB(t, "UTC", "2025-06-02", map[string][2]int{"Alice": {6, 4}})
+	bobID := "42"
+	if err := db.AddBirthday(ctx, testGuildID, "Bob", 6, 10, nil, false, nil, &bobID); err != nil {
+		t.Fatalf("Failed to add birthday: %v", err)
+	}
+	settings := database.ReminderSettings{GuildID: testGuildID, ChannelID: "organizers", RoleID: "888", Days: []int{7, 1}}
+	if err := db.SetReminderSettings(ctx, settings); err != nil {
+		t.Fatalf("Failed to set reminder settings: %v", err)
+	}
+
+	// Act
+	startWorkerWithDB(t, clock, client, db)
+
+	// Assert
+	if len(client.SentMessages) != 2 {
+		t.Fatalf("Expected a reminder for each day, got %+v", client.SentMessages)
+	}
+	for i, want := range []string{"Birthday in 7 days:**\n\n• Bob, June 10", "Birthday tomorrow:**\n\n• Alice, June 4"} {
+		sent := client.SentMessages[i]
+		if sent.ChannelID != "organizers" || !strings.HasPrefix(sent.Message, "<@&888>") || !strings.Contains(sent.Message, want) {
+			t.Errorf("Reminder %d = %+v; want it in the organizers channel, mentioning the role, with %q", i, sent, want)
+		}
+		if strings.Contains(sent.Message, "<@42>") || len(sent.Mentions.Users) != 0 || fmt.Sprint(sent.Mentions.Roles) != "[888]" {
+			t.Errorf("Reminder %d should only notify the role, got %q with mentions %+v", i, sent.Message, sent.Mentions)
+		}
+	}
+
+	// A restart on the same day doesn't send the reminders again
+	if err := db.SetGuildLastAnnounced(ctx, testGuildID, "2025-06-02"); err != nil {
+		t.Fatalf("Failed to set last announced date: %v", err)
+	}
+	restarted := &MockDiscordClient{}
+	startWorkerWithDB(t, clock, restarted, db)
+	if len(restarted.SentMessages) != 0 {
+		t.Errorf("Expected no duplicate reminders, got %+v", restarted.SentMessages)
+	}
+}
+
+func TestWorkerLeavesOutTheRoleWhenACelebrantHoldsIt(t *testing.T) {
+	// Arrange: Bob, whose birthday is a week away, is one of the organizers
+	clock := NewFakeClock(time.Date(2025, time.June, 3, 11, 0, 0, 0, time.UTC))
+	client := &MockDiscordClient{MemberRoles: map[string][]string{"42": {"777", "888"}}}
+	db := setupWorkerDB(t, "UTC", "2025-06-02", map[string][2]int{"Alice": {6, 4}})
+	bobID := "42"
+	if err := db.AddBirthday(ctx, testGuildID, "Bob", 6, 10, nil, false, nil, &bobID); err != nil {
+		t.Fatalf("Failed to add birthday: %v", err)
+	}
+	settings := database.ReminderSettings{GuildID: testGuildID, ChannelID: "organizers", RoleID: "888", Days: []int{7, 1}}
+	if err := db.SetReminderSettings(ctx, settings); err != nil {
+		t.Fatalf("Failed to set reminder settings: %v", err)
+	}
+
+	// Act
+	startWorkerWithDB(t, clock, client, db)
+
+	// Assert: Bob's reminder is still posted but pings nobody; Alice's pings the role
+	if len(client.SentMessages) != 2 {
+		t.Fatalf("Expected a reminder for each day, got %+v", client.SentMessages)
+	}
+	bob, alice := client.SentMessages[0], client.SentMessages[1]
+	if !strings.Contains(bob.Message, "Bob, June 10") || strings.Contains(bob.Message, "<@&888>") || len(bob.Mentions.Roles) != 0 {
+		t.Errorf("Expected Bob's reminder without the role, got %q with mentions %+v", bob.Message, bob.Mentions)
+	}
+	if !strings.HasPrefix(alice.Message, "<@&888>") || fmt.Sprint(alice.Mentions.Roles) != "[888]" {
+		t.Errorf("Expected Alice's reminder to mention the role, got %q with mentions %+v", alice.Message, alice.Mentions)
+	}
+}
+
+func TestWorkerSkipsRemindersWithoutAChannel(t *testing.T) {
+	// Arrange: settings saved before a channel was required only have a role;
+	// Alice's birthday is tomorrow
+	clock := NewFakeClock(time.Date(2025, time.June, 3, 11, 0, 0, 0, time.UTC))
+	client := &MockDiscordClient{}
+	db := setupWorkerDB(t, "UTC", "2025-06-02", map[string][2]int{"Alice": {6, 4}})
+	settings := database.ReminderSettings{GuildID: testGuildID, RoleID: "888", Days: []int{1}}
+	if err := db.SetReminderSettings(ctx, settings); err != nil {
+		t.Fatalf("Failed to set reminder settings: %v", err)
+	}
+
+	// Act
+	startWorkerWithDB(t, clock, client, db)
+
+	// Assert: nothing is posted, least of all in the announcement channel
+	if len(client.SentMessages) != 0 {
+		t.Errorf("Expected no reminders without an organizers channel, got %+v", client.SentMessages)
+	}
+}
+
+func TestWorkerSendsSubscriberDMs(t *testing.T) {
+	// Arrange: Alice's and Bob's birthdays are tomorrow and Carol's is next week.
+	// One member follows everyone, Alice follows everyone but is not told about
+	// her own birthday, and another member only follows Carol.
+	clock := NewFakeClock(time.Date(2025, time.June, 3, 11, 0, 0, 0, time.UTC))
+	client := &MockDiscordClient{}
+	db := setupWorkerDB(t, "UTC", "2025-06-02", map[string][2]int{"Bob": {6, 4}, "Carol": {6, 10}})
+	aliceID := "7"
+	if err := db.AddBirthday(ctx, testGuildID, "Alice", 6, 4, nil, false, nil, &aliceID); err != nil {
+		t.Fatalf("Failed to add birthday: %v", err)
+	}
+	for _, s := range [][2]string{{"5", ""}, {aliceID, ""}, {"9", "Carol"}} {
+		addSubscription(t, db, s[0], s[1])
+	}
+
+	// Act
+	startWorkerWithDB(t, clock, client, db)
+
+	// Assert
+	if len(client.SentMessages) != 2 {
+		t.Fatalf("Expected a DM for each subscriber with a birthday tomorrow, got %+v", client.SentMessages)
+	}
+	for i, want := range []struct{ channelID, content string }{
+		{"dm-5", "Birthdays tomorrow:**\n\n• Alice, June 4\n• Bob, June 4"},
+		{"dm-7", "Birthday tomorrow:**\n\n• Bob, June 4"},
+	} {
+		if sent := client.SentMessages[i]; sent.ChannelID != want.channelID || !strings.Contains(sent.Message, want.content) {
+			t.Errorf("DM %d = %+v; want %q sent to %s", i, sent, want.content, want.channelID)
+		}
+	}
+
+	// A restart on the same day doesn't send the DMs again
+	if err := db.SetGuildLastAnnounced(ctx, testGuildID, "2025-06-02"); err != nil {
+		t.Fatalf("Failed to set last announced date: %v", err)
+	}
+	restarted := &MockDiscordClient{}
+	startWorkerWithDB(t, clock, restarted, db)
+	if len(restarted.SentMessages) != 0 {
+		t.Errorf("Expected no duplicate DMs, got %+v", restarted.SentMessages)
+	}
+}
+
+func TestWorkerRecordsFailedDMs(t *testing.T) {
+	// Arrange: the subscriber doesn't accept DMs
+	clock := NewFakeClock(time.Date(2025, time.June, 3, 11, 0, 0, 0, time.UTC))
+	client := &MockDiscordClient{SendError: &discordgo.RESTError{
+		Response: &http.Response{Status: "403 Forbidden"},
+		Message:  &discordgo.APIErrorMessage{Code: discordgo.ErrCodeCannotSendMessagesToThisUser, Message: "Cannot send messages to this user"},
+	}}
+	db := setupWorkerDB(t, "UTC", "2025-06-02", map[string][2]int{"Bob": {6, 4}})
+	addSubscription(t, db, "5", "Bob")
+
+	// Act
+	_, wait := startWorkerWithDB(t, clock, client, db)
+
+	// Assert: the failure is recorded for the subscriber rather than retried
+	subscriptions, err := db.GetSubscriptions(ctx, testGuildID, "5")
+	if err != nil {
+		t.Fatalf("GetSubscriptions() returned error: %v", err)
+	}
+	if len(subscriptions) != 1 || !strings.Contains(subscriptions[0].Failure, "privacy settings") || !subscriptions[0].FailedAt.Equal(clock.Now()) {
+		t.Errorf("Subscriptions = %+v; want the failed DM recorded at %s", subscriptions, clock.Now())
+	}
+	if wait == 5*time.Minute {
+		t.Error("Expected a failed DM not to be retried")
+	}
+}
+
+func TestWorkerDoesNotRepeatAnnouncementAfterRestart(t *testing.T) {
+	// Arrange: today's announcement was already made before the restart
+	clock := NewFakeClock(time.Date(2025, time.June, 10, 11, 0, 0, 0, time.UTC))
+	client := &MockDiscordClient{}
+
+	// Act
+	_, wait := startWorker(t, clock, client, "UTC", "2025-06-10", map[string][2]int{"Bob": {6, 10}})
+
+	// Assert
+	if len(client.SentMessages) != 0 {
+		t.Errorf("Expected no messages, got %+v", client.SentMessages)
+	}
+	if wait != time.Hour {
+		t.Errorf("Expected to sleep for at most an hour, got %s", wait)
+	}
+}
+
+func TestWorkerWaitsForAnnouncementTime(t *testing.T) {
+	// Arrange: the bot starts at 08:00, an hour before the announcement
+	clock := NewFakeClock(time.Date(2025, time.June, 10, 8, 0, 0, 0, time.UTC))
+	client := &MockDiscordClient{}
+	_, wait := startWorker(t, clock, client, "UTC", "2025-06-09", map[string][2]int{"Bob": {6, 10}})
+	if len(client.SentMessages) != 0 {
+		t.Fatalf("Expected no messages before 09:00, got %+v", client.SentMessages)
+	}
+	if wait != time.Hour {
+		t.Fatalf("Expected to wait an hour, got %s", wait)
+	}
+
+	// Act
+	clock.Advance(wait)
+	<-clock.Waits
+
+	// Assert
+	if len(client.SentMessages) != 1 {
+		t.Errorf("Expected Bob's birthday to be announced at 09:00, got %+v", client.SentMessages)
+	}
+}
+
+func TestWorkerRetriesFailedAnnouncement(t *testing.T) {
+	// Arrange: Discord is unavailable when the worker starts
+	clock := NewFakeClock(time.Date(2025, time.June, 10, 9, 0, 0, 0, time.UTC))
+	client := &MockDiscordClient{SendError: errors.New("discord unavailable")}
+	_, wait := startWorker(t, clock, client, "UTC", "2025-06-09", map[string][2]int{"Bob": {6, 10}})
+	if wait != 5*time.Minute {
+		t.Fatalf("Expected a retry in 5 minutes, got %s", wait)
+	}
+
+	// Act
+	client.SendError = nil
+	clock.Advance(wait)
+	<-clock.Waits
+
+	// Assert
+	if len(client.SentMessages) != 1 {
+		t.Errorf("Expected the retry to announce Bob's birthday, got %+v", client.SentMessages)
+	}
+}
+
+func TestWorkerResumesChunkedAnnouncement(t *testing.T) {
+	// Arrange: 40 birthdays today make an announcement of several messages, and
+	// sending the second one fails
+	clock := NewFakeClock(time.Date(2025, time.June, 10, 9, 0, 0, 0, time.UTC))
+	client := &MockDiscordClient{FailAt: 2}
+	birthdays := make(map[string][2]int)
+	for i := 0; i < 40; i++ {
+		birthdays[fmt.Sprintf("Person number %02d", i)] = [2]int{6, 10}
+	}
+	_, wait := startWorker(t, clock, client, "UTC", "2025-06-09", birthdays)
+	if wait != 5*time.Minute || len(client.SentMessages) != 1 {
+		t.Fatalf("Expected one message sent and a retry in 5 minutes, got %d messages and %s", len(client.SentMessages), wait)
+	}
+
+	// Act
+	clock.Advance(wait)
+	<-clock.Waits
+
+	// Assert: the retry resumes with the message that failed
+	if len(client.SentMessages) < 2 {
+		t.Fatalf("Expected the rest of the announcement to be sent, got %d messages", len(client.SentMessages))
+	}
+	seen := make(map[string]bool)
+	for i, sent := range client.SentMessages {
+		if seen[sent.Message] {
+			t.Errorf("Message %d was sent twice: %q", i, sent.Message)
+		}
+		seen[sent.Message] = true
+	}
+}
+
+func TestWorkerResumesWithTheSameTemplate(t *testing.T) {
+	// Arrange: the guild has a short and a long birthday template, which split
+	// 40 birthdays into messages differently, and sending the second message fails
+	clock := NewFakeClock(time.Date(2025, time.June, 10, 9, 0, 0, 0, time.UTC))
+	client := &MockDiscordClient{FailAt: 2}
+	birthdays := make(map[string][2]int)
+	for i := 0; i < 40; i++ {
+		birthdays[fmt.Sprintf("Person number %02d", i)] = [2]int{6, 10}
+	}
+	db := setupWorkerDB(t, "UTC", "2025-06-09", birthdays)
+	templates := []string{"Happy birthday {{name}}!", "Happy birthday {{name}}! " + strings.Repeat("🎂", 900)}
+	if err := db.SetTemplates(ctx, testGuildID, database.AnnouncementBirthday, templates); err != nil {
+		t.Fatalf("Failed to set templates: %v", err)
+	}
+	_, wait := startWorkerWithDB(t, clock, client, db)
+
+	// Act
+	clock.Advance(wait)
+	<-clock.Waits
+
+	// Assert: every person is announced exactly once
+	var all strings.Builder
+	for _, sent := range client.SentMessages {
+		all.WriteString(sent.Message + "\n")
+	}
+	for name := range birthdays {
+		if count := strings.Count(all.String(), name+"!"); count != 1 {
+			t.Errorf("%q was announced %d times", name, count)
+		}
+	}
+}
+
+func TestWorkerSkipsAnnouncementsInLedger(t *testing.T) {
+	// Arrange: the birthday message went out, but the bot stopped before it
+	// could record the day as announced
+	clock := NewFakeClock(time.Date(2025, time.June, 10, 11, 0, 0, 0, time.UTC))
+	client := &MockDiscordClient{}
+	db := setupWorkerDB(t, "UTC", "2025-06-09", map[string][2]int{"Bob": {6, 10}})
+	if _, err := db.ClaimAnnouncement(ctx, testGuildID, "2025-06-10", database.AnnouncementBirthday); err != nil {
+		t.Fatalf("Failed to claim announcement: %v", err)
+	}
+
+	// Act
+	startWorkerWithDB(t, clock, client, db)
+
+	// Assert
+	if len(client.SentMessages) != 0 {
+		t.Errorf("Expected no duplicate messages, got %+v", client.SentMessages)
+	}
+}
+
+func TestWorkerReplicasAnnounceOnce(t *testing.T) {
+	// Arrange: two replicas share a database and wake up at the same time
+	db := setupWorkerDB(t, "UTC", "2025-05-31", map[string][2]int{"Bob": {6, 1}})
+	firstClient, secondClient := &MockDiscordClient{}, &MockDiscordClient{}
+	start := time.Date(2025, time.June, 1, 9, 0, 0, 0, time.UTC)
+
+	// Act
+	startWorkerWithDB(t, NewFakeClock(start), firstClient, db)
+	// The second replica read the guild before the first recorded the day as announced
+	if err := db.SetGuildLastAnnounced(ctx, testGuildID, "2025-05-31"); err != nil {
+		t.Fatalf("Failed to set last announced date: %v", err)
+	}
+	startWorkerWithDB(t, NewFakeClock(start), secondClient, db)
+
+	// Assert: the first replica posts the monthly roundup and the birthday message
+	if len(firstClient.SentMessages) != 2 {
+		t.Errorf("Expected the first replica to post 2 messages, got %+v", firstClient.SentMessages)
+	}
+	if len(secondClient.SentMessages) != 0 {
+		t.Errorf("Expected the second replica to post nothing, got %+v", secondClient.SentMessages)
+	}
+
+	history, err := db.GetAnnouncements(ctx, testGuildID, 10)
+	if err != nil {
+		t.Fatalf("Failed to get announcements: %v", err)
+	}
+	if len(history) != 2 {
+		t.Errorf("Expected 2 ledger entries, got %+v", history)
+	}
+}
+
+// FakeElector lets tests decide whether the worker's replica leads
+type FakeElector struct {
+	mu       sync.Mutex
+	Leading  bool
+	Released bool
+}
+
+func (e *FakeElector) Acquire() (bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.Leading, nil
+}
+
+func (e *FakeElector) Release() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.Released = true
+	return nil
+}
+
+func (e *FakeElector) RenewInterval() time.Duration {
+	return 20 * time.Second
+}
+
+func TestWorkerOnlyLeaderAnnounces(t *testing.T) {
+	tests := []struct {
+		name         string
+		leading      bool
+		wantMessages int
+	}{
+		{"Leader announces", true, 1},
+		{"Follower stays quiet", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			db := setupWorkerDB(t, "UTC", "2025-06-09", map[string][2]int{"Bob": {6, 10}})
+			clock := NewFakeClock(time.Date(2025, time.June, 10, 9, 0, 0, 0, time.UTC))
+			client := &MockDiscordClient{}
+			elector := &FakeElector{Leading: tt.leading}
+			worker := bot.NewWorker(client, birthday.NewServiceDB(clock, db), clock)
+			worker.SetRenderer(render.PlainText{})
+			worker.SetElector(elector)
+
+			// Act
+			go worker.Start()
+			wait := <-clock.Waits
+			worker.Stop()
+
+			// Assert
+			if len(client.SentMessages) != tt.wantMessages {
+				t.Errorf("Expected %d messages, got %+v", tt.wantMessages, client.SentMessages)
+			}
+			if wait != elector.RenewInterval() {
+				t.Errorf("Expected to check leadership again in %s, got %s", elector.RenewInterval(), wait)
+			}
+			if elector.Released != tt.leading {
+				t.Errorf("Released = %v; want %v", elector.Released, tt.leading)
+			}
+		})
+	}
+}
@@ -11,11 +11,21 @@ type TimeProvider interface {
 	Now() time.Time
 	Month() time.Month
 	Day() int
+
+	// After waits for the duration to elapse and then sends the current time
+	After(d time.Duration) <-chan time.Time
 }
 
 // DiscordClient provides Discord-related functionality that can be mocked in tests
 type DiscordClient interface {
 	SendMessage(channelID string, message string) error
+	SendComplexMessage(channelID string, data *discordgo.MessageSend) error
+	SendEmbeds(channelID string, content string, embeds []*discordgo.MessageEmbed, mentionIDs []string) error
+	SendDirectMessage(userID string, message string) error
+	User(userID string) (*discordgo.User, error)
+	GuildMember(guildID, userID string) (*discordgo.Member, error)
+	InteractionRespond(interaction *discordgo.Interaction, response *discordgo.InteractionResponse) error
+	FollowupMessage(interaction *discordgo.Interaction, params *discordgo.WebhookParams) error
 	AddHandler(handler interface{})
 	Close() error
 }
@@ -25,8 +35,64 @@ type DiscordSession struct {
 	Session *discordgo.Session
 }
 
+// SendMessage sends a plain message, split into several messages if it is too
+// long for one. Mentions in the content never notify anyone.
 func (ds *DiscordSession) SendMessage(channelID string, message string) error {
-	_, err := ds.Session.ChannelMessageSend(channelID, message)
+	return SendChunked(ds, channelID, &discordgo.MessageSend{Content: message})
+}
+
+// SendComplexMessage sends a single message with the given allowed mentions. If
+// none are set, no mentions are allowed, so content can never ping @everyone by
+// accident. Use SendChunked for content that may be too long for one message.
+func (ds *DiscordSession) SendComplexMessage(channelID string, data *discordgo.MessageSend) error {
+	if data.AllowedMentions == nil {
+		data.AllowedMentions = UserMentions(nil)
+	}
+	_, err := ds.Session.ChannelMessageSendComplex(channelID, data)
+	return err
+}
+
+// SendEmbeds sends embeds along with optional content. Only the given users are
+// notified by mentions in the content; mentions inside embeds never notify anyone.
+func (ds *DiscordSession) SendEmbeds(channelID string, content string, embeds []*discordgo.MessageEmbed, mentionIDs []string) error {
+	return SendChunked(ds, channelID, &discordgo.MessageSend{
+		Content:         content,
+		Embeds:          embeds,
+		AllowedMentions: UserMentions(mentionIDs),
+	})
+}
+
+// SendDirectMessage sends a plain message to a user's DMs, split into several
+// messages if it is too long for one. It fails if the user doesn't accept DMs
+// from the bot, e.g. because they turned off DMs from server members.
+func (ds *DiscordSession) SendDirectMessage(userID string, message string) error {
+	channel, err := ds.Session.UserChannelCreate(userID)
+	if err != nil {
+		return err
+	}
+	return SendChunked(ds, channel.ID, &discordgo.MessageSend{Content: message})
+}
+
+// User looks up a Discord user, e.g. for their avatar
+func (ds *DiscordSession) User(userID string) (*discordgo.User, error) {
+	return ds.Session.User(userID)
+}
+
+func (ds *DiscordSession) GuildMember(guildID, userID string) (*discordgo.Member, error) {
+	return ds.Session.GuildMember(guildID, userID)
+}
+
+func (ds *DiscordSession) InteractionRespond(interaction *discordgo.Interaction, response *discordgo.InteractionResponse) error {
+	return ds.Session.InteractionRespond(interaction, response)
+}
+
+// FollowupMessage sends a further message in response to an interaction that
+// has already been responded to. If no mentions are allowed, none notify anyone.
+func (ds *DiscordSession) FollowupMessage(interaction *discordgo.Interaction, params *discordgo.WebhookParams) error {
+	if params.AllowedMentions == nil {
+		params.AllowedMentions = UserMentions(nil)
+	}
+	_, err := ds.Session.FollowupMessageCreate(interaction, true, params)
 	return err
 }
 
@@ -37,3 +103,21 @@ func (ds *DiscordSession) AddHandler(handler interface{}) {
 func (ds *DiscordSession) Close() error {
 	return ds.Session.Close()
 }
+
+// UserMentions returns allowed mentions that notify exactly the given users and
+// never @everyone, @here or roles
+func UserMentions(userIDs []string) *discordgo.MessageAllowedMentions {
+	return &discordgo.MessageAllowedMentions{
+		Parse: []discordgo.AllowedMentionType{},
+		Users: userIDs,
+	}
+}
+
+// RoleMentions returns allowed mentions that notify exactly the given roles and
+// never users, @everyone or @here
+func RoleMentions(roleIDs []string) *discordgo.MessageAllowedMentions {
+	return &discordgo.MessageAllowedMentions{
+		Parse: []discordgo.AllowedMentionType{},
+		Roles: roleIDs,
+	}
+}
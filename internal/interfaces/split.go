@@ -0,0 +1,177 @@
+package interfaces
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// MessageLimit is the most characters Discord accepts in a message's content
+const MessageLimit = 2000
+
+// SplitMessage breaks content into chunks of at most limit characters. Chunks
+// end after the last line break that fits, or failing that the last space, and
+// never inside a **bold** span or a <@mention>, so markdown and mentions still
+// render in every chunk. A chunk is only cut anywhere else if it has no such
+// place to end.
+func SplitMessage(content string, limit int) []string {
+	runes := []rune(content)
+	var chunks []string
+	for len(runes) > limit {
+		cut := splitPoint(runes, limit)
+		chunks = append(chunks, string(runes[:cut]))
+		runes = runes[cut:]
+	}
+	if len(runes) > 0 || len(chunks) == 0 {
+		chunks = append(chunks, string(runes))
+	}
+	return chunks
+}
+
+// splitPoint returns where the first chunk of runes should end
+func splitPoint(runes []rune, limit int) int {
+	lastLine, lastSpace := 0, 0
+	inBold, inMention := false, false
+	for i := 0; i < limit; i++ {
+		switch {
+		case inMention:
+			inMention = runes[i] != '>'
+		case runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '*':
+			inBold = !inBold
+			i++
+		case runes[i] == '<' && i+1 < len(runes) && (runes[i+1] == '@' || runes[i+1] == '#' || runes[i+1] == ':'):
+			inMention = true
+		case inBold:
+		case runes[i] == '\n':
+			lastLine = i + 1
+		case runes[i] == ' ':
+			lastSpace = i + 1
+		}
+	}
+
+	switch {
+	case lastLine > 0:
+		return lastLine
+	case lastSpace > 0:
+		return lastSpace
+	default:
+		return limit
+	}
+}
+
+// Discord's limits on the embeds in one message
+const (
+	// MaxEmbeds is the most embeds in one message
+	MaxEmbeds = 10
+
+	// EmbedsLimit is the most characters across the titles, descriptions,
+	// fields, footers and authors of all the embeds in one message
+	EmbedsLimit = 6000
+)
+
+// ChunkMessage splits a message into messages within Discord's limits, in the
+// order they should be sent. Content that is too long for one message is split
+// with SplitMessage, and embeds are grouped into as few messages as fit. The
+// embeds start with the last chunk of content, so they follow all of the text,
+// and components go with the last message.
+func ChunkMessage(data *discordgo.MessageSend) []*discordgo.MessageSend {
+	var messages []*discordgo.MessageSend
+	for _, chunk := range SplitMessage(data.Content, MessageLimit) {
+		messages = append(messages, &discordgo.MessageSend{Content: chunk, AllowedMentions: data.AllowedMentions})
+	}
+	for i, group := range groupEmbeds(data.Embeds) {
+		if i == 0 {
+			messages[len(messages)-1].Embeds = group
+			continue
+		}
+		messages = append(messages, &discordgo.MessageSend{Embeds: group, AllowedMentions: data.AllowedMentions})
+	}
+	messages[len(messages)-1].Components = data.Components
+	return messages
+}
+
+// groupEmbeds splits embeds into groups of at most MaxEmbeds embeds and
+// EmbedsLimit characters, in order
+func groupEmbeds(embeds []*discordgo.MessageEmbed) [][]*discordgo.MessageEmbed {
+	var groups [][]*discordgo.MessageEmbed
+	var group []*discordgo.MessageEmbed
+	length := 0
+	for _, embed := range embeds {
+		n := embedLength(embed)
+		if len(group) > 0 && (len(group) == MaxEmbeds || length+n > EmbedsLimit) {
+			groups = append(groups, group)
+			group, length = nil, 0
+		}
+		group = append(group, embed)
+		length += n
+	}
+	if len(group) > 0 {
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// embedLength counts the characters of an embed that count toward EmbedsLimit
+func embedLength(embed *discordgo.MessageEmbed) int {
+	n := len([]rune(embed.Title)) + len([]rune(embed.Description))
+	for _, field := range embed.Fields {
+		n += len([]rune(field.Name)) + len([]rune(field.Value))
+	}
+	if embed.Footer != nil {
+		n += len([]rune(embed.Footer.Text))
+	}
+	if embed.Author != nil {
+		n += len([]rune(embed.Author.Name))
+	}
+	return n
+}
+
+// SendChunked sends a message, split by ChunkMessage into as many messages as
+// Discord's limits need, in order. It stops at the first message that fails.
+func SendChunked(client DiscordClient, channelID string, data *discordgo.MessageSend) error {
+	for _, message := range ChunkMessage(data) {
+		if err := client.SendComplexMessage(channelID, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RespondChunked responds to an interaction. A response too big for one
+// message, as split by ChunkMessage, is continued in follow-up messages with
+// the same flags; the embeds follow the text and the components come last.
+func RespondChunked(client DiscordClient, interaction *discordgo.Interaction, response *discordgo.InteractionResponse) error {
+	data := response.Data
+	if data == nil {
+		return client.InteractionRespond(interaction, response)
+	}
+	messages := ChunkMessage(&discordgo.MessageSend{
+		Content:         data.Content,
+		Embeds:          data.Embeds,
+		Components:      data.Components,
+		AllowedMentions: data.AllowedMentions,
+	})
+	if len(messages) == 1 {
+		return client.InteractionRespond(interaction, response)
+	}
+
+	first := *data
+	first.Content = messages[0].Content
+	first.Embeds = messages[0].Embeds
+	first.Components = messages[0].Components
+	if err := client.InteractionRespond(interaction, &discordgo.InteractionResponse{Type: response.Type, Data: &first}); err != nil {
+		return err
+	}
+
+	for _, message := range messages[1:] {
+		params := &discordgo.WebhookParams{
+			Content:         message.Content,
+			Flags:           data.Flags,
+			AllowedMentions: data.AllowedMentions,
+			Embeds:          message.Embeds,
+			Components:      message.Components,
+		}
+		if err := client.FollowupMessage(interaction, params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
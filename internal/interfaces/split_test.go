@@ -0,0 +1,168 @@
+package interfaces_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/nrzaman/baos-birthday-bot/internal/interfaces"
+)
+
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		limit   int
+		want    []string
+	}{
+		{"Fits", "one\ntwo\n", 10, []string{"one\ntwo\n"}},
+		{"Splits between lines", "one\ntwo\nthree\n", 8, []string{"one\ntwo\n", "three\n"}},
+		{"Splits a long line", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"Counts characters, not bytes", "🎉🎉🎉\n🎂🎂🎂\n", 4, []string{"🎉🎉🎉\n", "🎂🎂🎂\n"}},
+		{"Falls back to spaces", "one two three", 9, []string{"one two ", "three"}},
+		{"Keeps bold spans together", "a\n**b c\nd** e", 10, []string{"a\n", "**b c\nd** ", "e"}},
+		{"Keeps mentions together", "hi <@123456> there", 12, []string{"hi ", "<@123456> ", "there"}},
+		{"Empty", "", 10, []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			chunks := interfaces.SplitMessage(tt.content, tt.limit)
+
+			// Assert
+			if strings.Join(chunks, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("SplitMessage() = %q; want %q", chunks, tt.want)
+			}
+		})
+	}
+}
+
+// recordingClient records what is sent through it
+type recordingClient struct {
+	interfaces.DiscordClient
+	sent      []*discordgo.MessageSend
+	responses []*discordgo.InteractionResponse
+	followups []*discordgo.WebhookParams
+}
+
+func (c *recordingClient) SendComplexMessage(channelID string, data *discordgo.MessageSend) error {
+	c.sent = append(c.sent, data)
+	return nil
+}
+
+func (c *recordingClient) InteractionRespond(interaction *discordgo.Interaction, response *discordgo.InteractionResponse) error {
+	c.responses = append(c.responses, response)
+	return nil
+}
+
+func (c *recordingClient) FollowupMessage(interaction *discordgo.Interaction, params *discordgo.WebhookParams) error {
+	c.followups = append(c.followups, params)
+	return nil
+}
+
+// longContent returns lines of text adding up to more than one message
+func longContent() string {
+	return strings.Repeat("• **Someone's birthday** is on <@1234567890>'s list\n", 60)
+}
+
+func TestSendChunked(t *testing.T) {
+	// Arrange
+	client := &recordingClient{}
+	embeds := []*discordgo.MessageEmbed{{Title: "Birthdays"}}
+	mentions := interfaces.UserMentions([]string{"1234567890"})
+
+	// Act
+	err := interfaces.SendChunked(client, "channel", &discordgo.MessageSend{Content: longContent(), Embeds: embeds, AllowedMentions: mentions})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("SendChunked() returned error: %v", err)
+	}
+	if len(client.sent) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(client.sent))
+	}
+	var joined string
+	for i, sent := range client.sent {
+		joined += sent.Content
+		if sent.AllowedMentions != mentions {
+			t.Errorf("Message %d lost its allowed mentions", i)
+		}
+		if hasEmbeds := len(sent.Embeds) > 0; hasEmbeds != (i == len(client.sent)-1) {
+			t.Errorf("Message %d has embeds = %v; want them only on the last message", i, hasEmbeds)
+		}
+	}
+	if joined != longContent() {
+		t.Error("Expected the messages to add up to the original content, in order")
+	}
+}
+
+func TestChunkMessage_GroupsEmbeds(t *testing.T) {
+	// embeds returns count embeds with descriptions of the given length
+	embeds := func(count, length int) []*discordgo.MessageEmbed {
+		var result []*discordgo.MessageEmbed
+		for i := 0; i < count; i++ {
+			result = append(result, &discordgo.MessageEmbed{Description: strings.Repeat("a", length)})
+		}
+		return result
+	}
+
+	tests := []struct {
+		name   string
+		data   *discordgo.MessageSend
+		groups []int
+	}{
+		{"At most 10 embeds per message", &discordgo.MessageSend{Embeds: embeds(25, 10)}, []int{10, 10, 5}},
+		{"At most 6000 characters per message", &discordgo.MessageSend{Embeds: embeds(4, 2000)}, []int{3, 1}},
+		{"Embeds follow the text", &discordgo.MessageSend{Content: longContent(), Embeds: embeds(2, 10)}, []int{0, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			tt.data.Components = []discordgo.MessageComponent{discordgo.ActionsRow{}}
+
+			// Act
+			messages := interfaces.ChunkMessage(tt.data)
+
+			// Assert
+			var groups []int
+			for i, m := range messages {
+				groups = append(groups, len(m.Embeds))
+				if hasComponents := len(m.Components) > 0; hasComponents != (i == len(messages)-1) {
+					t.Errorf("Message %d has components = %v; want them only on the last message", i, hasComponents)
+				}
+			}
+			if fmt.Sprint(groups) != fmt.Sprint(tt.groups) {
+				t.Errorf("Embeds per message = %v; want %v", groups, tt.groups)
+			}
+		})
+	}
+}
+
+func TestRespondChunked(t *testing.T) {
+	// Arrange
+	client := &recordingClient{}
+	response := &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: longContent(), Flags: discordgo.MessageFlagsEphemeral},
+	}
+
+	// Act
+	err := interfaces.RespondChunked(client, &discordgo.Interaction{}, response)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("RespondChunked() returned error: %v", err)
+	}
+	if len(client.responses) != 1 || len(client.followups) != 1 {
+		t.Fatalf("Expected a response and a follow-up, got %d and %d", len(client.responses), len(client.followups))
+	}
+	if client.followups[0].Flags != discordgo.MessageFlagsEphemeral {
+		t.Error("Expected the follow-up to be ephemeral like the response")
+	}
+	if client.responses[0].Data.Content+client.followups[0].Content != longContent() {
+		t.Error("Expected the response and follow-up to add up to the original content, in order")
+	}
+}
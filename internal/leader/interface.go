@@ -0,0 +1,26 @@
+package leader
+
+import "time"
+
+// DefaultLeaseDuration is how long a replica stays leader without renewing. A
+// new leader takes over at most this long after the old one disappears.
+const DefaultLeaseDuration = time.Minute
+
+// Elector decides which replica runs the scheduled announcements. Every replica
+// calls Acquire periodically, and at most one of them leads at a time.
+type Elector interface {
+	// Acquire tries to become leader, or to stay leader, and reports whether
+	// this replica leads until the next call
+	Acquire() (bool, error)
+
+	// Release gives up leadership so another replica can take over right away
+	Release() error
+
+	// RenewInterval is how often Acquire must be called to keep leadership
+	RenewInterval() time.Duration
+}
+
+// renewInterval leaves a leader two chances to renew before its lease expires
+func renewInterval(leaseDuration time.Duration) time.Duration {
+	return leaseDuration / 3
+}
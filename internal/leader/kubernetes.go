@@ -0,0 +1,294 @@
+package leader
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/interfaces"
+)
+
+// Paths of the service account credentials mounted into every pod
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	tokenFile         = serviceAccountDir + "/token"
+	namespaceFile     = serviceAccountDir + "/namespace"
+	caFile            = serviceAccountDir + "/ca.crt"
+)
+
+// microTimeLayout is the format Kubernetes uses for Lease timestamps
+const microTimeLayout = "2006-01-02T15:04:05.000000Z07:00"
+
+// KubernetesConfig describes how to reach the Kubernetes API and which Lease to use
+type KubernetesConfig struct {
+	APIServer     string // Base URL of the API server, e.g. https://10.0.0.1:443
+	Token         string // Bearer token; if empty, TokenFile is read on every request
+	TokenFile     string // Service account token file, which Kubernetes rotates
+	Namespace     string
+	LeaseName     string
+	Identity      string // Name of this replica, usually the pod name
+	LeaseDuration time.Duration
+	HTTPClient    *http.Client
+}
+
+// InClusterConfig returns the configuration for a bot running in a pod, using
+// the pod's service account
+func InClusterConfig(leaseName, identity string) (KubernetesConfig, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return KubernetesConfig{}, errors.New("not running in Kubernetes: KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are not set")
+	}
+
+	namespace, err := os.ReadFile(namespaceFile)
+	if err != nil {
+		return KubernetesConfig{}, fmt.Errorf("failed to read namespace: %w", err)
+	}
+
+	ca, err := os.ReadFile(caFile)
+	if err != nil {
+		return KubernetesConfig{}, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return KubernetesConfig{}, errors.New("failed to parse CA certificate")
+	}
+
+	return KubernetesConfig{
+		APIServer:     "https://" + net.JoinHostPort(host, port),
+		TokenFile:     tokenFile,
+		Namespace:     strings.TrimSpace(string(namespace)),
+		LeaseName:     leaseName,
+		Identity:      identity,
+		LeaseDuration: DefaultLeaseDuration,
+		HTTPClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}},
+		},
+	}, nil
+}
+
+// KubernetesElector elects a leader using a coordination.k8s.io/v1 Lease. Updates
+// carry the Lease's resourceVersion, so when two replicas race for an expired
+// Lease the API server accepts only one of them.
+type KubernetesElector struct {
+	config       KubernetesConfig
+	timeProvider interfaces.TimeProvider
+}
+
+// NewKubernetesElector creates an elector for the given configuration
+func NewKubernetesElector(config KubernetesConfig, timeProvider interfaces.TimeProvider) *KubernetesElector {
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	return &KubernetesElector{config: config, timeProvider: timeProvider}
+}
+
+// lease is the part of a coordination.k8s.io/v1 Lease the elector uses
+type lease struct {
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Metadata   leaseMetadata `json:"metadata"`
+	Spec       leaseSpec     `json:"spec"`
+}
+
+type leaseMetadata struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace,omitempty"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       string `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds int    `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          string `json:"acquireTime,omitempty"`
+	RenewTime            string `json:"renewTime,omitempty"`
+	LeaseTransitions     int    `json:"leaseTransitions,omitempty"`
+}
+
+// errLeaseNotFound is returned by get when the Lease doesn't exist yet
+var errLeaseNotFound = errors.New("lease not found")
+
+// Acquire creates, takes over or renews the Lease
+func (e *KubernetesElector) Acquire() (bool, error) {
+	now := e.timeProvider.Now()
+
+	current, err := e.get()
+	if errors.Is(err, errLeaseNotFound) {
+		next := lease{
+			APIVersion: "coordination.k8s.io/v1",
+			Kind:       "Lease",
+			Metadata:   leaseMetadata{Name: e.config.LeaseName, Namespace: e.config.Namespace},
+		}
+		e.hold(&next, now)
+		return e.write(http.MethodPost, e.collectionURL(), next)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	holder := current.Spec.HolderIdentity
+	if holder != "" && holder != e.config.Identity && !expired(current, now) {
+		return false, nil
+	}
+
+	e.hold(current, now)
+	return e.write(http.MethodPut, e.leaseURL(), *current)
+}
+
+// Release clears the Lease's holder if this replica holds it
+func (e *KubernetesElector) Release() error {
+	current, err := e.get()
+	if errors.Is(err, errLeaseNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current.Spec.HolderIdentity != e.config.Identity {
+		return nil
+	}
+
+	current.Spec.HolderIdentity = ""
+	if _, err := e.write(http.MethodPut, e.leaseURL(), *current); err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
+
+// RenewInterval is how often Acquire must be called to keep the Lease
+func (e *KubernetesElector) RenewInterval() time.Duration {
+	return renewInterval(e.config.LeaseDuration)
+}
+
+// hold sets this replica as the Lease's holder as of now
+func (e *KubernetesElector) hold(l *lease, now time.Time) {
+	if l.Spec.HolderIdentity != e.config.Identity {
+		if l.Spec.HolderIdentity != "" || l.Spec.AcquireTime != "" {
+			l.Spec.LeaseTransitions++
+		}
+		l.Spec.AcquireTime = now.UTC().Format(microTimeLayout)
+	}
+	l.Spec.HolderIdentity = e.config.Identity
+	l.Spec.LeaseDurationSeconds = int(e.config.LeaseDuration / time.Second)
+	l.Spec.RenewTime = now.UTC().Format(microTimeLayout)
+}
+
+// expired reports whether the Lease's holder has failed to renew it in time
+func expired(l *lease, now time.Time) bool {
+	renewed, err := time.Parse(microTimeLayout, l.Spec.RenewTime)
+	if err != nil {
+		// A Lease without a valid renew time can't be kept alive by anyone
+		return true
+	}
+	return !now.Before(renewed.Add(time.Duration(l.Spec.LeaseDurationSeconds) * time.Second))
+}
+
+// get fetches the Lease
+func (e *KubernetesElector) get() (*lease, error) {
+	status, body, err := e.do(http.MethodGet, e.leaseURL(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNotFound {
+		return nil, errLeaseNotFound
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to get lease: %s: %s", http.StatusText(status), body)
+	}
+
+	var l lease
+	if err := json.Unmarshal(body, &l); err != nil {
+		return nil, fmt.Errorf("failed to decode lease: %w", err)
+	}
+	return &l, nil
+}
+
+// write creates or replaces the Lease, returning false if another replica
+// created or changed it first
+func (e *KubernetesElector) write(method, url string, l lease) (bool, error) {
+	payload, err := json.Marshal(l)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode lease: %w", err)
+	}
+
+	status, body, err := e.do(method, url, payload)
+	if err != nil {
+		return false, err
+	}
+	switch status {
+	case http.StatusOK, http.StatusCreated:
+		return true, nil
+	case http.StatusConflict:
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to write lease: %s: %s", http.StatusText(status), body)
+	}
+}
+
+// do sends an authenticated request to the API server and returns the response status and body
+func (e *KubernetesElector) do(method, url string, payload []byte) (int, []byte, error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	token, err := e.token()
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := e.config.HTTPClient.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to reach Kubernetes API: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close() // Best effort close
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp.StatusCode, body, nil
+}
+
+// token returns the bearer token, re-reading the token file so rotated tokens are picked up
+func (e *KubernetesElector) token() (string, error) {
+	if e.config.Token != "" || e.config.TokenFile == "" {
+		return e.config.Token, nil
+	}
+	token, err := os.ReadFile(e.config.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read service account token: %w", err)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// collectionURL is the URL of the namespace's Leases
+func (e *KubernetesElector) collectionURL() string {
+	return fmt.Sprintf("%s/apis/coordination.k8s.io/v1/namespaces/%s/leases",
+		strings.TrimSuffix(e.config.APIServer, "/"), e.config.Namespace)
+}
+
+// leaseURL is the URL of the elector's Lease
+func (e *KubernetesElector) leaseURL() string {
+	return e.collectionURL() + "/" + e.config.LeaseName
+}
@@ -0,0 +1,311 @@
+package leader_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/leader"
+)
+
+const (
+	testNamespace = "bots"
+	testLeaseName = "baos-birthday-bot"
+	testToken     = "test-token"
+)
+
+// fakeLease mirrors the fields of a coordination.k8s.io/v1 Lease the fake API stores
+type fakeLease struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name            string `json:"name"`
+		Namespace       string `json:"namespace,omitempty"`
+		ResourceVersion string `json:"resourceVersion,omitempty"`
+	} `json:"metadata"`
+	Spec struct {
+		HolderIdentity       string `json:"holderIdentity,omitempty"`
+		LeaseDurationSeconds int    `json:"leaseDurationSeconds,omitempty"`
+		AcquireTime          string `json:"acquireTime,omitempty"`
+		RenewTime            string `json:"renewTime,omitempty"`
+		LeaseTransitions     int    `json:"leaseTransitions,omitempty"`
+	} `json:"spec"`
+}
+
+// FakeLeaseAPI is a minimal Kubernetes API server for Leases. Like the real one,
+// it rejects creating a Lease that exists and replacing a Lease with a stale
+// resourceVersion.
+type FakeLeaseAPI struct {
+	mu      sync.Mutex
+	leases  map[string]*fakeLease
+	version int
+
+	// BeforeWrite, if set, runs before a create or replace is applied
+	BeforeWrite func()
+}
+
+func (f *FakeLeaseAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+testToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	prefix := "/apis/coordination.k8s.io/v1/namespaces/" + testNamespace + "/leases"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+
+	if r.Method != http.MethodGet && f.BeforeWrite != nil {
+		f.BeforeWrite()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		l, ok := f.leases[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(l)
+
+	case http.MethodPost:
+		var l fakeLease
+		if err := json.NewDecoder(r.Body).Decode(&l); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if _, ok := f.leases[l.Metadata.Name]; ok {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.store(&l)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(l)
+
+	case http.MethodPut:
+		var l fakeLease
+		if err := json.NewDecoder(r.Body).Decode(&l); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		current, ok := f.leases[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if l.Metadata.ResourceVersion != current.Metadata.ResourceVersion {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.store(&l)
+		_ = json.NewEncoder(w).Encode(l)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// store saves a Lease with a new resourceVersion
+func (f *FakeLeaseAPI) store(l *fakeLease) {
+	f.version++
+	l.Metadata.ResourceVersion = strconv.Itoa(f.version)
+	f.leases[l.Metadata.Name] = l
+}
+
+// Lease returns a copy of the stored Lease
+func (f *FakeLeaseAPI) Lease() fakeLease {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return *f.leases[testLeaseName]
+}
+
+// Touch bumps the Lease's resourceVersion, as if another replica had just written it
+func (f *FakeLeaseAPI) Touch() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.store(f.leases[testLeaseName])
+}
+
+func setupFakeLeaseAPI(t *testing.T) (*FakeLeaseAPI, *httptest.Server) {
+	t.Helper()
+	api := &FakeLeaseAPI{leases: map[string]*fakeLease{}}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	return api, server
+}
+
+func newKubernetesElector(server *httptest.Server, clock *MockTimeProvider, identity string) *leader.KubernetesElector {
+	return leader.NewKubernetesElector(leader.KubernetesConfig{
+		APIServer:     server.URL,
+		Token:         testToken,
+		Namespace:     testNamespace,
+		LeaseName:     testLeaseName,
+		Identity:      identity,
+		LeaseDuration: time.Minute,
+		HTTPClient:    server.Client(),
+	}, clock)
+}
+
+func TestKubernetesElectorCreatesLease(t *testing.T) {
+	// Arrange
+	api, server := setupFakeLeaseAPI(t)
+	clock := &MockTimeProvider{CurrentTime: time.Date(2025, 6, 10, 9, 0, 0, 0, time.UTC)}
+	elector := newKubernetesElector(server, clock, "pod-a")
+
+	// Act
+	leading, err := elector.Acquire()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Failed to acquire lease: %v", err)
+	}
+	if !leading {
+		t.Fatal("Expected the first replica to become leader")
+	}
+	l := api.Lease()
+	if l.Spec.HolderIdentity != "pod-a" || l.Spec.LeaseDurationSeconds != 60 {
+		t.Errorf("Expected pod-a to hold a 60s lease, got %+v", l.Spec)
+	}
+	if l.Spec.RenewTime != "2025-06-10T09:00:00.000000Z" {
+		t.Errorf("Expected renew time in MicroTime format, got %q", l.Spec.RenewTime)
+	}
+	if elector.RenewInterval() != 20*time.Second {
+		t.Errorf("Expected to renew every 20s, got %s", elector.RenewInterval())
+	}
+}
+
+func TestKubernetesElectorOnlyOneLeader(t *testing.T) {
+	// Arrange
+	_, server := setupFakeLeaseAPI(t)
+	clock := &MockTimeProvider{CurrentTime: time.Date(2025, 6, 10, 9, 0, 0, 0, time.UTC)}
+	a := newKubernetesElector(server, clock, "pod-a")
+	b := newKubernetesElector(server, clock, "pod-b")
+	_, _ = a.Acquire()
+
+	// Act
+	clock.CurrentTime = clock.CurrentTime.Add(20 * time.Second)
+	bLeading, bErr := b.Acquire()
+	aLeading, aErr := a.Acquire()
+
+	// Assert
+	if bErr != nil || aErr != nil {
+		t.Fatalf("Unexpected errors: %v, %v", bErr, aErr)
+	}
+	if bLeading {
+		t.Error("Expected pod-b not to lead while pod-a holds the lease")
+	}
+	if !aLeading {
+		t.Error("Expected pod-a to renew its lease")
+	}
+}
+
+func TestKubernetesElectorTakesOverExpiredLease(t *testing.T) {
+	// Arrange: pod-a stops renewing
+	api, server := setupFakeLeaseAPI(t)
+	clock := &MockTimeProvider{CurrentTime: time.Date(2025, 6, 10, 9, 0, 0, 0, time.UTC)}
+	a := newKubernetesElector(server, clock, "pod-a")
+	b := newKubernetesElector(server, clock, "pod-b")
+	_, _ = a.Acquire()
+
+	// Act
+	clock.CurrentTime = clock.CurrentTime.Add(61 * time.Second)
+	bLeading, err := b.Acquire()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Failed to acquire lease: %v", err)
+	}
+	if !bLeading {
+		t.Fatal("Expected pod-b to take over the expired lease")
+	}
+	l := api.Lease()
+	if l.Spec.HolderIdentity != "pod-b" || l.Spec.LeaseTransitions != 1 {
+		t.Errorf("Expected pod-b to hold the lease after 1 transition, got %+v", l.Spec)
+	}
+	if aLeading, _ := a.Acquire(); aLeading {
+		t.Error("Expected pod-a to have lost the lease")
+	}
+}
+
+func TestKubernetesElectorRelease(t *testing.T) {
+	// Arrange
+	_, server := setupFakeLeaseAPI(t)
+	clock := &MockTimeProvider{CurrentTime: time.Date(2025, 6, 10, 9, 0, 0, 0, time.UTC)}
+	a := newKubernetesElector(server, clock, "pod-a")
+	b := newKubernetesElector(server, clock, "pod-b")
+	_, _ = a.Acquire()
+
+	// Act
+	if err := b.Release(); err != nil {
+		t.Fatalf("Releasing a lease held by another replica should be a no-op: %v", err)
+	}
+	if err := a.Release(); err != nil {
+		t.Fatalf("Failed to release lease: %v", err)
+	}
+	bLeading, err := b.Acquire()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Failed to acquire lease: %v", err)
+	}
+	if !bLeading {
+		t.Error("Expected pod-b to take over a released lease without waiting")
+	}
+}
+
+func TestKubernetesElectorLosesRace(t *testing.T) {
+	// Arrange: another replica writes the Lease between pod-b's read and write
+	api, server := setupFakeLeaseAPI(t)
+	clock := &MockTimeProvider{CurrentTime: time.Date(2025, 6, 10, 9, 0, 0, 0, time.UTC)}
+	a := newKubernetesElector(server, clock, "pod-a")
+	b := newKubernetesElector(server, clock, "pod-b")
+	_, _ = a.Acquire()
+	clock.CurrentTime = clock.CurrentTime.Add(2 * time.Minute)
+	api.BeforeWrite = api.Touch
+
+	// Act
+	leading, err := b.Acquire()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("A lost race should not be an error: %v", err)
+	}
+	if leading {
+		t.Error("Expected pod-b not to lead after losing the race")
+	}
+}
+
+func TestKubernetesElectorReportsAPIErrors(t *testing.T) {
+	// Arrange
+	_, server := setupFakeLeaseAPI(t)
+	clock := &MockTimeProvider{CurrentTime: time.Date(2025, 6, 10, 9, 0, 0, 0, time.UTC)}
+	elector := leader.NewKubernetesElector(leader.KubernetesConfig{
+		APIServer:     server.URL,
+		Token:         "wrong-token",
+		Namespace:     testNamespace,
+		LeaseName:     testLeaseName,
+		Identity:      "pod-a",
+		LeaseDuration: time.Minute,
+	}, clock)
+
+	// Act
+	leading, err := elector.Acquire()
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "Unauthorized") {
+		t.Errorf("Expected an Unauthorized error, got %v", err)
+	}
+	if leading {
+		t.Error("Expected not to lead when the API rejects the request")
+	}
+}
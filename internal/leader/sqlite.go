@@ -0,0 +1,45 @@
+package leader
+
+import (
+	"time"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+	"github.com/nrzaman/baos-birthday-bot/internal/interfaces"
+)
+
+// leaseName is the lease replicas compete for to run announcements
+const leaseName = "announcements"
+
+// SQLiteElector elects a leader using a lease row in the bot's SQLite database.
+// It works for replicas that share the database file, e.g. on a ReadWriteMany volume.
+type SQLiteElector struct {
+	db            *database.DB
+	timeProvider  interfaces.TimeProvider
+	identity      string
+	leaseDuration time.Duration
+}
+
+// NewSQLiteElector creates an elector for the replica called identity
+func NewSQLiteElector(db *database.DB, timeProvider interfaces.TimeProvider, identity string, leaseDuration time.Duration) *SQLiteElector {
+	return &SQLiteElector{
+		db:            db,
+		timeProvider:  timeProvider,
+		identity:      identity,
+		leaseDuration: leaseDuration,
+	}
+}
+
+// Acquire takes or renews the lease
+func (e *SQLiteElector) Acquire() (bool, error) {
+	return e.db.AcquireLease(leaseName, e.identity, e.timeProvider.Now(), e.leaseDuration)
+}
+
+// Release gives up the lease if this replica holds it
+func (e *SQLiteElector) Release() error {
+	return e.db.ReleaseLease(leaseName, e.identity)
+}
+
+// RenewInterval is how often Acquire must be called to keep the lease
+func (e *SQLiteElector) RenewInterval() time.Duration {
+	return renewInterval(e.leaseDuration)
+}
@@ -0,0 +1,86 @@
+package leader_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+	"github.com/nrzaman/baos-birthday-bot/internal/leader"
+)
+
+// MockTimeProvider for testing
+type MockTimeProvider struct {
+	CurrentTime time.Time
+}
+
+func (m *MockTimeProvider) Now() time.Time {
+	return m.CurrentTime
+}
+
+func (m *MockTimeProvider) Month() time.Month {
+	return m.CurrentTime.Month()
+}
+
+func (m *MockTimeProvider) Day() int {
+	return m.CurrentTime.Day()
+}
+
+func (m *MockTimeProvider) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Helper function to create an in-memory test database
+func setupTestDB(t *testing.T) *database.DB {
+	t.Helper()
+	db, err := database.New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close() // Best effort close in tests
+	})
+	return db
+}
+
+func TestSQLiteElector(t *testing.T) {
+	// Arrange: two replicas sharing a database
+	db := setupTestDB(t)
+	clock := &MockTimeProvider{CurrentTime: time.Date(2025, 6, 10, 9, 0, 0, 0, time.UTC)}
+	a := leader.NewSQLiteElector(db, clock, "pod-a", time.Minute)
+	b := leader.NewSQLiteElector(db, clock, "pod-b", time.Minute)
+
+	// Act & Assert: pod-a leads, pod-b waits
+	if leading, err := a.Acquire(); err != nil || !leading {
+		t.Fatalf("Expected pod-a to become leader, got %v, %v", leading, err)
+	}
+	if leading, _ := b.Acquire(); leading {
+		t.Error("Expected pod-b not to lead while pod-a holds the lease")
+	}
+
+	// pod-a renews before the lease expires
+	clock.CurrentTime = clock.CurrentTime.Add(a.RenewInterval())
+	if leading, _ := a.Acquire(); !leading {
+		t.Error("Expected pod-a to renew its lease")
+	}
+	clock.CurrentTime = clock.CurrentTime.Add(50 * time.Second)
+	if leading, _ := b.Acquire(); leading {
+		t.Error("Expected the renewed lease to still be held by pod-a")
+	}
+
+	// pod-a stops renewing, so pod-b takes over once the lease expires
+	clock.CurrentTime = clock.CurrentTime.Add(10 * time.Second)
+	if leading, _ := b.Acquire(); !leading {
+		t.Error("Expected pod-b to take over the expired lease")
+	}
+	if leading, _ := a.Acquire(); leading {
+		t.Error("Expected pod-a to have lost the lease")
+	}
+
+	// Releasing hands leadership over right away
+	if err := b.Release(); err != nil {
+		t.Fatalf("Failed to release lease: %v", err)
+	}
+	if leading, _ := a.Acquire(); !leading {
+		t.Error("Expected pod-a to take over the released lease")
+	}
+}
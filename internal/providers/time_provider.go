@@ -16,3 +16,7 @@ func (r *RealTimeProvider) Month() time.Month {
 func (r *RealTimeProvider) Day() int {
 	return time.Now().Day()
 }
+
+func (r *RealTimeProvider) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
@@ -0,0 +1,121 @@
+package render
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/nrzaman/baos-birthday-bot/internal/birthday"
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+	"github.com/nrzaman/baos-birthday-bot/internal/interfaces"
+)
+
+// Embed colors
+const (
+	announcementColor = 0xEB459E // Discord fuchsia
+	listColor         = 0x5865F2 // Discord blurple
+)
+
+// embedDescriptionLimit is the most characters Discord accepts in an embed's description
+const embedDescriptionLimit = 4096
+
+// embedTitleLimit is the most characters Discord accepts in an embed's title
+const embedTitleLimit = 256
+
+// embedFieldLimit is the most characters Discord accepts in an embed field's value
+const embedFieldLimit = 1024
+
+// Embeds renders messages as Discord embeds
+type Embeds struct{}
+
+// BirthdayAnnouncement posts one embed per person, with their avatar if their
+// Discord account is linked. Mentions in embeds never notify anyone, so linked
+// people are also mentioned in the message content.
+func (Embeds) BirthdayAnnouncement(birthdays []database.Birthday, today time.Time, avatars map[string]string, templates []string) Message {
+	var message Message
+	var mentions []string
+	for _, line := range announcementLines(birthdays, today, templates) {
+		embed := &discordgo.MessageEmbed{
+			Description: line.text,
+			Color:       announcementColor,
+		}
+		if b := line.birthday; b.DiscordID != nil && *b.DiscordID != "" {
+			mentions = append(mentions, b.Mention())
+			if url := avatars[*b.DiscordID]; url != "" {
+				embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: url}
+			}
+		}
+		message.Embeds = append(message.Embeds, embed)
+	}
+	message.Content = strings.Join(mentions, " ")
+	return message
+}
+
+func (Embeds) MonthlyRoundup(group birthday.MonthGroup, template string) Message {
+	description := noBirthdaysThisMonth
+	if len(group.Celebrations) > 0 {
+		description = "Below are all of the birthdays this month:\n\n" + groupLines(group)
+	}
+	// Templates saved before greetings were limited may still be too long for a title
+	title := truncate(strings.TrimSpace(roundupGreeting(group.Month, template)), embedTitleLimit)
+	return Message{Embeds: splitEmbed(title, description, announcementColor)}
+}
+
+func (Embeds) MonthBirthdays(group birthday.MonthGroup) Message {
+	return Message{Embeds: splitEmbed(group.Month.String()+" Birthdays", groupLines(group), listColor)}
+}
+
+// AllBirthdays lists one page of every birthday with a field per month, or
+// several if a month is too long for one
+func (Embeds) AllBirthdays(groups []birthday.MonthGroup, page, pages int) Message {
+	var fields []*discordgo.MessageEmbedField
+	for _, group := range groups {
+		name := group.Month.String()
+		for _, chunk := range interfaces.SplitMessage(groupLines(group), embedFieldLimit) {
+			fields = append(fields, &discordgo.MessageEmbedField{Name: name, Value: chunk})
+			name = group.Month.String() + " (continued)"
+		}
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:  "All Birthdays",
+		Color:  listColor,
+		Fields: fields,
+	}
+	if pages > 1 {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: pageFooter(page, pages)}
+	}
+	return Message{Embeds: []*discordgo.MessageEmbed{embed}}
+}
+
+// Reminder mentions the role in the message content, as mentions in embeds
+// never notify anyone
+func (Embeds) Reminder(reminder birthday.Reminder, roleID string) Message {
+	return Message{
+		Content: roleMention(roleID),
+		Embeds:  splitEmbed("⏰ "+reminderTitle(reminder), reminderLines(reminder), listColor),
+	}
+}
+
+// splitEmbed renders a titled description, continued in further embeds if it
+// is too long for one
+func splitEmbed(title, description string, color int) []*discordgo.MessageEmbed {
+	var embeds []*discordgo.MessageEmbed
+	for i, chunk := range interfaces.SplitMessage(description, embedDescriptionLimit) {
+		embed := &discordgo.MessageEmbed{Description: chunk, Color: color}
+		if i == 0 {
+			embed.Title = title
+		}
+		embeds = append(embeds, embed)
+	}
+	return embeds
+}
+
+// truncate shortens text to at most limit characters, ending it with an
+// ellipsis if it was cut
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,295 @@
+// Package render turns birthday data into the messages the bot posts
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/birthday"
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+)
+
+// BirthdayMessage wishes everyone celebrating today a happy birthday, or
+// returns "" if there is nobody to announce. templates holds the template for
+// each birthday, "" for the default. today is the current time in the guild's
+// timezone.
+func BirthdayMessage(birthdays []database.Birthday, today time.Time, templates []string) string {
+	var buffer bytes.Buffer
+	for _, line := range announcementLines(birthdays, today, templates) {
+		buffer.WriteString(line.text + "\n")
+	}
+
+	return buffer.String()
+}
+
+// announcementLine is one line of a birthday announcement
+type announcementLine struct {
+	birthday *database.Birthday
+	text     string
+}
+
+// announcementLines returns the lines of today's birthday announcement, one per
+// person rendered from their template
+func announcementLines(birthdays []database.Birthday, today time.Time, templates []string) []announcementLine {
+	lines := make([]announcementLine, len(birthdays))
+	for i := range birthdays {
+		template := ""
+		if i < len(templates) {
+			template = templates[i]
+		}
+		lines[i] = announcementLine{
+			birthday: &birthdays[i],
+			text:     renderTemplate(database.AnnouncementBirthday, template, &birthdays[i], today.Month(), today.Year()),
+		}
+	}
+	return lines
+}
+
+// isMilestone reports whether an age gets special wording: 18, 21 and every
+// round decade from 30
+func isMilestone(age int) bool {
+	return age == 18 || age == 21 || (age >= 30 && age%10 == 0)
+}
+
+// MonthBirthdays lists the birthdays celebrated this month under a single
+// header, or returns "" if there are none
+func MonthBirthdays(group birthday.MonthGroup) string {
+	if len(group.Celebrations) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("**%s Birthdays:**\n\n", group.Month.String()) + groupLines(group)
+}
+
+// MonthlyRoundup is the announcement posted on the first of the month. The
+// greeting is rendered from the template, or the default if it is "".
+func MonthlyRoundup(group birthday.MonthGroup, template string) string {
+	if len(group.Celebrations) > 0 {
+		return roundupGreeting(group.Month, template) + " \nBelow are all of the birthdays this month:\n" + groupLines(group)
+	}
+	return roundupGreeting(group.Month, template) + " \n" + noBirthdaysThisMonth
+}
+
+// noBirthdaysThisMonth closes a monthly roundup without any birthdays
+const noBirthdaysThisMonth = "There are no birthdays this month. See you next month! 🫡"
+
+// roundupGreeting opens the monthly roundup
+func roundupGreeting(month time.Month, template string) string {
+	// The year doesn't matter, as monthly templates can't mention ages
+	return renderTemplate(database.AnnouncementMonthly, template, nil, month, 0)
+}
+
+// AllBirthdays lists every birthday with a header per month, or returns "" if
+// there are none
+func AllBirthdays(groups []birthday.MonthGroup) string {
+	if len(groups) == 0 {
+		return ""
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString("**All Birthdays:**\n")
+	for _, group := range groups {
+		buffer.WriteString("\n**" + group.Month.String() + "**\n")
+		buffer.WriteString(groupLines(group))
+	}
+
+	return buffer.String()
+}
+
+// Paginate splits month groups into pages of at most size birthdays whose
+// lines add up to at most length characters. A month that doesn't fit on one
+// page is continued on the next. A single line longer than length gets a page
+// of its own.
+func Paginate(groups []birthday.MonthGroup, size, length int) [][]birthday.MonthGroup {
+	var pages [][]birthday.MonthGroup
+	var page []birthday.MonthGroup
+	count, chars := 0, 0
+	for _, group := range groups {
+		start := 0
+		for i, c := range group.Celebrations {
+			n := len([]rune(celebrationItem(c)))
+			if count > 0 && (count == size || chars+n > length) {
+				if i > start {
+					page = append(page, birthday.MonthGroup{Month: group.Month, Celebrations: group.Celebrations[start:i]})
+				}
+				pages = append(pages, page)
+				page, count, chars, start = nil, 0, 0, i
+			}
+			count++
+			chars += n
+		}
+		if start < len(group.Celebrations) {
+			page = append(page, birthday.MonthGroup{Month: group.Month, Celebrations: group.Celebrations[start:]})
+		}
+	}
+	if len(page) > 0 {
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// pageFooter says which page of a listing is shown
+func pageFooter(page, pages int) string {
+	return fmt.Sprintf("Page %d of %d", page, pages)
+}
+
+// groupLines lists the birthdays in a month as bullet points
+func groupLines(group birthday.MonthGroup) string {
+	var buffer bytes.Buffer
+	for _, c := range group.Celebrations {
+		buffer.WriteString(celebrationItem(c))
+	}
+	return buffer.String()
+}
+
+// celebrationItem is one bullet point of groupLines
+func celebrationItem(c birthday.Celebration) string {
+	return "• " + celebrationLine(c) + "\n"
+}
+
+// celebrationLine describes one birthday. February 29 birthdays moved to
+// another day are marked, today's birthdays are highlighted and birthdays
+// earlier this month are struck through.
+func celebrationLine(c birthday.Celebration) string {
+	dayText := strconv.Itoa(c.Day)
+	if c.Day != c.Birthday.Day {
+		dayText += " (February 29)"
+	}
+	line := fmt.Sprintf("%s, %s %s", c.Name, c.Month.String(), dayText)
+
+	switch {
+	case c.Today:
+		return "🎉 **" + line + "** (today!)"
+	case c.Past:
+		return "~~" + line + "~~"
+	default:
+		return line
+	}
+}
+
+// NextBirthday announces the next birthday, celebrated by everyone in upcoming
+// on the same day, or returns "" if there is none
+func NextBirthday(upcoming []birthday.Upcoming) string {
+	if len(upcoming) == 0 {
+		return ""
+	}
+
+	next := upcoming[0]
+	result := fmt.Sprintf("**Next birthday:** %s on %s %d", next.Name, next.Month.String(), next.Day)
+
+	if next.DaysUntil == 0 {
+		result += " (Today! 🎉)"
+	} else if next.DaysUntil == 1 {
+		result += " (Tomorrow!)"
+	} else {
+		result += fmt.Sprintf(" (in %d days!)", next.DaysUntil)
+	}
+
+	// Other birthdays on the same day
+	for _, u := range upcoming[1:] {
+		if u.DaysUntil == next.DaysUntil {
+			result += fmt.Sprintf("\nAlso: %s", u.Name)
+		}
+	}
+
+	return result
+}
+
+// UpcomingBirthdays lists birthdays under a title, soonest first, with how far
+// away each one is, or returns "" if there are none
+func UpcomingBirthdays(title string, upcoming []birthday.Upcoming) string {
+	if len(upcoming) == 0 {
+		return ""
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString("**" + title + ":**\n\n")
+	for _, u := range upcoming {
+		buffer.WriteString("• " + upcomingLine(u) + "\n")
+	}
+
+	return buffer.String()
+}
+
+// Lookup describes the birthdays found by a search: when each is, how far away
+// it is and the age being turned, if known. More than one match is listed
+// under a header.
+func Lookup(query string, matches []birthday.Upcoming) string {
+	if len(matches) == 1 {
+		return "🎂 " + lookupLine(matches[0])
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("**Birthdays matching \"%s\":**\n\n", query))
+	for _, u := range matches {
+		buffer.WriteString("• " + lookupLine(u) + "\n")
+	}
+	return buffer.String()
+}
+
+// lookupLine describes a birthday found by a search
+func lookupLine(u birthday.Upcoming) string {
+	return upcomingLine(u) + turning(u)
+}
+
+// turning describes the age being turned, or returns "" if it isn't known or is private
+func turning(u birthday.Upcoming) string {
+	if age, ok := u.AgeIn(u.Year); ok && age > 0 && !u.YearPrivate {
+		return fmt.Sprintf(", turning %d", age)
+	}
+	return ""
+}
+
+// Reminder tells organizers about the birthdays some days away. People are
+// named rather than mentioned, so a reminder never notifies them.
+func Reminder(reminder birthday.Reminder) string {
+	return fmt.Sprintf("⏰ **%s:**\n\n", reminderTitle(reminder)) + reminderLines(reminder)
+}
+
+// Notification is the DM sent to a subscriber about the birthdays they subscribed to
+func Notification(notification birthday.Notification) string {
+	return Reminder(notification.Reminder) + "\n_You get these because of /subscribe. Use /unsubscribe to stop them._"
+}
+
+// reminderTitle says how far away a reminder's birthdays are
+func reminderTitle(reminder birthday.Reminder) string {
+	if len(reminder.Birthdays) == 1 {
+		return "Birthday " + daysAway(reminder.DaysBefore)
+	}
+	return "Birthdays " + daysAway(reminder.DaysBefore)
+}
+
+// reminderLines lists a reminder's birthdays with the ages being turned
+func reminderLines(reminder birthday.Reminder) string {
+	var buffer bytes.Buffer
+	for _, u := range reminder.Birthdays {
+		buffer.WriteString("• " + celebrationLine(u.Celebration) + turning(u) + "\n")
+	}
+	return buffer.String()
+}
+
+// roleMention mentions a role, or returns "" if there is none
+func roleMention(roleID string) string {
+	if roleID == "" {
+		return ""
+	}
+	return "<@&" + roleID + ">"
+}
+
+// upcomingLine describes an upcoming birthday and how far away it is
+func upcomingLine(u birthday.Upcoming) string {
+	line := celebrationLine(u.Celebration)
+	if !u.Today {
+		line += " (" + daysAway(u.DaysUntil) + ")"
+	}
+	return line
+}
+
+// daysAway describes how far away a day in the future is
+func daysAway(days int) string {
+	if days == 1 {
+		return "tomorrow"
+	}
+	return fmt.Sprintf("in %d days", days)
+}
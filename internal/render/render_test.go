@@ -0,0 +1,359 @@
+package render_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/birthday"
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+	"github.com/nrzaman/baos-birthday-bot/internal/render"
+)
+
+func TestMonthlyRoundup(t *testing.T) {
+	leah := birthday.Celebration{Birthday: database.Birthday{Name: "Leah", Month: 2, Day: 29}, Month: time.March, Day: 1}
+
+	tests := []struct {
+		name         string
+		month        time.Month
+		celebrations []birthday.Celebration
+		want         []string
+	}{
+		{"January", time.January, nil, []string{"Happy New Year and January! 🎊", "There are no birthdays this month"}},
+		{"No birthdays", time.June, nil, []string{"Happy June! 🙌", "There are no birthdays this month"}},
+		{"Moved leap day birthday", time.March, []birthday.Celebration{leah}, []string{"Happy March! 🙌", "Below are all of the birthdays this month", "Leah, March 1 (February 29)"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			message := render.MonthlyRoundup(birthday.MonthGroup{Month: tt.month, Celebrations: tt.celebrations}, "")
+
+			// Assert
+			for _, want := range tt.want {
+				if !strings.Contains(message, want) {
+					t.Errorf("MonthlyRoundup() = %q; want it to contain %q", message, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMonthBirthdays(t *testing.T) {
+	// Arrange: on March 10th, one birthday has passed and one is today
+	birthdays := []database.Birthday{
+		{Name: "John", Month: 3, Day: 15},
+		{Name: "Alice", Month: 3, Day: 10},
+		{Name: "Bob", Month: 3, Day: 2},
+	}
+	groups := birthday.GroupByMonth(birthdays, time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC), database.LeapDayFeb28)
+
+	// Act
+	message := render.MonthBirthdays(groups[0])
+
+	// Assert
+	want := "**March Birthdays:**\n\n" +
+		"• ~~Bob, March 2~~\n" +
+		"• 🎉 **Alice, March 10** (today!)\n" +
+		"• John, March 15\n"
+	if message != want {
+		t.Errorf("MonthBirthdays() = %q; want %q", message, want)
+	}
+	if empty := render.MonthBirthdays(birthday.MonthGroup{Month: time.March}); empty != "" {
+		t.Errorf("MonthBirthdays() without birthdays = %q; want \"\"", empty)
+	}
+}
+
+func TestAllBirthdays(t *testing.T) {
+	// Act & Assert: nothing to list
+	if message := render.AllBirthdays(nil); message != "" {
+		t.Errorf("AllBirthdays(nil) = %q; want \"\"", message)
+	}
+
+	// Arrange
+	birthdays := []database.Birthday{
+		{Name: "Bob", Month: 12, Day: 2},
+		{Name: "Benjamin", Month: 1, Day: 31},
+		{Name: "Alice", Month: 1, Day: 25},
+	}
+	groups := birthday.GroupByMonth(birthdays, time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC), database.LeapDayFeb28)
+
+	// Act
+	message := render.AllBirthdays(groups)
+
+	// Assert
+	want := "**All Birthdays:**\n" +
+		"\n**January**\n• Alice, January 25\n• Benjamin, January 31\n" +
+		"\n**December**\n• Bob, December 2\n"
+	if message != want {
+		t.Errorf("AllBirthdays() = %q; want %q", message, want)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	// Arrange: 3 birthdays in January, each line 17 characters, and 4 in March,
+	// each line 15 characters
+	var birthdays []database.Birthday
+	for day := 1; day <= 3; day++ {
+		birthdays = append(birthdays, database.Birthday{Name: "Jan", Month: 1, Day: day})
+	}
+	for day := 1; day <= 4; day++ {
+		birthdays = append(birthdays, database.Birthday{Name: "Mar", Month: 3, Day: day})
+	}
+	groups := birthday.GroupByMonth(birthdays, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), database.LeapDayFeb28)
+
+	tests := []struct {
+		name   string
+		size   int
+		length int
+		want   []string
+	}{
+		{"By count", 5, 1000, []string{"January:3,March:2", "March:2"}},
+		{"By length", 25, 50, []string{"January:2", "January:1,March:2", "March:2"}},
+		{"A line longer than a page", 25, 10, []string{"January:1", "January:1", "January:1", "March:1", "March:1", "March:1", "March:1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			pages := render.Paginate(groups, tt.size, tt.length)
+
+			// Assert: a month that doesn't fit is continued on the next page
+			var got []string
+			for _, page := range pages {
+				var months []string
+				for _, group := range page {
+					months = append(months, fmt.Sprintf("%s:%d", group.Month, len(group.Celebrations)))
+				}
+				got = append(got, strings.Join(months, ","))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Paginate() = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbeds_MonthlyRoundup_TruncatesLongGreetings(t *testing.T) {
+	// Arrange: a greeting saved before greetings were limited
+	group := birthday.MonthGroup{Month: time.March}
+	template := strings.Repeat("a", 300)
+
+	// Act
+	message := render.Embeds{}.MonthlyRoundup(group, template)
+
+	// Assert
+	if title := []rune(message.Embeds[0].Title); len(title) != 256 || title[255] != '…' {
+		t.Errorf("Title is %d characters ending in %q; want 256 ending in an ellipsis", len(title), title[len(title)-1])
+	}
+}
+
+func TestEmbeds_AllBirthdays_SplitsLongMonths(t *testing.T) {
+	// Arrange: 25 September birthdays with long names are too long for one field
+	var birthdays []database.Birthday
+	for day := 1; day <= 25; day++ {
+		birthdays = append(birthdays, database.Birthday{Name: strings.Repeat("Christopher Johnson ", 3), Month: 9, Day: day})
+	}
+	groups := birthday.GroupByMonth(birthdays, time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC), database.LeapDayFeb28)
+
+	// Act
+	message := render.Embeds{}.AllBirthdays(groups, 1, 1)
+
+	// Assert
+	fields := message.Embeds[0].Fields
+	if len(fields) < 2 || fields[0].Name != "September" || fields[1].Name != "September (continued)" {
+		t.Fatalf("Expected September to be split over several fields, got %+v", fields)
+	}
+	lines := 0
+	for _, f := range fields {
+		if n := len([]rune(f.Value)); n > 1024 {
+			t.Errorf("Field %q is %d characters; Discord allows 1024", f.Name, n)
+		}
+		lines += strings.Count(f.Value, "\n")
+	}
+	if lines != 25 {
+		t.Errorf("Expected all 25 birthdays across the fields, got %d lines", lines)
+	}
+}
+
+// upcoming returns people celebrating on January 25th in daysUntil days
+func upcoming(daysUntil int, names ...string) []birthday.Upcoming {
+	var result []birthday.Upcoming
+	for _, name := range names {
+		result = append(result, birthday.Upcoming{
+			Celebration: birthday.Celebration{Birthday: database.Birthday{Name: name, Month: 1, Day: 25}, Month: time.January, Day: 25, Today: daysUntil == 0},
+			DaysUntil:   daysUntil,
+		})
+	}
+	return result
+}
+
+func TestNextBirthday(t *testing.T) {
+	tests := []struct {
+		name     string
+		upcoming []birthday.Upcoming
+		want     string
+	}{
+		{"Today", upcoming(0, "Alice"), "**Next birthday:** Alice on January 25 (Today! 🎉)"},
+		{"Tomorrow", upcoming(1, "Alice"), "**Next birthday:** Alice on January 25 (Tomorrow!)"},
+		{"Later", upcoming(10, "Alice"), "**Next birthday:** Alice on January 25 (in 10 days!)"},
+		{"Same day", upcoming(10, "Alice", "Bob"), "**Next birthday:** Alice on January 25 (in 10 days!)\nAlso: Bob"},
+		{"Nobody", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			message := render.NextBirthday(tt.upcoming)
+
+			// Assert
+			if message != tt.want {
+				t.Errorf("NextBirthday() = %q; want %q", message, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpcomingBirthdays(t *testing.T) {
+	// Arrange
+	list := append(upcoming(0, "Alice"), upcoming(1, "Bob")...)
+	list = append(list, upcoming(9, "Carol")...)
+
+	// Act
+	message := render.UpcomingBirthdays("Birthdays This Week", list)
+
+	// Assert
+	want := "**Birthdays This Week:**\n\n" +
+		"• 🎉 **Alice, January 25** (today!)\n" +
+		"• Bob, January 25 (tomorrow)\n" +
+		"• Carol, January 25 (in 9 days)\n"
+	if message != want {
+		t.Errorf("UpcomingBirthdays() = %q; want %q", message, want)
+	}
+	if empty := render.UpcomingBirthdays("Birthdays This Week", nil); empty != "" {
+		t.Errorf("UpcomingBirthdays() without birthdays = %q; want \"\"", empty)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	// Arrange
+	year := 1995
+	alice := upcoming(10, "Alice")[0]
+	alice.Year, alice.Birthday.Year = 2026, &year
+	private := alice
+	private.YearPrivate = true
+
+	tests := []struct {
+		name    string
+		matches []birthday.Upcoming
+		want    string
+	}{
+		{"Age known", []birthday.Upcoming{alice}, "🎂 Alice, January 25 (in 10 days), turning 31"},
+		{"Age private", []birthday.Upcoming{private}, "🎂 Alice, January 25 (in 10 days)"},
+		{"Age unknown, today", upcoming(0, "Bob"), "🎂 🎉 **Bob, January 25** (today!)"},
+		{"Several matches", append(upcoming(1, "Bob"), alice), "**Birthdays matching \"b\":**\n\n• Bob, January 25 (tomorrow)\n• Alice, January 25 (in 10 days), turning 31\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			message := render.Lookup("b", tt.matches)
+
+			// Assert
+			if message != tt.want {
+				t.Errorf("Lookup() = %q; want %q", message, tt.want)
+			}
+		})
+	}
+}
+
+func TestReminder(t *testing.T) {
+	// Arrange
+	year := 1995
+	alice := upcoming(7, "Alice")[0]
+	alice.Year, alice.Birthday.Year = 2026, &year
+	discordID := "42"
+	alice.DiscordID = &discordID
+
+	tests := []struct {
+		name     string
+		reminder birthday.Reminder
+		roleID   string
+		want     string
+	}{
+		{"One birthday", birthday.Reminder{DaysBefore: 7, Birthdays: []birthday.Upcoming{alice}}, "",
+			"⏰ **Birthday in 7 days:**\n\n• Alice, January 25, turning 31\n"},
+		{"Several birthdays with a role", birthday.Reminder{DaysBefore: 1, Birthdays: append(upcoming(1, "Bob"), upcoming(1, "Carol")...)}, "888",
+			"<@&888> ⏰ **Birthdays tomorrow:**\n\n• Bob, January 25\n• Carol, January 25\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			message := render.PlainText{}.Reminder(tt.reminder, tt.roleID)
+
+			// Assert
+			if message.Content != tt.want {
+				t.Errorf("Reminder() = %q; want %q", message.Content, tt.want)
+			}
+		})
+	}
+
+	// Embeds mention the role in the content, and nobody is ever mentioned
+	embeds := render.Embeds{}.Reminder(tests[0].reminder, "888")
+	if embeds.Content != "<@&888>" || len(embeds.Embeds) != 1 || strings.Contains(embeds.Embeds[0].Description, "<@42>") {
+		t.Errorf("Embeds.Reminder() = %+v; want the role in the content and Alice named in an embed", embeds)
+	}
+}
+
+func TestEmbeds_MonthlyRoundup(t *testing.T) {
+	// Arrange
+	group := birthday.MonthGroup{Month: time.March, Celebrations: []birthday.Celebration{
+		{Birthday: database.Birthday{Name: "Leah", Month: 2, Day: 29}, Month: time.March, Day: 1},
+		{Birthday: database.Birthday{Name: "John", Month: 3, Day: 15}, Month: time.March, Day: 15},
+	}}
+
+	// Act
+	message := render.Embeds{}.MonthlyRoundup(group, "")
+
+	// Assert
+	if len(message.Embeds) != 1 {
+		t.Fatalf("Expected 1 embed, got %d", len(message.Embeds))
+	}
+	embed := message.Embeds[0]
+	if embed.Title != "Happy March! 🙌" {
+		t.Errorf("Title = %q; want the monthly greeting", embed.Title)
+	}
+	want := "• Leah, March 1 (February 29)\n• John, March 15\n"
+	if !strings.HasSuffix(embed.Description, want) {
+		t.Errorf("Description = %q; want it to list %q", embed.Description, want)
+	}
+}
+
+func TestEmbeds_BirthdayAnnouncement(t *testing.T) {
+	// Arrange
+	discordID := "42"
+	birthdays := []database.Birthday{
+		{Name: "John", Month: 3, Day: 15, DiscordID: &discordID},
+		{Name: "Alice", Month: 3, Day: 15},
+	}
+	avatars := map[string]string{"42": "https://cdn.example/42.png"}
+
+	// Act
+	message := render.Embeds{}.BirthdayAnnouncement(birthdays, time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC), avatars, nil)
+
+	// Assert
+	if message.Content != "<@42>" {
+		t.Errorf("Content = %q; want John's mention", message.Content)
+	}
+	if len(message.Embeds) != 2 {
+		t.Fatalf("Expected an embed per person, got %d", len(message.Embeds))
+	}
+	if thumbnail := message.Embeds[0].Thumbnail; thumbnail == nil || thumbnail.URL != avatars["42"] {
+		t.Errorf("Expected John's avatar, got %+v", thumbnail)
+	}
+	if !strings.Contains(message.Embeds[1].Description, "**Alice's birthday**") || message.Embeds[1].Thumbnail != nil {
+		t.Errorf("Expected Alice's embed without an avatar, got %+v", message.Embeds[1])
+	}
+}
@@ -0,0 +1,75 @@
+package render
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/nrzaman/baos-birthday-bot/internal/birthday"
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+)
+
+// Message is a rendered message: text, embeds or both, optionally with buttons
+type Message struct {
+	Content    string
+	Embeds     []*discordgo.MessageEmbed
+	Components []discordgo.MessageComponent
+}
+
+// Text returns a message that is only text
+func Text(content string) Message {
+	return Message{Content: content}
+}
+
+// Renderer turns service results into the messages the bot posts. Embeds is
+// used in production; PlainText renders the same information as markdown,
+// which is easier to read in tests.
+type Renderer interface {
+	// BirthdayAnnouncement wishes everyone celebrating today a happy birthday.
+	// avatars maps Discord user IDs to avatar URLs. templates holds the
+	// template for each birthday, "" for the default.
+	BirthdayAnnouncement(birthdays []database.Birthday, today time.Time, avatars map[string]string, templates []string) Message
+
+	// MonthlyRoundup is the announcement posted on the first of the month.
+	// template is the guild's greeting template, or "" for the default.
+	MonthlyRoundup(group birthday.MonthGroup, template string) Message
+
+	// MonthBirthdays lists the birthdays celebrated this month
+	MonthBirthdays(group birthday.MonthGroup) Message
+
+	// AllBirthdays lists one page of every birthday, grouped by month. page
+	// counts from 1.
+	AllBirthdays(groups []birthday.MonthGroup, page, pages int) Message
+
+	// Reminder tells organizers about the birthdays some days away,
+	// mentioning the role if roleID isn't empty
+	Reminder(reminder birthday.Reminder, roleID string) Message
+}
+
+// PlainText renders messages as markdown text
+type PlainText struct{}
+
+func (PlainText) BirthdayAnnouncement(birthdays []database.Birthday, today time.Time, avatars map[string]string, templates []string) Message {
+	return Text(BirthdayMessage(birthdays, today, templates))
+}
+
+func (PlainText) MonthlyRoundup(group birthday.MonthGroup, template string) Message {
+	return Text(MonthlyRoundup(group, template))
+}
+
+func (PlainText) MonthBirthdays(group birthday.MonthGroup) Message {
+	return Text(MonthBirthdays(group))
+}
+
+func (PlainText) AllBirthdays(groups []birthday.MonthGroup, page, pages int) Message {
+	if pages > 1 {
+		return Text(AllBirthdays(groups) + "\n_" + pageFooter(page, pages) + "_")
+	}
+	return Text(AllBirthdays(groups))
+}
+
+func (PlainText) Reminder(reminder birthday.Reminder, roleID string) Message {
+	if roleID == "" {
+		return Text(Reminder(reminder))
+	}
+	return Text(roleMention(roleID) + " " + Reminder(reminder))
+}
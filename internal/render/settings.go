@@ -0,0 +1,141 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+)
+
+// Settings describes a guild's announcement settings
+func Settings(guild database.Guild) string {
+	channel := "not set (announcements are off)"
+	if guild.ChannelID != "" {
+		channel = "<#" + guild.ChannelID + ">"
+	}
+	return fmt.Sprintf("**Birthday settings:**\n• Channel: %s\n• Time: %02d:%02d\n• Timezone: %s\n• February 29 birthdays in non-leap years: %s",
+		channel, guild.Hour, guild.Minute, guild.Timezone, LeapDayName(guild.LeapDayPolicy))
+}
+
+// LeapDayName describes the day a leap day policy celebrates February 29 birthdays on
+func LeapDayName(policy string) string {
+	if policy == database.LeapDayMar1 {
+		return "March 1"
+	}
+	return "February 28"
+}
+
+// History describes the announcements in the ledger, newest first
+func History(announcements []database.Announcement) string {
+	if len(announcements) == 0 {
+		return "No announcements have been posted yet."
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString("**Recent announcements:**\n")
+	for _, a := range announcements {
+		buffer.WriteString(fmt.Sprintf("• %s: %s (posted %s UTC)\n",
+			a.Date, announcementDescription(a.Kind), a.CreatedAt.UTC().Format("2006-01-02 15:04")))
+	}
+	return buffer.String()
+}
+
+// announcementDescription returns a readable name for a kind of announcement
+func announcementDescription(kind string) string {
+	switch kind {
+	case database.AnnouncementMonthly:
+		return "monthly roundup"
+	case database.AnnouncementBirthday:
+		return "birthday message"
+	case database.AnnouncementNotifications:
+		return "subscriber DMs"
+	}
+	if days, ok := database.ReminderDays(kind); ok {
+		return "reminder " + dayCount(days) + " ahead"
+	}
+	return kind
+}
+
+// Overrides describes a guild's custom messages for special dates
+func Overrides(overrides []database.Override) string {
+	if len(overrides) == 0 {
+		return "No custom messages have been set."
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString("**Custom messages:**\n")
+	for _, o := range overrides {
+		buffer.WriteString(fmt.Sprintf("• %s %d, %s: %s\n", time.Month(o.Month), o.Day, OverrideTarget(o.Name), o.Message))
+	}
+	return buffer.String()
+}
+
+// OverrideTarget describes who an override is for
+func OverrideTarget(name string) string {
+	if name == "" {
+		return "everyone"
+	}
+	return "**" + name + "**"
+}
+
+// ReminderSettings describes a guild's advance reminders
+func ReminderSettings(settings database.ReminderSettings) string {
+	if len(settings.Days) == 0 {
+		return "Birthday reminders are off."
+	}
+
+	days := make([]string, len(settings.Days))
+	for i, d := range settings.Days {
+		days[i] = dayCount(d)
+	}
+	channel := "none, so no reminders are sent"
+	if settings.ChannelID != "" {
+		channel = "<#" + settings.ChannelID + ">"
+	}
+	role := "nobody"
+	if settings.RoleID != "" {
+		role = roleMention(settings.RoleID)
+	}
+	return fmt.Sprintf("**Birthday reminders:**\n• Before each birthday: %s\n• Channel: %s\n• Mentions: %s",
+		strings.Join(days, ", "), channel, role)
+}
+
+// dayCount describes a number of days, e.g. "1 day" or "7 days"
+func dayCount(days int) string {
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
+
+// Subscriptions describes the birthdays a member gets a DM about, and why the
+// last DM could not be sent if it wasn't
+func Subscriptions(subscriptions []database.Subscription) string {
+	if len(subscriptions) == 0 {
+		return "You aren't subscribed to any birthdays. Use /subscribe to get a DM the day before one."
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString("**You get a DM the day before:**\n")
+	for _, s := range subscriptions {
+		buffer.WriteString("• " + SubscriptionTarget(s.Name) + "\n")
+	}
+	for _, s := range subscriptions {
+		if s.Failure != "" {
+			buffer.WriteString(fmt.Sprintf("\n⚠️ The last DM could not be sent (%s UTC): %s",
+				s.FailedAt.UTC().Format("2006-01-02 15:04"), s.Failure))
+			break
+		}
+	}
+	return buffer.String()
+}
+
+// SubscriptionTarget describes which birthdays a subscription is for
+func SubscriptionTarget(name string) string {
+	if name == "" {
+		return "every birthday in this server"
+	}
+	return "**" + name + "**'s birthday"
+}
@@ -0,0 +1,310 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/birthday"
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+)
+
+// Announcement templates are Go text/template text. They are given no data,
+// only these functions:
+//
+//	name       the person's name
+//	mention    a mention of the person if their Discord account is linked, or their name
+//	pronoun    the person's pronoun in the given form: "subject", "object",
+//	           "possessive" or "reflexive"
+//	age        the age the person is turning, or 0 if it isn't known or is private
+//	milestone  whether the age is a milestone: 18, 21 or a round decade from 30
+//	month      the name of the month being celebrated
+//
+// Monthly roundup templates greet the month, so only month is available in them.
+// With no data to loop over, range is not allowed.
+
+// MaxTemplateLength is the most characters a template may have
+const MaxTemplateLength = 1000
+
+// MaxRenderedLength is the most characters a template may render, so that
+// templates calling each other can't blow up into many messages
+const MaxRenderedLength = 2000
+
+// MaxTemplateSteps is the most actions a template may run, counting those of
+// the templates it calls each time they are called, so that templates that
+// render little or nothing can't keep the bot busy either
+const MaxTemplateSteps = 10000
+
+// MaxGreetingLength is the most characters a monthly roundup greeting may
+// render, as it is the title of the roundup embed
+const MaxGreetingLength = embedTitleLimit
+
+// errTooLong stops a template that renders more than MaxRenderedLength characters
+var errTooLong = fmt.Errorf("the template renders more than %d characters", MaxRenderedLength)
+
+// Default templates, used until a guild sets its own
+const (
+	defaultBirthdayTemplate = `Today is **{{mention}}'s birthday**! 🎉` +
+		`{{if age}}{{if milestone}} {{name}} is turning **{{age}}**, a milestone birthday! 🥳{{else}} {{name}} is turning {{age}}!{{end}}{{end}}` +
+		` Please wish {{pronoun "object"}} a happy birthday and make {{pronoun "possessive"}} day! 🎂`
+	defaultMonthlyTemplate = `{{if eq month "January"}}Happy New Year and January! 🎊{{else}}Happy {{month}}! 🙌{{end}}`
+)
+
+// errBirthdayOnly is returned by the person functions in monthly templates
+var errBirthdayOnly = errors.New("is only available in birthday templates")
+
+// DefaultTemplate returns the template used for a kind of announcement when a
+// guild hasn't set its own
+func DefaultTemplate(kind string) string {
+	if kind == database.AnnouncementMonthly {
+		return defaultMonthlyTemplate
+	}
+	return defaultBirthdayTemplate
+}
+
+// Preview renders a template for a kind of announcement against a sample
+// person celebrating today, returning an error wrapping
+// database.ErrInvalidValue if it is not a usable template
+func Preview(kind, text string, today time.Time) (string, error) {
+	if kind != database.AnnouncementBirthday && kind != database.AnnouncementMonthly {
+		return "", fmt.Errorf("%w: unknown kind of announcement %q", database.ErrInvalidValue, kind)
+	}
+	if n := len([]rune(text)); n > MaxTemplateLength {
+		return "", fmt.Errorf("%w: the template is %d characters long; the most allowed is %d", database.ErrInvalidValue, n, MaxTemplateLength)
+	}
+
+	year := today.Year() - 30
+	sample := database.Birthday{Name: "Alex", Month: int(today.Month()), Day: today.Day(), Year: &year}
+
+	if kind == database.AnnouncementMonthly {
+		// The greeting is a title, so it must fit in every month
+		for month := time.January; month <= time.December; month++ {
+			greeting, err := executeTemplate(text, nil, month, today.Year())
+			if err != nil {
+				return "", err
+			}
+			if n := len([]rune(strings.TrimSpace(greeting))); n > MaxGreetingLength {
+				return "", fmt.Errorf("%w: the greeting for %s is %d characters long; the most allowed is %d", database.ErrInvalidValue, month, n, MaxGreetingLength)
+			}
+		}
+		group := birthday.MonthGroup{Month: today.Month(), Celebrations: []birthday.Celebration{
+			{Birthday: sample, Month: today.Month(), Day: today.Day(), Today: true},
+		}}
+		return MonthlyRoundup(group, text), nil
+	}
+
+	line, err := executeTemplate(text, &sample, today.Month(), today.Year())
+	if err != nil {
+		return "", err
+	}
+	return line, nil
+}
+
+// executeTemplate renders a template for a person celebrating in the given
+// month and year, or for a monthly roundup if person is nil. Errors wrap
+// database.ErrInvalidValue.
+func executeTemplate(text string, person *database.Birthday, month time.Month, year int) (string, error) {
+	tmpl, err := template.New("announcement").Funcs(templateFuncs(person, month, year)).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", database.ErrInvalidValue, err)
+	}
+	if err := checkSteps(tmpl); err != nil {
+		return "", fmt.Errorf("%w: %v", database.ErrInvalidValue, err)
+	}
+
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&limitedWriter{buffer: &buffer, limit: MaxRenderedLength}, nil); err != nil {
+		if errors.Is(err, errTooLong) {
+			return "", fmt.Errorf("%w: %v", database.ErrInvalidValue, errTooLong)
+		}
+		return "", fmt.Errorf("%w: %v", database.ErrInvalidValue, err)
+	}
+	if strings.TrimSpace(buffer.String()) == "" {
+		return "", fmt.Errorf("%w: the template renders an empty message", database.ErrInvalidValue)
+	}
+	return buffer.String(), nil
+}
+
+// checkSteps rejects a template that could run more than MaxTemplateSteps
+// actions. Templates are given no data, so range has nothing useful to loop
+// over and only serves to spin (e.g. {{range 100000}} from Go 1.22), and is
+// rejected along with templates that call themselves. Without those, the
+// actions run are at most those in the template with each template call
+// expanded, so they can be counted without running it.
+func checkSteps(tmpl *template.Template) error {
+	steps, err := countSteps(tmpl, tmpl.Tree.Root, make(map[string]int))
+	if err != nil {
+		return err
+	}
+	if steps > MaxTemplateSteps {
+		return fmt.Errorf("the template runs more than %d actions", MaxTemplateSteps)
+	}
+	return nil
+}
+
+// countSteps returns how many actions a node runs at most, stopping once that
+// is more than MaxTemplateSteps. called holds the steps of the templates
+// counted so far, or -1 for those being counted.
+func countSteps(tmpl *template.Template, node parse.Node, called map[string]int) (int, error) {
+	var children []*parse.ListNode
+	switch node := node.(type) {
+	case *parse.ListNode:
+		children = []*parse.ListNode{node}
+	case *parse.IfNode:
+		children = []*parse.ListNode{node.List, node.ElseList}
+	case *parse.WithNode:
+		children = []*parse.ListNode{node.List, node.ElseList}
+	case *parse.RangeNode:
+		return 0, errors.New("templates can't use range")
+	case *parse.TemplateNode:
+		steps, ok := called[node.Name]
+		if steps < 0 {
+			return 0, fmt.Errorf("template %q calls itself", node.Name)
+		}
+		if !ok {
+			callee := tmpl.Lookup(node.Name)
+			if callee == nil || callee.Tree == nil {
+				return 1, nil // Fails when run
+			}
+			called[node.Name] = -1
+			var err error
+			if steps, err = countSteps(tmpl, callee.Tree.Root, called); err != nil {
+				return 0, err
+			}
+			called[node.Name] = steps
+		}
+		return 1 + steps, nil
+	}
+
+	steps := 1
+	for _, list := range children {
+		if list == nil {
+			continue
+		}
+		for _, child := range list.Nodes {
+			n, err := countSteps(tmpl, child, called)
+			if err != nil {
+				return 0, err
+			}
+			if steps += n; steps > MaxTemplateSteps {
+				return steps, nil
+			}
+		}
+	}
+	return steps, nil
+}
+
+// limitedWriter writes to a buffer until it holds more than limit characters,
+// then fails with errTooLong, which stops the template
+type limitedWriter struct {
+	buffer *bytes.Buffer
+	limit  int
+	count  int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	w.count += utf8.RuneCount(p)
+	if w.count > w.limit {
+		return 0, errTooLong
+	}
+	return w.buffer.Write(p)
+}
+
+// renderTemplate renders a template, falling back to the default template for
+// the kind of announcement if it is empty or fails
+func renderTemplate(kind, text string, person *database.Birthday, month time.Month, year int) string {
+	if text != "" {
+		result, err := executeTemplate(text, person, month, year)
+		if err == nil {
+			return result
+		}
+		fmt.Printf("Error rendering %s template, using the default: %v\n", kind, err)
+	}
+	result, err := executeTemplate(DefaultTemplate(kind), person, month, year)
+	if err != nil {
+		// The default templates always render
+		panic(err)
+	}
+	return result
+}
+
+// templateFuncs returns the functions available in templates about a person
+// celebrating in the given month and year, or in monthly templates if person
+// is nil
+func templateFuncs(person *database.Birthday, month time.Month, year int) template.FuncMap {
+	funcs := template.FuncMap{
+		"month": func() string { return month.String() },
+	}
+
+	// personFunc wraps a function that needs a person to describe
+	personFunc := func(name string, f func(b *database.Birthday) string) func() (string, error) {
+		return func() (string, error) {
+			if person == nil {
+				return "", fmt.Errorf("%s %w", name, errBirthdayOnly)
+			}
+			return f(person), nil
+		}
+	}
+	funcs["name"] = personFunc("name", func(b *database.Birthday) string { return b.Name })
+	funcs["mention"] = personFunc("mention", func(b *database.Birthday) string { return b.Mention() })
+
+	age := func() (int, error) {
+		if person == nil {
+			return 0, fmt.Errorf("age %w", errBirthdayOnly)
+		}
+		if person.YearPrivate {
+			return 0, nil
+		}
+		age, ok := person.AgeIn(year)
+		if !ok || age < 1 {
+			return 0, nil
+		}
+		return age, nil
+	}
+	funcs["age"] = age
+	funcs["milestone"] = func() (bool, error) {
+		n, err := age()
+		return n > 0 && isMilestone(n), err
+	}
+	funcs["pronoun"] = func(form string) (string, error) {
+		if person == nil {
+			return "", fmt.Errorf("pronoun %w", errBirthdayOnly)
+		}
+		pronoun, ok := person.PronounSet().Form(form)
+		if !ok {
+			return "", fmt.Errorf("unknown pronoun form %q: use %q, %q, %q or %q", form,
+				database.PronounSubject, database.PronounObject, database.PronounPossessive, database.PronounReflexive)
+		}
+		return pronoun, nil
+	}
+	return funcs
+}
+
+// TemplatePreviews renders each of a guild's templates for a kind of
+// announcement against a sample person, returning an error wrapping
+// database.ErrInvalidValue if any of them is not usable
+func TemplatePreviews(kind string, templates []string, today time.Time) (string, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("**Preview of the %s:**\n", announcementDescription(kind)))
+	for i, text := range templates {
+		preview, err := Preview(kind, text, today)
+		if err != nil {
+			if len(templates) > 1 {
+				return "", fmt.Errorf("variant %d: %w", i+1, err)
+			}
+			return "", err
+		}
+		if len(templates) > 1 {
+			buffer.WriteString(fmt.Sprintf("\n_Variant %d:_\n", i+1))
+		} else {
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString(strings.TrimRight(preview, "\n") + "\n")
+	}
+	return buffer.String(), nil
+}
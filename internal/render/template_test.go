@@ -0,0 +1,105 @@
+package render_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nrzaman/baos-birthday-bot/internal/database"
+	"github.com/nrzaman/baos-birthday-bot/internal/render"
+)
+
+func TestBirthdayMessage_Templates(t *testing.T) {
+	male, female, year, discordID := database.HeHim, database.SheHer, 1995, "42"
+	birthdays := []database.Birthday{
+		{Name: "John", Month: 3, Day: 15, Pronouns: &male, Year: &year, DiscordID: &discordID},
+		{Name: "Alice", Month: 3, Day: 15, Pronouns: &female},
+	}
+	today := time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"Default", "", "Today is **<@42>'s birthday**! 🎉 John is turning **30**, a milestone birthday! 🥳 Please wish him a happy birthday and make his day! 🎂\n" +
+			"Today is **Alice's birthday**! 🎉 Please wish her a happy birthday and make her day! 🎂\n"},
+		{"Functions", `{{name}} ({{mention}}, {{pronoun "subject"}}/{{pronoun "object"}}/{{pronoun "possessive"}}/{{pronoun "reflexive"}}) turns {{if age}}{{age}}{{else}}a year older{{end}} this {{month}}`,
+			"John (<@42>, he/him/his/himself) turns 30 this March\nAlice (Alice, she/her/her/herself) turns a year older this March\n"},
+		{"Broken template falls back to the default", `{{pronoun "sideways"}}`, "Today is **<@42>'s birthday**! 🎉 John is turning **30**"},
+		{"Missing template uses the default", "", "Today is **<@42>'s birthday**!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			message := render.BirthdayMessage(birthdays, today, []string{tt.template, tt.template})
+
+			// Assert
+			if !strings.HasPrefix(message, tt.want) {
+				t.Errorf("BirthdayMessage() = %q; want it to start with %q", message, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreview(t *testing.T) {
+	today := time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		kind    string
+		text    string
+		want    string
+		wantErr string
+	}{
+		{"Birthday", database.AnnouncementBirthday, "Happy {{age}}th, {{mention}}!", "Happy 30th, Alex!", ""},
+		{"Monthly", database.AnnouncementMonthly, "Hello {{month}}", "Hello March \nBelow are all of the birthdays this month:\n• 🎉 **Alex, March 15** (today!)\n", ""},
+		{"Syntax error", database.AnnouncementBirthday, "{{if age}}", "", "unexpected EOF"},
+		{"Unknown function", database.AnnouncementBirthday, "{{birthday}}", "", `function "birthday" not defined`},
+		{"Person in a monthly template", database.AnnouncementMonthly, "{{mention}}", "", "mention is only available in birthday templates"},
+		{"Renders nothing", database.AnnouncementBirthday, "{{if false}}hi{{end}}", "", "renders an empty message"},
+		{"Too long", database.AnnouncementBirthday, strings.Repeat("a", render.MaxTemplateLength+1), "", "the most allowed is"},
+		{"Unknown kind", "weekly", "Hi", "", "unknown kind of announcement"},
+		{"Greeting too long for a title", database.AnnouncementMonthly, "{{if eq month \"September\"}}" + strings.Repeat("a", render.MaxGreetingLength) + "{{end}}Hi",
+			"", "the greeting for September is 258 characters long"},
+		{"Renders too much", database.AnnouncementBirthday,
+			`{{define "a"}}{{name}}{{name}}{{name}}{{name}}{{end}}{{define "b"}}{{template "a"}}{{template "a"}}{{template "a"}}{{template "a"}}{{end}}` +
+				`{{define "c"}}{{template "b"}}{{template "b"}}{{template "b"}}{{template "b"}}{{end}}` +
+				`{{define "d"}}{{template "c"}}{{template "c"}}{{template "c"}}{{template "c"}}{{end}}{{template "d"}}{{template "d"}}{{template "d"}}{{template "d"}}`,
+			"", "renders more than 2000 characters"},
+		{"Loops", database.AnnouncementBirthday, "{{range 100000}}{{end}}Hi", "", "templates can't use range"},
+		{"Loops over a variable", database.AnnouncementBirthday, "{{$n := 100000}}{{range $n}}{{end}}Hi", "", "templates can't use range"},
+		{"Calls itself", database.AnnouncementBirthday, `{{define "a"}}{{if false}}{{template "a"}}{{end}}{{end}}{{template "a"}}Hi`, "", `template "a" calls itself`},
+		{"Runs too long without rendering", database.AnnouncementBirthday,
+			`{{define "a"}}{{if false}}{{end}}{{if false}}{{end}}{{if false}}{{end}}{{if false}}{{end}}{{end}}` +
+				`{{define "b"}}{{template "a"}}{{template "a"}}{{template "a"}}{{template "a"}}{{end}}` +
+				`{{define "c"}}{{template "b"}}{{template "b"}}{{template "b"}}{{template "b"}}{{end}}` +
+				`{{define "d"}}{{template "c"}}{{template "c"}}{{template "c"}}{{template "c"}}{{end}}` +
+				`{{define "e"}}{{template "d"}}{{template "d"}}{{template "d"}}{{template "d"}}{{end}}` +
+				`{{define "f"}}{{template "e"}}{{template "e"}}{{template "e"}}{{template "e"}}{{end}}{{template "f"}}{{template "f"}}Hi`,
+			"", "runs more than 10000 actions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			preview, err := render.Preview(tt.kind, tt.text, today)
+
+			// Assert
+			if tt.wantErr != "" {
+				if !errors.Is(err, database.ErrInvalidValue) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Preview() error = %v; want ErrInvalidValue containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Preview() returned error: %v", err)
+			}
+			if preview != tt.want {
+				t.Errorf("Preview() = %q; want %q", preview, tt.want)
+			}
+		})
+	}
+}
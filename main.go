@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -12,6 +13,7 @@ import (
 	"github.com/nrzaman/baos-birthday-bot/internal/database"
 	bot "github.com/nrzaman/baos-birthday-bot/internal/discord"
 	"github.com/nrzaman/baos-birthday-bot/internal/interfaces"
+	"github.com/nrzaman/baos-birthday-bot/internal/leader"
 	"github.com/nrzaman/baos-birthday-bot/internal/providers"
 )
 
@@ -23,10 +25,8 @@ func main() {
 		log.Fatal("DISCORD_BIRTHDAY_BOT_TOKEN environment variable is required")
 	}
 
-	generalChannelID := os.Getenv("DISCORD_BIRTHDAY_CHANNEL_ID")
-	if generalChannelID == "" {
-		log.Fatal("DISCORD_BIRTHDAY_CHANNEL_ID environment variable is required")
-	}
+	// Optional: single-server deployments from before multi-guild support
+	legacyChannelID := os.Getenv("DISCORD_BIRTHDAY_CHANNEL_ID")
 
 	// Create real implementations of our dependencies
 	timeProvider := &providers.RealTimeProvider{}
@@ -62,17 +62,37 @@ func main() {
 	// Wrap Discord session in our interface
 	discordClient := &interfaces.DiscordSession{Session: session}
 
+	// Create the worker first so /config changes can reschedule it
+	worker := bot.NewWorker(discordClient, birthdayService, timeProvider)
+
+	// Optional: only the elected replica posts announcements
+	elector, err := newElector(os.Getenv("LEADER_ELECTION"), db, timeProvider)
+	if err != nil {
+		log.Fatalf("Failed to set up leader election: %v", err)
+	}
+	if elector != nil {
+		worker.SetElector(elector)
+	}
+
 	// Create handler with dependencies
 	handler := bot.NewHandler(discordClient, birthdayService)
+	handler.SetRescheduler(worker)
 
-	// Register slash command handler
+	// Register slash command and button handlers
 	session.AddHandler(handler.HandleSlashCommand)
+	session.AddHandler(handler.HandleMessageComponent)
 
 	// Open websocket connection
 	if err := session.Open(); err != nil {
 		log.Fatalf("Failed to open connection: %v", err)
 	}
 
+	if legacyChannelID != "" {
+		if err := configureLegacyChannel(session, db, legacyChannelID); err != nil {
+			log.Printf("Warning: Failed to configure DISCORD_BIRTHDAY_CHANNEL_ID: %v", err)
+		}
+	}
+
 	// Register slash commands globally (works in all servers)
 	fmt.Println("Registering slash commands...")
 	if err := bot.RegisterGlobalCommands(session); err != nil {
@@ -80,11 +100,10 @@ func main() {
 		log.Println("Slash commands may not work, but legacy !commands will still work")
 	} else {
 		fmt.Println("Slash commands registered successfully!")
-		fmt.Println("Available commands: /month, /all, /next")
+		fmt.Println("Available commands: /month, /all, /next, /upcoming, /birthday add|edit|remove|lookup, /setmybirthday, /forgetme, /config, /history, /template, /override, /reminders, /subscribe, /unsubscribe, /subscriptions")
 	}
 
 	// Start worker in background
-	worker := bot.NewWorker(discordClient, birthdayService, timeProvider, generalChannelID)
 	go worker.Start()
 
 	// Wait for termination signal
@@ -105,3 +124,80 @@ func main() {
 	}
 	fmt.Println("Bot terminated.")
 }
+
+// newElector creates the leader elector selected by LEADER_ELECTION: "sqlite"
+// (a lease in the shared database), "kubernetes" (a Lease object, named by
+// LEADER_ELECTION_LEASE) or empty for a single replica that always leads
+func newElector(mode string, db *database.DB, timeProvider interfaces.TimeProvider) (leader.Elector, error) {
+	if mode == "" || mode == "none" {
+		return nil, nil
+	}
+
+	// The pod name identifies the replica; POD_NAME is set by the Helm chart
+	identity := os.Getenv("POD_NAME")
+	if identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hostname: %w", err)
+		}
+		identity = hostname
+	}
+
+	switch mode {
+	case "sqlite":
+		return leader.NewSQLiteElector(db, timeProvider, identity, leader.DefaultLeaseDuration), nil
+	case "kubernetes":
+		leaseName := os.Getenv("LEADER_ELECTION_LEASE")
+		if leaseName == "" {
+			leaseName = "baos-birthday-bot"
+		}
+		config, err := leader.InClusterConfig(leaseName, identity)
+		if err != nil {
+			return nil, err
+		}
+		return leader.NewKubernetesElector(config, timeProvider), nil
+	default:
+		return nil, fmt.Errorf("unknown LEADER_ELECTION mode %q: use sqlite or kubernetes", mode)
+	}
+}
+
+// configureLegacyChannel keeps deployments that set DISCORD_BIRTHDAY_CHANNEL_ID
+// working: the channel's guild is configured to announce there (unless it already
+// has settings), and birthdays imported before guild support are moved into it.
+func configureLegacyChannel(session *discordgo.Session, db *database.DB, channelID string) error {
+	channel, err := session.Channel(channelID)
+	if err != nil {
+		return fmt.Errorf("failed to look up channel %s: %w", channelID, err)
+	}
+	if channel.GuildID == "" {
+		return fmt.Errorf("channel %s is not in a server", channelID)
+	}
+
+	ctx := context.Background()
+	guild, err := db.GetGuild(ctx, channel.GuildID)
+	if err != nil {
+		return err
+	}
+	if guild == nil {
+		timezone := os.Getenv("TZ")
+		if timezone == "" {
+			timezone = "UTC"
+		}
+		settings := database.DefaultGuild(channel.GuildID)
+		settings.ChannelID = channelID
+		settings.Timezone = timezone
+		if err := db.UpsertGuild(ctx, settings); err != nil {
+			return err
+		}
+		fmt.Printf("Configured server %s to announce in channel %s (%s)\n", channel.GuildID, channelID, timezone)
+	}
+
+	moved, err := db.AssignUnscopedBirthdays(ctx, channel.GuildID)
+	if err != nil {
+		return err
+	}
+	if moved > 0 {
+		fmt.Printf("Assigned %d existing birthdays to server %s\n", moved, channel.GuildID)
+	}
+	return nil
+}
@@ -1,16 +1,22 @@
 package util
 
-// Birthday A struct containing the month and day of a birthday.
+// Birthday A struct containing the month and day of a birthday, and optionally
+// the year of birth.
 type Birthday struct {
-	Month int `json:"Month"`
-	Day   int `json:"Day"`
+	Month int  `json:"Month"`
+	Day   int  `json:"Day"`
+	Year  *int `json:"Year,omitempty"`
 }
 
-// Person A struct containing the person's first name and birthday.
+// Person A struct containing the person's first name and birthday. HideAge
+// keeps the year of birth out of announcements. Pronouns are given as a preset
+// such as "she/her" or as all four forms; older files give a Gender instead.
 type Person struct {
 	Name     string   `json:"Name"`
 	Birthday Birthday `json:"Birthday"`
-	Gender   *string  `json:"Gender"`
+	Pronouns *string  `json:"Pronouns,omitempty"`
+	Gender   *string  `json:"Gender,omitempty"`
+	HideAge  bool     `json:"HideAge,omitempty"`
 }
 
 // People A struct containing an array of persons (includes their first name